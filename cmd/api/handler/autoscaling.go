@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+)
+
+func CreateAutoscalingPolicy(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.TokenInvalid, nil)
+		return
+	}
+	req := request.AutoscalingPolicyRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	policy := &model.AutoscalingPolicy{
+		ClusterName:      req.ClusterName,
+		PolicyName:       req.PolicyName,
+		PolicyType:       req.PolicyType,
+		MetricSource:     req.MetricSource,
+		MetricName:       req.MetricName,
+		MetricQuery:      req.MetricQuery,
+		TargetValue:      req.TargetValue,
+		MinCount:         req.MinCount,
+		MaxCount:         req.MaxCount,
+		ScaleOutCooldown: req.ScaleOutCooldown,
+		ScaleInCooldown:  req.ScaleInCooldown,
+		Enabled:          true,
+		CreateBy:         user.Name,
+		UpdateBy:         user.Name,
+	}
+	if len(req.Steps) > 0 {
+		policy.Steps, _ = jsoniter.MarshalToString(req.Steps)
+	}
+	err = service.CreateAutoscalingPolicy(ctx, policy)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, cast.ToString(policy.Id))
+}
+
+func ListAutoscalingPolicies(ctx *gin.Context) {
+	clusterName := ctx.Query("cluster_name")
+	if clusterName == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	policies, err := service.ListAutoscalingPolicies(ctx, clusterName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToAutoscalingPolicyList(policies))
+}
+
+func EnableAutoscalingPolicy(ctx *gin.Context) {
+	setAutoscalingPolicyEnabled(ctx, true)
+}
+
+func DisableAutoscalingPolicy(ctx *gin.Context) {
+	setAutoscalingPolicyEnabled(ctx, false)
+}
+
+func setAutoscalingPolicyEnabled(ctx *gin.Context, enabled bool) {
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.SetAutoscalingPolicyEnabled(ctx, id, enabled)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
+
+func DeleteAutoscalingPolicy(ctx *gin.Context) {
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.DeleteAutoscalingPolicy(ctx, id)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
+
+func ListAutoscalingDecisions(ctx *gin.Context) {
+	clusterName := ctx.Query("cluster_name")
+	if clusterName == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	pn, ps := getPager(ctx)
+	decisions, total, err := service.ListAutoscalingDecisions(ctx, clusterName, pn, ps)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, &response.AutoscalingDecisionListResponse{
+		DecisionList: helper.ConvertToAutoscalingDecisionList(decisions),
+		Pager: response.Pager{
+			PageNumber: pn,
+			PageSize:   ps,
+			Total:      int(total),
+		},
+	})
+}
+
+func PushMetric(ctx *gin.Context) {
+	req := request.PushMetricRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.PushMetric(ctx, req.ClusterName, req.MetricName, req.Value)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
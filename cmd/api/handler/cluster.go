@@ -2,16 +2,20 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/galaxy-future/BridgX/cmd/api/helper"
 	"github.com/galaxy-future/BridgX/cmd/api/request"
 	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
 	"github.com/gin-gonic/gin"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/cast"
@@ -40,6 +44,7 @@ func GetClusterById(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
+	resp.Password = ""
 	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
 	return
 }
@@ -145,6 +150,7 @@ func GetClusterByName(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
+	resp.Password = ""
 	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
 	return
 }
@@ -161,6 +167,10 @@ func CreateCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
 		return
 	}
+	if clusterInput.Password == "" && clusterInput.KeyPairName == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, "missing password or key pair", nil)
+		return
+	}
 	m, err := convertToClusterModel(&clusterInput)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), err)
@@ -224,8 +234,60 @@ func convertToClusterModel(clusterInput *types.ClusterInfo) (*model.Cluster, err
 	if clusterInput.StorageConfig == nil {
 		return nil, errors.New("missing storage config")
 	}
+	if err := checkZones(clusterInput); err != nil {
+		return nil, err
+	}
 	nc, _ := jsoniter.MarshalToString(clusterInput.NetworkConfig)
 	sc, _ := jsoniter.MarshalToString(clusterInput.StorageConfig)
+	if err := checkChargeConfig(clusterInput); err != nil {
+		return nil, err
+	}
+	var charge string
+	if clusterInput.ChargeType == cloud.InstanceChargeTypePrePaid {
+		charge, _ = jsoniter.MarshalToString(clusterInput.ChargeConfig)
+	}
+	if err := service.CheckUserDataTemplate(clusterInput.UserData); err != nil {
+		return nil, fmt.Errorf("invalid user data: %w", err)
+	}
+	var spot string
+	if clusterInput.SpotConfig != nil {
+		if err := checkSpotConfig(clusterInput.SpotConfig); err != nil {
+			return nil, err
+		}
+		spot, _ = jsoniter.MarshalToString(clusterInput.SpotConfig)
+	}
+	var instanceTypeConfig string
+	if clusterInput.InstanceTypeConfig != nil {
+		if err := checkInstanceTypeConfig(clusterInput.InstanceTypeConfig); err != nil {
+			return nil, err
+		}
+		instanceTypeConfig, _ = jsoniter.MarshalToString(clusterInput.InstanceTypeConfig)
+	}
+	var terminationConfig string
+	if clusterInput.TerminationConfig != nil {
+		if err := checkTerminationConfig(clusterInput.TerminationConfig); err != nil {
+			return nil, err
+		}
+		terminationConfig, _ = jsoniter.MarshalToString(clusterInput.TerminationConfig)
+	}
+	var lifecycleConfig string
+	if clusterInput.LifecycleConfig != nil {
+		if err := checkLifecycleConfig(clusterInput.LifecycleConfig); err != nil {
+			return nil, err
+		}
+		lifecycleConfig, _ = jsoniter.MarshalToString(clusterInput.LifecycleConfig)
+	}
+	var healthCheckConfig string
+	if clusterInput.HealthCheckConfig != nil {
+		if err := checkHealthCheckConfig(clusterInput.HealthCheckConfig); err != nil {
+			return nil, err
+		}
+		healthCheckConfig, _ = jsoniter.MarshalToString(clusterInput.HealthCheckConfig)
+	}
+	//使用密钥对登录时不保存密码
+	if clusterInput.KeyPairName != "" {
+		clusterInput.Password = ""
+	}
 	m := model.Cluster{
 		ClusterName:  clusterInput.Name,
 		ClusterDesc:  clusterInput.Desc,
@@ -235,15 +297,192 @@ func convertToClusterModel(clusterInput *types.ClusterInfo) (*model.Cluster, err
 		ChargeType:   clusterInput.ChargeType,
 		Image:        clusterInput.Image,
 		Password:     clusterInput.Password,
+		KeyPairName:  clusterInput.KeyPairName,
 		Provider:     clusterInput.Provider,
 		AccountKey:   clusterInput.AccountKey,
 
 		NetworkConfig: nc,
 		StorageConfig: sc,
+		ChargeConfig:  charge,
+		SpotConfig:    spot,
+		UserData:      clusterInput.UserData,
+
+		InstanceTypeConfig: instanceTypeConfig,
+		TerminationConfig:  terminationConfig,
+		LifecycleConfig:    lifecycleConfig,
+		HealthCheckConfig:  healthCheckConfig,
 	}
 	return &m, nil
 }
 
+// maxPeriod 包年包月各时长单位允许的最大购买时长
+var maxPeriod = map[string]int{
+	cloud.PeriodUnitWeek:  4,
+	cloud.PeriodUnitMonth: 60,
+	cloud.PeriodUnitYear:  5,
+}
+
+func checkChargeConfig(clusterInput *types.ClusterInfo) error {
+	switch clusterInput.ChargeType {
+	case "", cloud.InstanceChargeTypePostPaid:
+		return nil
+	case cloud.InstanceChargeTypePrePaid:
+	default:
+		return errors.New("invalid charge type")
+	}
+	cc := clusterInput.ChargeConfig
+	if cc == nil {
+		return errors.New("missing charge config for PrePaid cluster")
+	}
+	max, ok := maxPeriod[cc.PeriodUnit]
+	if !ok {
+		return errors.New("invalid period unit")
+	}
+	if cc.Period <= 0 || cc.Period > max {
+		return errors.New("invalid period")
+	}
+	if clusterInput.SpotConfig != nil {
+		return errors.New("spot config is not allowed for PrePaid cluster")
+	}
+	return nil
+}
+
+// checkZones 配置了多可用区时, 集群的 zone_id 和 subnet_id 默认取第一个可用区
+func checkZones(clusterInput *types.ClusterInfo) error {
+	zones := clusterInput.NetworkConfig.Zones
+	if len(zones) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if zone.ZoneId == "" || zone.SubnetId == "" {
+			return errors.New("zone_id and subnet_id are required in zones")
+		}
+		if zone.Weight < 0 {
+			return errors.New("invalid zone weight")
+		}
+		if seen[zone.ZoneId] {
+			return fmt.Errorf("duplicate zone %s", zone.ZoneId)
+		}
+		seen[zone.ZoneId] = true
+	}
+	if clusterInput.ZoneId == "" {
+		clusterInput.ZoneId = zones[0].ZoneId
+	}
+	if clusterInput.NetworkConfig.SubnetId == "" {
+		clusterInput.NetworkConfig.SubnetId = zones[0].SubnetId
+	}
+	return nil
+}
+
+func checkInstanceTypeConfig(config *types.InstanceTypeConfig) error {
+	for _, instanceType := range config.InstanceTypes {
+		if instanceType == "" {
+			return errors.New("empty instance type in instance_type_config")
+		}
+	}
+	return nil
+}
+
+// terminationPolicies 可以配置的终止策略
+var terminationPolicies = map[string]bool{
+	constants.TerminationZoneBalanced:         true,
+	constants.TerminationOldestFirst:          true,
+	constants.TerminationNewestFirst:          true,
+	constants.TerminationClosestToBillingHour: true,
+	constants.TerminationDeprecatedFirst:      true,
+	constants.TerminationPendingFirst:         true,
+}
+
+func checkTerminationConfig(config *types.TerminationConfig) error {
+	seen := make(map[string]bool, len(config.Policies))
+	for _, policy := range config.Policies {
+		if !terminationPolicies[policy] {
+			return fmt.Errorf("invalid termination policy %s", policy)
+		}
+		if seen[policy] {
+			return fmt.Errorf("duplicate termination policy %s", policy)
+		}
+		seen[policy] = true
+	}
+	return nil
+}
+
+func checkLifecycleConfig(config *types.LifecycleConfig) error {
+	if hook := config.PreTerminate; hook != nil {
+		if hook.WebhookUrl == "" {
+			return errors.New("pre_terminate hook needs webhook_url")
+		}
+		if hook.HealthCheckPort != 0 {
+			return errors.New("health_check_port is only for post_launch hook")
+		}
+		if err := checkLifecycleHook(hook); err != nil {
+			return fmt.Errorf("invalid pre_terminate hook: %w", err)
+		}
+	}
+	if hook := config.PostLaunch; hook != nil {
+		if hook.WebhookUrl == "" && hook.HealthCheckPort == 0 {
+			return errors.New("post_launch hook needs webhook_url or health_check_port")
+		}
+		if hook.HealthCheckPort < 0 || hook.HealthCheckPort > 65535 {
+			return errors.New("invalid health_check_port")
+		}
+		if err := checkLifecycleHook(hook); err != nil {
+			return fmt.Errorf("invalid post_launch hook: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkLifecycleHook(hook *types.LifecycleHook) error {
+	if hook.WebhookUrl != "" {
+		u, err := url.Parse(hook.WebhookUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhook_url must be an http or https url")
+		}
+	}
+	if hook.TimeoutSeconds < 0 || hook.TimeoutSeconds > constants.MaxLifecycleHookTimeout {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", constants.MaxLifecycleHookTimeout)
+	}
+	switch hook.DefaultResult {
+	case "", constants.LifecycleResultContinue, constants.LifecycleResultAbandon:
+		return nil
+	}
+	return fmt.Errorf("invalid default_result %s", hook.DefaultResult)
+}
+
+func checkHealthCheckConfig(config *types.HealthCheckConfig) error {
+	switch config.Type {
+	case constants.HealthCheckTCP, constants.HealthCheckHTTP:
+		if config.Port <= 0 || config.Port > 65535 {
+			return errors.New("invalid health check port")
+		}
+	case constants.HealthCheckCloud:
+	default:
+		return fmt.Errorf("invalid health check type %s", config.Type)
+	}
+	if config.Type == constants.HealthCheckHTTP && !strings.HasPrefix(config.Path, "/") {
+		return errors.New("health check path must start with /")
+	}
+	if config.TimeoutSeconds < 0 || config.UnhealthyThreshold < 0 || config.GracePeriodSeconds < 0 || config.MaxHealPerHour < 0 {
+		return errors.New("health check timeout, threshold, grace period and max heal per hour can not be negative")
+	}
+	if config.MaxUnhealthyPercent < 0 || config.MaxUnhealthyPercent > 100 {
+		return errors.New("max_unhealthy_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+func checkSpotConfig(spot *types.SpotConfig) error {
+	if spot.Strategy != constants.SpotOnly && spot.Strategy != constants.SpotWithFallback {
+		return errors.New("invalid spot strategy")
+	}
+	if spot.PriceLimit < 0 {
+		return errors.New("invalid spot price limit")
+	}
+	return nil
+}
+
 func AddClusterTags(ctx *gin.Context) {
 	req := request.AddTagRequest{}
 	err := ctx.Bind(&req)
@@ -298,7 +537,17 @@ func ExpandCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
 		return
 	}
-	taskId, err := service.CreateExpandTask(ctx, req.ClusterName, req.Count, req.TaskName, user.UserId)
+	if req.DryRun {
+		plan, err := service.PlanExpand(ctx, req.ClusterName, req.Count)
+		if err != nil {
+			response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToExpandPlan(plan))
+		return
+	}
+	//声明式模式下同步调整期望数量, 否则调度器会把实例数量收敛回原来的期望值
+	taskId, err := service.CreateExpandTaskWithExpectCount(ctx, req.ClusterName, req.Count, req.TaskName, user.UserId)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
@@ -307,6 +556,69 @@ func ExpandCluster(ctx *gin.Context) {
 	return
 }
 
+//RollingUpdateCluster 创建滚动更新任务, 把规格或镜像不是集群当前配置的实例分批替换为新实例
+func RollingUpdateCluster(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.RollingUpdateRequest{}
+	err := ctx.Bind(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.CheckRollingUpdate(ctx, req.ClusterName)
+	if errors.Is(err, service.ErrNoOutdatedInstances) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	info := &model.RollingUpdateTaskInfo{
+		ClusterName:    req.ClusterName,
+		BatchSize:      req.BatchSize,
+		MaxUnavailable: req.MaxUnavailable,
+		UserId:         user.UserId,
+	}
+	if info.BatchSize == 0 {
+		info.BatchSize = constants.DefaultRollingUpdateBatchSize
+	}
+	info.MaxSurge = info.BatchSize
+	if req.MaxSurge != nil {
+		info.MaxSurge = *req.MaxSurge
+	}
+	taskId, err := service.CreateRollingUpdateTask(ctx, info, req.TaskName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, taskId)
+}
+
+func SetExpectCount(ctx *gin.Context) {
+	req := request.SetExpectInstanceCountRequest{}
+	err := ctx.Bind(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	declarative := true
+	if req.Declarative != nil {
+		declarative = *req.Declarative
+	}
+	err = service.SetExpectCount(ctx, req.ClusterName, req.ExpectCount, declarative)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
 func ShrinkCluster(ctx *gin.Context) {
 	user := helper.GetUserClaims(ctx)
 	if user == nil {
@@ -319,7 +631,16 @@ func ShrinkCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
 		return
 	}
-	taskId, err := service.CreateShrinkTask(ctx, req.ClusterName, req.Count, strings.Join(req.IPs, ","), req.TaskName, user.UserId)
+	if req.DryRun {
+		plan, err := service.PlanShrink(ctx, req.ClusterName, req.Count, req.IPs)
+		if err != nil {
+			response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToShrinkPlan(plan))
+		return
+	}
+	taskId, err := service.CreateShrinkTaskWithExpectCount(ctx, req.ClusterName, req.Count, strings.Join(req.IPs, ","), req.TaskName, user.UserId)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
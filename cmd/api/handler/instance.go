@@ -1,15 +1,19 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
 	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
 )
 
 func GetInstanceCount(ctx *gin.Context) {
@@ -57,6 +61,60 @@ func GetInstance(ctx *gin.Context) {
 	return
 }
 
+//ProtectInstances 开启实例的缩容保护, 缩容时不释放
+func ProtectInstances(ctx *gin.Context) {
+	setInstancesProtected(ctx, true)
+}
+
+//UnprotectInstances 关闭实例的缩容保护
+func UnprotectInstances(ctx *gin.Context) {
+	setInstancesProtected(ctx, false)
+}
+
+func setInstancesProtected(ctx *gin.Context, protected bool) {
+	req := request.InstanceIdsRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v protected:%v", req, protected)
+	err = service.SetInstancesProtected(ctx, req.InstanceIds, protected)
+	if errors.Is(err, service.ErrInstanceNotFound) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
+
+//GetInstanceHealth 查询实例的健康状态和最近的健康事件
+func GetInstanceHealth(ctx *gin.Context) {
+	instanceId := ctx.Query("instance_id")
+	if instanceId == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	limit := cast.ToInt(ctx.Query("limit"))
+	if limit <= 0 || limit > constants.MaxInstanceHealthEvents {
+		limit = constants.MaxInstanceHealthEvents
+	}
+	instance, err := service.GetInstance(ctx, instanceId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	events, err := service.GetInstanceHealthEvents(ctx, instanceId, limit)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToInstanceHealth(instance, events))
+}
+
 func GetInstanceList(ctx *gin.Context) {
 	user := helper.GetUserClaims(ctx)
 	if user == nil {
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateKeyPair(ctx *gin.Context) {
+	req := request.KeyPairRequest{}
+	err := ctx.Bind(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	provider, err := getAccountProvider(ctx, req.AccountKey)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	resp, err := service.CreateKeyPair(ctx, service.KeyPairRequest{
+		Provider:    provider,
+		RegionId:    req.RegionId,
+		Ak:          req.AccountKey,
+		KeyPairName: req.KeyPairName,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.CreateKeyPairResponse{
+		KeyPairId:   resp.KeyPairId,
+		KeyPairName: resp.KeyPairName,
+		PrivateKey:  resp.PrivateKey,
+	})
+}
+
+func ImportKeyPair(ctx *gin.Context) {
+	req := request.KeyPairRequest{}
+	err := ctx.Bind(&req)
+	if err != nil || !req.Check() || req.PublicKey == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	provider, err := getAccountProvider(ctx, req.AccountKey)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	resp, err := service.ImportKeyPair(ctx, service.KeyPairRequest{
+		Provider:    provider,
+		RegionId:    req.RegionId,
+		Ak:          req.AccountKey,
+		KeyPairName: req.KeyPairName,
+		PublicKey:   req.PublicKey,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.KeyPairThumb{
+		KeyPairId:   resp.KeyPairId,
+		KeyPairName: resp.KeyPairName,
+	})
+}
+
+func ListKeyPairs(ctx *gin.Context) {
+	ak := ctx.Query("account_key")
+	regionId := ctx.Query("region_id")
+	if ak == "" || regionId == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("ak:[%s] regionId:[%s]", ak, regionId)
+	provider, err := getAccountProvider(ctx, ak)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	keyPairs, err := service.ListKeyPairs(ctx, service.KeyPairRequest{
+		Provider: provider,
+		RegionId: regionId,
+		Ak:       ak,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	res := make([]response.KeyPairThumb, 0, len(keyPairs))
+	for _, kp := range keyPairs {
+		res = append(res, response.KeyPairThumb{
+			KeyPairId:   kp.KeyPairId,
+			KeyPairName: kp.KeyPairName,
+			Fingerprint: kp.Fingerprint,
+		})
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, res)
+}
+
+func DeleteKeyPair(ctx *gin.Context) {
+	req := request.KeyPairRequest{
+		AccountKey:  ctx.Query("account_key"),
+		RegionId:    ctx.Query("region_id"),
+		KeyPairName: ctx.Param("name"),
+	}
+	if !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	provider, err := getAccountProvider(ctx, req.AccountKey)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	err = service.DeleteKeyPair(ctx, service.KeyPairRequest{
+		Provider:    provider,
+		RegionId:    req.RegionId,
+		Ak:          req.AccountKey,
+		KeyPairName: req.KeyPairName,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
+
+//getAccountProvider 校验云账户属于当前用户所在的组织, 返回账户对应的云厂商
+func getAccountProvider(ctx *gin.Context, ak string) (string, error) {
+	account, err := GetOrgKeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, info := range account.Info {
+		if info.AK == ak {
+			return info.Provider, nil
+		}
+	}
+	return "", errors.New(response.PermissionDenied)
+}
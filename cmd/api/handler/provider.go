@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func ListProviders(ctx *gin.Context) {
+	providers := service.ListProviders()
+	res := make([]response.ProviderThumb, 0, len(providers))
+	for _, p := range providers {
+		res = append(res, response.ProviderThumb{
+			Provider:      p.Name,
+			DefaultRegion: p.DefaultRegion,
+		})
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, res)
+}
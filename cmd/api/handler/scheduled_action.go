@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+func CreateScheduledAction(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.TokenInvalid, nil)
+		return
+	}
+	req := request.ScheduledActionRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	action := &model.ScheduledAction{
+		ClusterName: req.ClusterName,
+		ActionName:  req.ActionName,
+		Cron:        req.Cron,
+		Timezone:    req.Timezone,
+		ExpectCount: req.ExpectCount,
+		CreateBy:    user.Name,
+		UpdateBy:    user.Name,
+	}
+	err = service.CreateScheduledAction(ctx, action)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, cast.ToString(action.Id))
+}
+
+func ListScheduledActions(ctx *gin.Context) {
+	clusterName := ctx.Query("cluster_name")
+	if clusterName == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	actions, err := service.ListScheduledActions(ctx, clusterName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToScheduledActionList(actions))
+}
+
+func PauseScheduledAction(ctx *gin.Context) {
+	setScheduledActionStatus(ctx, constants.ScheduledActionStatusPaused)
+}
+
+func ResumeScheduledAction(ctx *gin.Context) {
+	setScheduledActionStatus(ctx, constants.ScheduledActionStatusEnable)
+}
+
+func setScheduledActionStatus(ctx *gin.Context, status string) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.TokenInvalid, nil)
+		return
+	}
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.SetScheduledActionStatus(ctx, id, status, user.Name)
+	if errors.Is(err, service.ErrScheduledActionNotFound) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
+
+func DeleteScheduledAction(ctx *gin.Context) {
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	err = service.DeleteScheduledAction(ctx, id)
+	if errors.Is(err, service.ErrScheduledActionNotFound) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+}
@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
 	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cast"
 )
@@ -45,10 +52,210 @@ func GetTaskDescribe(ctx *gin.Context) {
 		return
 	}
 	resp := helper.ConvertToTaskDetail(instances, task)
+	chain, err := service.GetTaskRetryChain(ctx, task)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if resp != nil && len(chain) > 1 {
+		resp.RetryChain = helper.ConvertToRetryChain(chain)
+	}
+	position, err := service.GetTaskQueuePosition(ctx, task)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if resp != nil {
+		resp.QueuePosition = position
+	}
 	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
 	return
 }
 
+func CancelTask(ctx *gin.Context) {
+	req := request.TaskIdRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	status, err := service.CancelTask(ctx, cast.ToInt64(req.TaskId))
+	if errors.Is(err, service.ErrTaskNotCancellable) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.CancelTaskResponse{
+		TaskId:     req.TaskId,
+		TaskStatus: status,
+	})
+}
+
+//PauseTask 暂停滚动更新任务, 正在替换的批次完成后停止
+func PauseTask(ctx *gin.Context) {
+	setTaskPaused(ctx, true)
+}
+
+//ResumeTask 继续执行暂停的任务
+func ResumeTask(ctx *gin.Context) {
+	setTaskPaused(ctx, false)
+}
+
+func setTaskPaused(ctx *gin.Context, paused bool) {
+	req := request.TaskIdRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	status := constants.TaskStatusPaused
+	if paused {
+		err = service.PauseTask(ctx, cast.ToInt64(req.TaskId))
+	} else {
+		status = constants.TaskStatusRunning
+		err = service.ResumeTask(ctx, cast.ToInt64(req.TaskId))
+	}
+	if errors.Is(err, service.ErrTaskNotPausable) || errors.Is(err, service.ErrTaskNotPaused) {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.CancelTaskResponse{
+		TaskId:     req.TaskId,
+		TaskStatus: status,
+	})
+}
+
+func RetryTask(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.TaskIdRequest{}
+	err := ctx.BindJSON(&req)
+	if err != nil || !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	logs.Logger.Infof("req is:%v ", req)
+	taskId, err := service.RetryTask(ctx, cast.ToInt64(req.TaskId), user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.RetryTaskResponse{
+		TaskId:       cast.ToString(taskId),
+		ParentTaskId: req.TaskId,
+	})
+}
+
+//GetTaskQueue 按执行顺序查看集群排队中的任务
+func GetTaskQueue(ctx *gin.Context) {
+	clusterName, ok := ctx.GetQuery("cluster_name")
+	if !ok || clusterName == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	tasks, err := service.ListQueuedTasks(ctx, clusterName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.QueuedTaskListResponse{
+		ClusterName: clusterName,
+		TaskList:    helper.ConvertToQueuedTaskList(tasks),
+	})
+}
+
+//GetTaskEvents 查询任务的步骤事件和进度, 请求头 Accept 为 text/event-stream 时以 SSE 持续推送直到任务结束
+func GetTaskEvents(ctx *gin.Context) {
+	taskId := cast.ToInt64(ctx.Param("id"))
+	if taskId <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	task, err := service.GetTask(ctx, cast.ToString(taskId))
+	if err != nil || task == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.TaskNotFound, nil)
+		return
+	}
+	afterId := cast.ToInt64(ctx.Query("after_id"))
+	if strings.Contains(ctx.GetHeader("Accept"), "text/event-stream") {
+		//断线重连时浏览器通过 Last-Event-ID 带上最后收到的事件id
+		if lastId := ctx.GetHeader("Last-Event-ID"); lastId != "" {
+			afterId = cast.ToInt64(lastId)
+		}
+		streamTaskEvents(ctx, taskId, afterId)
+		return
+	}
+	events, err := service.GetTaskEvents(ctx, taskId, afterId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, response.TaskEventListResponse{
+		TaskId:     cast.ToString(task.Id),
+		TaskStatus: task.Status,
+		Progress:   helper.ConvertToTaskProgress(task),
+		EventList:  helper.ConvertToTaskEventList(events),
+	})
+}
+
+//streamTaskEvents 定期查询新事件和进度并推送, 任务结束后再多查询一次, 避免漏掉结束时写入的事件
+func streamTaskEvents(ctx *gin.Context, taskId, afterId int64) {
+	ticker := time.NewTicker(constants.DefaultTaskEventPollInterval * time.Second)
+	defer ticker.Stop()
+	var progress *response.TaskProgress
+	finished := false
+	ctx.Stream(func(w io.Writer) bool {
+		task, err := service.GetTask(ctx, cast.ToString(taskId))
+		if err != nil {
+			ctx.SSEvent("error", err.Error())
+			return false
+		}
+		events, err := service.GetTaskEvents(ctx, taskId, afterId)
+		if err != nil {
+			ctx.SSEvent("error", err.Error())
+			return false
+		}
+		for _, event := range events {
+			ctx.Render(-1, sse.Event{
+				Id:    cast.ToString(event.Id),
+				Event: "task_event",
+				Data:  helper.ConvertToTaskEventThumb(event),
+			})
+			afterId = event.Id
+		}
+		current := helper.ConvertToTaskProgress(task)
+		if progress == nil || *progress != current {
+			progress = &current
+			ctx.SSEvent("progress", current)
+		}
+		if len(events) == constants.MaxTaskEventsPerQuery {
+			return true
+		}
+		if finished {
+			ctx.SSEvent("end", task.Status)
+			return false
+		}
+		finished = service.IsTaskFinished(task)
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
 func GetTaskDescribeAll(ctx *gin.Context) {
 	user := helper.GetUserClaims(ctx)
 	if user == nil {
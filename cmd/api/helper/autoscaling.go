@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+)
+
+func ConvertToAutoscalingPolicyList(policies []model.AutoscalingPolicy) []response.AutoscalingPolicyThumb {
+	res := make([]response.AutoscalingPolicyThumb, 0, len(policies))
+	for _, policy := range policies {
+		steps := make([]types.ScalingStep, 0)
+		if policy.Steps != "" {
+			_ = jsoniter.UnmarshalFromString(policy.Steps, &steps)
+		}
+		res = append(res, response.AutoscalingPolicyThumb{
+			Id:               cast.ToString(policy.Id),
+			ClusterName:      policy.ClusterName,
+			PolicyName:       policy.PolicyName,
+			PolicyType:       policy.PolicyType,
+			MetricSource:     policy.MetricSource,
+			MetricName:       policy.MetricName,
+			MetricQuery:      policy.MetricQuery,
+			TargetValue:      policy.TargetValue,
+			Steps:            steps,
+			MinCount:         policy.MinCount,
+			MaxCount:         policy.MaxCount,
+			ScaleOutCooldown: policy.ScaleOutCooldown,
+			ScaleInCooldown:  policy.ScaleInCooldown,
+			Enabled:          policy.Enabled,
+			LastScaleAt:      getStringTime(policy.LastScaleAt),
+			CreateAt:         getStringTime(policy.CreateAt),
+			CreateBy:         policy.CreateBy,
+		})
+	}
+	return res
+}
+
+func ConvertToAutoscalingDecisionList(decisions []model.AutoscalingDecision) []response.AutoscalingDecisionThumb {
+	res := make([]response.AutoscalingDecisionThumb, 0, len(decisions))
+	for _, decision := range decisions {
+		res = append(res, response.AutoscalingDecisionThumb{
+			PolicyId:     cast.ToString(decision.PolicyId),
+			MetricValue:  decision.MetricValue,
+			CurrentCount: decision.CurrentCount,
+			DesiredCount: decision.DesiredCount,
+			Action:       decision.Action,
+			TaskId:       cast.ToString(decision.TaskId),
+			Reason:       decision.Reason,
+			CreateAt:     getStringTime(decision.CreateAt),
+		})
+	}
+	return res
+}
@@ -25,17 +25,19 @@ func ConvertToInstanceThumbList(ctx context.Context, instances []model.Instance,
 			startupTime = int(instance.RunningAt.Sub(*instance.CreateAt).Seconds())
 		}
 		r := response.InstanceThumb{
-			InstanceId:    instance.InstanceId,
-			IpInner:       instance.IpInner,
-			IpOuter:       instance.IpOuter,
-			Provider:      getProvider(instance.ClusterName, clusterMap),
-			ClusterName:   instance.ClusterName,
-			InstanceType:  getInstanceType(instance.ClusterName, clusterMap),
-			LoginName:     getLoginName(instance.ClusterName, clusterMap),
-			LoginPassword: getLoginPassword(instance.ClusterName, clusterMap),
-			CreateAt:      instance.CreateAt.String(),
-			Status:        getStringStatus(instance.Status),
-			StartupTime:   startupTime,
+			InstanceId:   instance.InstanceId,
+			IpInner:      instance.IpInner,
+			IpOuter:      instance.IpOuter,
+			Provider:     getProvider(instance.ClusterName, clusterMap),
+			ClusterName:  instance.ClusterName,
+			InstanceType: getInstanceType(instance, clusterMap),
+			LoginName:    getLoginName(instance.ClusterName, clusterMap),
+			KeyPairName:  getKeyPairName(instance.ClusterName, clusterMap),
+			CreateAt:     instance.CreateAt.String(),
+			Status:       getStringStatus(instance.Status),
+			StartupTime:  startupTime,
+			Protected:    instance.Protected,
+			HealthStatus: instance.HealthStatus,
 		}
 		ret = append(ret, r)
 	}
@@ -54,16 +56,20 @@ func getLoginName(clusterName string, m map[string]model.Cluster) string {
 	return "root"
 }
 
-func getLoginPassword(clusterName string, m map[string]model.Cluster) string {
+func getKeyPairName(clusterName string, m map[string]model.Cluster) string {
 	cluster, ok := m[clusterName]
 	if ok {
-		return cluster.Password
+		return cluster.KeyPairName
 	}
 	return ""
 }
 
-func getInstanceType(clusterName string, m map[string]model.Cluster) string {
-	cluster, ok := m[clusterName]
+//getInstanceType 优先使用实例实际的规格, 记录规格前创建的实例使用集群规格
+func getInstanceType(instance model.Instance, m map[string]model.Cluster) string {
+	if instance.InstanceType != "" {
+		return instance.InstanceType
+	}
+	cluster, ok := m[instance.ClusterName]
 	if ok {
 		return cluster.InstanceType
 	}
@@ -107,6 +113,9 @@ func ConvertToInstanceUsageList(ctx context.Context, instances []model.Instance)
 			StartupTime:  int(startupTime),
 			InstanceType: instanceType,
 		}
+		if instance.InstanceType != "" {
+			r.InstanceType = instance.InstanceType
+		}
 		ret = append(ret, r)
 	}
 	return ret
@@ -128,6 +137,11 @@ func ConvertToInstanceDetail(ctx context.Context, instance *model.Instance) (*re
 		CreateAt:      instance.CreateAt.String(),
 		StorageConfig: parseStorageConfig(cluster.StorageConfig),
 		NetworkConfig: parseNetworkConfig(cluster.NetworkConfig),
+		Protected:     instance.Protected,
+		HealthStatus:  instance.HealthStatus,
+	}
+	if instance.InstanceType != "" {
+		ret.InstanceType = instance.InstanceType
 	}
 	return &ret, nil
 }
@@ -188,3 +202,22 @@ func getStringStatus(s constants.Status) string {
 	}
 	return ""
 }
+
+func ConvertToInstanceHealth(instance *model.Instance, events []model.InstanceHealthEvent) *response.InstanceHealthResponse {
+	res := &response.InstanceHealthResponse{
+		InstanceId:     instance.InstanceId,
+		HealthStatus:   instance.HealthStatus,
+		HealthFailures: instance.HealthFailures,
+		HealthCheckAt:  getStringTime(instance.HealthCheckAt),
+		EventList:      make([]response.InstanceHealthEventThumb, 0, len(events)),
+	}
+	for _, event := range events {
+		res.EventList = append(res.EventList, response.InstanceHealthEventThumb{
+			EventId:   cast.ToString(event.Id),
+			EventType: event.EventType,
+			Message:   event.Message,
+			CreateAt:  getStringTime(event.CreateAt),
+		})
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/service"
+)
+
+func ConvertToExpandPlan(plan *service.ExpandPlan) response.ExpandPlanResponse {
+	zones := make([]response.ExpandZonePlan, 0, len(plan.Zones))
+	for _, zone := range plan.Zones {
+		zones = append(zones, response.ExpandZonePlan{
+			ZoneId:           zone.ZoneId,
+			SubnetId:         zone.SubnetId,
+			InstanceType:     zone.InstanceType,
+			Candidates:       zone.Candidates,
+			Count:            zone.Count,
+			AvailableIpCount: zone.AvailableIpCount,
+		})
+	}
+	return response.ExpandPlanResponse{
+		ClusterName: plan.ClusterName,
+		Count:       plan.Count,
+		Feasible:    service.PlanFeasible(plan.Checks),
+		ZoneList:    zones,
+		CheckList:   convertToPlanChecks(plan.Checks),
+	}
+}
+
+func ConvertToShrinkPlan(plan *service.ShrinkPlan) response.ShrinkPlanResponse {
+	instances := make([]response.ShrinkPlanInstance, 0, len(plan.Instances))
+	for _, instance := range plan.Instances {
+		instances = append(instances, response.ShrinkPlanInstance{
+			InstanceId:   instance.InstanceId,
+			IpInner:      instance.IpInner,
+			IpOuter:      instance.IpOuter,
+			ZoneId:       instance.ZoneId,
+			InstanceType: instance.InstanceType,
+			CreateAt:     getStringTime(instance.CreateAt),
+		})
+	}
+	return response.ShrinkPlanResponse{
+		ClusterName:         plan.ClusterName,
+		Count:               plan.Count,
+		Feasible:            service.PlanFeasible(plan.Checks),
+		TerminationPolicies: plan.TerminationPolicies,
+		InstanceList:        instances,
+		NotFoundIps:         plan.NotFoundIps,
+		CheckList:           convertToPlanChecks(plan.Checks),
+	}
+}
+
+func convertToPlanChecks(checks []service.PlanCheck) []response.PlanCheck {
+	res := make([]response.PlanCheck, 0, len(checks))
+	for _, check := range checks {
+		res = append(res, response.PlanCheck{
+			Name:    check.Name,
+			ZoneId:  check.ZoneId,
+			Result:  check.Result,
+			Message: check.Message,
+		})
+	}
+	return res
+}
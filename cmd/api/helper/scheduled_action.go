@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/spf13/cast"
+)
+
+func ConvertToScheduledActionList(actions []model.ScheduledAction) []response.ScheduledActionThumb {
+	res := make([]response.ScheduledActionThumb, 0, len(actions))
+	now := time.Now()
+	for i := range actions {
+		action := &actions[i]
+		res = append(res, response.ScheduledActionThumb{
+			Id:          cast.ToString(action.Id),
+			ClusterName: action.ClusterName,
+			ActionName:  action.ActionName,
+			Cron:        action.Cron,
+			Timezone:    action.Timezone,
+			ExpectCount: action.ExpectCount,
+			Status:      action.Status,
+			LastRunAt:   getStringTime(action.LastRunAt),
+			NextRunAt:   getStringTime(service.NextScheduledActionRun(action, now)),
+			CreateAt:    getStringTime(action.CreateAt),
+			CreateBy:    action.CreateBy,
+		})
+	}
+	return res
+}
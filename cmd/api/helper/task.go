@@ -15,7 +15,11 @@ import (
 
 func ConvertToTaskDetail(instances []model.Instance, task *model.Task) *response.TaskDetailResponse {
 	if len(instances) == 0 {
-		return defaultTaskDetailByType(task)
+		ret := defaultTaskDetailByType(task)
+		if ret != nil {
+			ret.Progress = ConvertToTaskProgress(task)
+		}
+		return ret
 	}
 	ret := &response.TaskDetailResponse{}
 	ret.TaskName = task.TaskName
@@ -58,10 +62,76 @@ func ConvertToTaskDetail(instances []model.Instance, task *model.Task) *response
 		endTime = *task.FinishTime
 	}
 	ret.ExecuteTime = int(endTime.Sub(*task.CreateAt).Seconds())
+	ret.Progress = ConvertToTaskProgress(task)
 
 	return ret
 }
 
+//ConvertToQueuedTaskList tasks 为按入队顺序排列的排队任务
+func ConvertToQueuedTaskList(tasks []model.Task) []response.QueuedTaskThumb {
+	res := make([]response.QueuedTaskThumb, 0, len(tasks))
+	for i, task := range tasks {
+		var count int
+		if task.TaskAction == constants.TaskActionExpand {
+			info := model.ExpandTaskInfo{}
+			_ = jsoniter.UnmarshalFromString(task.TaskInfo, &info)
+			count = info.Count
+		} else {
+			info := model.ShrinkTaskInfo{}
+			_ = jsoniter.UnmarshalFromString(task.TaskInfo, &info)
+			count = info.Count
+		}
+		res = append(res, response.QueuedTaskThumb{
+			TaskId:        cast.ToString(task.Id),
+			TaskName:      task.TaskName,
+			TaskAction:    task.TaskAction,
+			Count:         count,
+			QueuePosition: i + 1,
+			CreateAt:      getStringTime(task.CreateAt),
+		})
+	}
+	return res
+}
+
+func ConvertToTaskProgress(task *model.Task) response.TaskProgress {
+	return response.TaskProgress{
+		Requested: task.RequestedCount,
+		Created:   task.CreatedCount,
+		Released:  task.ReleasedCount,
+		Running:   task.RunningCount,
+		Failed:    task.FailedCount,
+	}
+}
+
+func ConvertToTaskEventList(events []model.TaskEvent) []response.TaskEventThumb {
+	res := make([]response.TaskEventThumb, 0, len(events))
+	for _, event := range events {
+		res = append(res, ConvertToTaskEventThumb(event))
+	}
+	return res
+}
+
+func ConvertToTaskEventThumb(event model.TaskEvent) response.TaskEventThumb {
+	return response.TaskEventThumb{
+		EventId:   cast.ToString(event.Id),
+		EventType: event.EventType,
+		Message:   event.Message,
+		CreateAt:  getStringTime(event.CreateAt),
+	}
+}
+
+func ConvertToRetryChain(tasks []model.Task) []response.RetryTaskThumb {
+	res := make([]response.RetryTaskThumb, 0, len(tasks))
+	for _, task := range tasks {
+		res = append(res, response.RetryTaskThumb{
+			TaskId:     cast.ToString(task.Id),
+			TaskStatus: task.Status,
+			CreateAt:   getStringTime(task.CreateAt),
+		})
+	}
+	return res
+}
+
 func defaultTaskDetailByType(task *model.Task) *response.TaskDetailResponse {
 	if task == nil {
 		return nil
@@ -91,6 +161,11 @@ func defaultTaskDetailByType(task *model.Task) *response.TaskDetailResponse {
 		}
 		return resp
 	}
+	if task.TaskAction == constants.TaskActionRollingUpdate {
+		resp.SuccessRate = "0.00"
+		resp.TotalNum = task.RequestedCount
+		return resp
+	}
 	if task.TaskAction == constants.TaskActionShrink {
 		if task.Status == constants.TaskStatusSuccess {
 			taskInfo := model.ShrinkTaskInfo{}
@@ -9,6 +9,11 @@ import (
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/service"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/alibaba"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/aws"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/fake"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/huawei"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/tencent"
 )
 
 func main() {
@@ -19,6 +24,7 @@ func main() {
 		panic(err)
 	}
 	service.Init(100)
+	service.InitMetricSources(config.GlobalConfig.MetricCfg)
 	r := routers.Init()
 	err := r.Run(fmt.Sprintf(":%d", config.GlobalConfig.ServerPort))
 	if err != nil {
@@ -0,0 +1,89 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+//CheckIdempotencyKey 请求头带 Idempotency-Key 时, 保留期内同一用户重复提交相同的请求返回第一次请求的响应,
+//请求内容不同或第一次请求还在处理中时返回 409. 需要在 CheckTokenAuth 之后使用
+func CheckIdempotencyKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(constants.IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > constants.MaxIdempotencyKeyLength {
+			ctx.Abort()
+			response.MkResponse(ctx, http.StatusBadRequest, "idempotency key is too long", nil)
+			return
+		}
+		body, err := ioutil.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.Abort()
+			response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		var userId int64
+		if user := helper.GetUserClaims(ctx); user != nil {
+			userId = user.UserId
+		}
+		hash := service.IdempotencyRequestHash(ctx.Request.Method, ctx.FullPath(), body)
+		record, replay, err := service.BeginIdempotentRequest(ctx, userId, key, ctx.FullPath(), hash)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) || errors.Is(err, service.ErrIdempotencyKeyInProgress) {
+			ctx.Abort()
+			response.MkResponse(ctx, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		if err != nil {
+			ctx.Abort()
+			response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		if replay {
+			ctx.Abort()
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			return
+		}
+
+		writer := &bodyRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		defer func() {
+			//处理请求时 panic 也要释放 key, 否则保留期内无法重试
+			if r := recover(); r != nil {
+				service.FinishIdempotentRequest(context.Background(), record, http.StatusInternalServerError, nil)
+				panic(r)
+			}
+		}()
+		ctx.Next()
+		service.FinishIdempotentRequest(context.Background(), record, writer.Status(), writer.body.Bytes())
+	}
+}
+
+//bodyRecorder 在写出响应的同时记录响应内容
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -1,6 +1,10 @@
 package request
 
-import "github.com/galaxy-future/BridgX/internal/service"
+import (
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/spf13/cast"
+)
 
 type AddTagRequest struct {
 	ClusterName string            `json:"cluster_name"`
@@ -10,12 +14,83 @@ type AddTagRequest struct {
 type SetExpectInstanceCountRequest struct {
 	ClusterName string `json:"cluster_name"`
 	ExpectCount int    `json:"expect_count"`
+	Declarative *bool  `json:"declarative"` //不传时默认开启声明式模式
+}
+
+func (c *SetExpectInstanceCountRequest) Check() bool {
+	return c.ClusterName != "" && c.ExpectCount >= 0
+}
+
+type AutoscalingPolicyRequest struct {
+	ClusterName      string              `json:"cluster_name"`
+	PolicyName       string              `json:"policy_name"`
+	PolicyType       string              `json:"policy_type"`
+	MetricSource     string              `json:"metric_source"`
+	MetricName       string              `json:"metric_name"`
+	MetricQuery      string              `json:"metric_query"`
+	TargetValue      float64             `json:"target_value"`
+	Steps            []types.ScalingStep `json:"steps"`
+	MinCount         int                 `json:"min_count"`
+	MaxCount         int                 `json:"max_count"`
+	ScaleOutCooldown int                 `json:"scale_out_cooldown"`
+	ScaleInCooldown  int                 `json:"scale_in_cooldown"`
+}
+
+func (c *AutoscalingPolicyRequest) Check() bool {
+	return c.ClusterName != "" && c.PolicyName != "" && c.PolicyType != "" && c.MetricSource != ""
+}
+
+type PushMetricRequest struct {
+	ClusterName string  `json:"cluster_name"`
+	MetricName  string  `json:"metric_name"`
+	Value       float64 `json:"value"`
+}
+
+func (c *PushMetricRequest) Check() bool {
+	return c.ClusterName != "" && c.MetricName != ""
+}
+
+type ScheduledActionRequest struct {
+	ClusterName string `json:"cluster_name"`
+	ActionName  string `json:"action_name"`
+	Cron        string `json:"cron"`
+	Timezone    string `json:"timezone"`
+	ExpectCount int    `json:"expect_count"`
+}
+
+func (c *ScheduledActionRequest) Check() bool {
+	return c.ClusterName != "" && c.ActionName != "" && c.Cron != "" && c.ExpectCount >= 0
+}
+
+type TaskIdRequest struct {
+	TaskId string `json:"task_id"`
+}
+
+func (c *TaskIdRequest) Check() bool {
+	return cast.ToInt64(c.TaskId) > 0
+}
+
+type InstanceIdsRequest struct {
+	InstanceIds []string `json:"instance_ids"`
+}
+
+func (c *InstanceIdsRequest) Check() bool {
+	if len(c.InstanceIds) == 0 {
+		return false
+	}
+	for _, id := range c.InstanceIds {
+		if id == "" {
+			return false
+		}
+	}
+	return true
 }
 
 type ExpandClusterRequest struct {
 	TaskName    string `json:"task_name"`
 	ClusterName string `json:"cluster_name"`
 	Count       int    `json:"count"`
+	DryRun      bool   `json:"dry_run"` //只返回扩容计划和预检结果, 不创建任务
 }
 
 type ShrinkClusterRequest struct {
@@ -23,6 +98,22 @@ type ShrinkClusterRequest struct {
 	ClusterName string   `json:"cluster_name"`
 	IPs         []string `json:"ips"`
 	Count       int      `json:"count"`
+	DryRun      bool     `json:"dry_run"` //只返回将要释放的实例和预检结果, 不创建任务
+}
+
+type RollingUpdateRequest struct {
+	TaskName       string `json:"task_name"`
+	ClusterName    string `json:"cluster_name"`
+	BatchSize      int    `json:"batch_size"`      //每批替换的实例数量, 不传时为 1
+	MaxSurge       *int   `json:"max_surge"`       //不传时与 batch_size 相同
+	MaxUnavailable int    `json:"max_unavailable"` //不传时为 0
+}
+
+func (c *RollingUpdateRequest) Check() bool {
+	if c.ClusterName == "" || c.BatchSize < 0 || c.MaxUnavailable < 0 {
+		return false
+	}
+	return c.MaxSurge == nil || (*c.MaxSurge >= 0 && *c.MaxSurge+c.MaxUnavailable > 0)
 }
 
 type CreateVpcRequest struct {
@@ -37,6 +128,17 @@ func (c *CreateVpcRequest) Check() bool {
 	return c.Provider != "" && c.RegionId != "" && c.VpcName != "" && c.Ak != ""
 }
 
+type KeyPairRequest struct {
+	AccountKey  string `json:"account_key"`
+	RegionId    string `json:"region_id"`
+	KeyPairName string `json:"key_pair_name"`
+	PublicKey   string `json:"public_key"`
+}
+
+func (c *KeyPairRequest) Check() bool {
+	return c.AccountKey != "" && c.RegionId != "" && c.KeyPairName != ""
+}
+
 type CreateSwitchRequest struct {
 	SwitchName string `json:"switch_name"`
 	RegionId   string `json:"region_id"`
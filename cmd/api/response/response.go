@@ -1,5 +1,7 @@
 package response
 
+import "github.com/galaxy-future/BridgX/internal/types"
+
 type ClusterCountResponse struct {
 	ClusterNum int64 `json:"cluster_num"`
 }
@@ -28,6 +30,68 @@ type TaskThumb struct {
 	FinishAt    string `json:"finish_at"`
 }
 
+type KeyPairThumb struct {
+	KeyPairId   string `json:"key_pair_id"`
+	KeyPairName string `json:"key_pair_name"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type CreateKeyPairResponse struct {
+	KeyPairId   string `json:"key_pair_id"`
+	KeyPairName string `json:"key_pair_name"`
+	PrivateKey  string `json:"private_key"`
+}
+
+type AutoscalingPolicyThumb struct {
+	Id               string              `json:"id"`
+	ClusterName      string              `json:"cluster_name"`
+	PolicyName       string              `json:"policy_name"`
+	PolicyType       string              `json:"policy_type"`
+	MetricSource     string              `json:"metric_source"`
+	MetricName       string              `json:"metric_name"`
+	MetricQuery      string              `json:"metric_query"`
+	TargetValue      float64             `json:"target_value"`
+	Steps            []types.ScalingStep `json:"steps"`
+	MinCount         int                 `json:"min_count"`
+	MaxCount         int                 `json:"max_count"`
+	ScaleOutCooldown int                 `json:"scale_out_cooldown"`
+	ScaleInCooldown  int                 `json:"scale_in_cooldown"`
+	Enabled          bool                `json:"enabled"`
+	LastScaleAt      string              `json:"last_scale_at"`
+	CreateAt         string              `json:"create_at"`
+	CreateBy         string              `json:"create_by"`
+}
+
+type AutoscalingDecisionThumb struct {
+	PolicyId     string  `json:"policy_id"`
+	MetricValue  float64 `json:"metric_value"`
+	CurrentCount int     `json:"current_count"`
+	DesiredCount int     `json:"desired_count"`
+	Action       string  `json:"action"`
+	TaskId       string  `json:"task_id"`
+	Reason       string  `json:"reason"`
+	CreateAt     string  `json:"create_at"`
+}
+
+type AutoscalingDecisionListResponse struct {
+	DecisionList []AutoscalingDecisionThumb `json:"decision_list"`
+	Pager        Pager                      `json:"pager"`
+}
+
+type ScheduledActionThumb struct {
+	Id          string `json:"id"`
+	ClusterName string `json:"cluster_name"`
+	ActionName  string `json:"action_name"`
+	Cron        string `json:"cron"`
+	Timezone    string `json:"timezone"`
+	ExpectCount int    `json:"expect_count"`
+	Status      string `json:"status"`
+	LastRunAt   string `json:"last_run_at"`
+	NextRunAt   string `json:"next_run_at"`
+	CreateAt    string `json:"create_at"`
+	CreateBy    string `json:"create_by"`
+}
+
 type ClusterThumb struct {
 	ClusterId     string `json:"cluster_id"`
 	ClusterName   string `json:"cluster_name"`
@@ -38,6 +102,22 @@ type ClusterThumb struct {
 	CreateBy      string `json:"create_by"`
 }
 
+type RetryTaskResponse struct {
+	TaskId       string `json:"task_id"`
+	ParentTaskId string `json:"parent_task_id"`
+}
+
+type RetryTaskThumb struct {
+	TaskId     string `json:"task_id"`
+	TaskStatus string `json:"task_status"`
+	CreateAt   string `json:"create_at"`
+}
+
+type CancelTaskResponse struct {
+	TaskId     string `json:"task_id"`
+	TaskStatus string `json:"task_status"`
+}
+
 type TaskDetailResponse struct {
 	TaskId      string `json:"task_id"`
 	TaskName    string `json:"task_name"`
@@ -54,6 +134,46 @@ type TaskDetailResponse struct {
 	SuccessRate string `json:"success_rate"`
 	ExecuteTime int    `json:"execute_time"`
 	CreateAt    string `json:"create_at"`
+
+	QueuePosition int              `json:"queue_position"`
+	Progress      TaskProgress     `json:"progress"`
+	RetryChain    []RetryTaskThumb `json:"retry_chain,omitempty"`
+}
+
+type QueuedTaskThumb struct {
+	TaskId        string `json:"task_id"`
+	TaskName      string `json:"task_name"`
+	TaskAction    string `json:"task_action"`
+	Count         int    `json:"count"`
+	QueuePosition int    `json:"queue_position"`
+	CreateAt      string `json:"create_at"`
+}
+
+type QueuedTaskListResponse struct {
+	ClusterName string            `json:"cluster_name"`
+	TaskList    []QueuedTaskThumb `json:"task_list"`
+}
+
+type TaskProgress struct {
+	Requested int `json:"requested"`
+	Created   int `json:"created"`
+	Released  int `json:"released"`
+	Running   int `json:"running"`
+	Failed    int `json:"failed"`
+}
+
+type TaskEventThumb struct {
+	EventId   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Message   string `json:"message"`
+	CreateAt  string `json:"create_at"`
+}
+
+type TaskEventListResponse struct {
+	TaskId     string           `json:"task_id"`
+	TaskStatus string           `json:"task_status"`
+	Progress   TaskProgress     `json:"progress"`
+	EventList  []TaskEventThumb `json:"event_list"`
 }
 
 type TaskDetailListResponse struct {
@@ -61,6 +181,49 @@ type TaskDetailListResponse struct {
 	Pager    Pager                 `json:"pager"`
 }
 
+type PlanCheck struct {
+	Name    string `json:"name"`
+	ZoneId  string `json:"zone_id"`
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+type ExpandZonePlan struct {
+	ZoneId           string   `json:"zone_id"`
+	SubnetId         string   `json:"subnet_id"`
+	InstanceType     string   `json:"instance_type"`
+	Candidates       []string `json:"candidates"`
+	Count            int      `json:"count"`
+	AvailableIpCount int      `json:"available_ip_count"`
+}
+
+type ExpandPlanResponse struct {
+	ClusterName string           `json:"cluster_name"`
+	Count       int              `json:"count"`
+	Feasible    bool             `json:"feasible"`
+	ZoneList    []ExpandZonePlan `json:"zone_list"`
+	CheckList   []PlanCheck      `json:"check_list"`
+}
+
+type ShrinkPlanInstance struct {
+	InstanceId   string `json:"instance_id"`
+	IpInner      string `json:"ip_inner"`
+	IpOuter      string `json:"ip_outer"`
+	ZoneId       string `json:"zone_id"`
+	InstanceType string `json:"instance_type"`
+	CreateAt     string `json:"create_at"`
+}
+
+type ShrinkPlanResponse struct {
+	ClusterName         string               `json:"cluster_name"`
+	Count               int                  `json:"count"`
+	Feasible            bool                 `json:"feasible"`
+	TerminationPolicies []string             `json:"termination_policies"`
+	InstanceList        []ShrinkPlanInstance `json:"instance_list"`
+	NotFoundIps         []string             `json:"not_found_ips"`
+	CheckList           []PlanCheck          `json:"check_list"`
+}
+
 type InstanceResponse struct {
 	InstanceDetail
 }
@@ -76,6 +239,8 @@ type InstanceDetail struct {
 	CreateAt      string         `json:"create_at"`
 	StorageConfig *StorageConfig `json:"storage_config"`
 	NetworkConfig *NetworkConfig `json:"network_config"`
+	Protected     bool           `json:"protected"`
+	HealthStatus  string         `json:"health_status"`
 }
 
 type StorageConfig struct {
@@ -102,17 +267,34 @@ type InstanceListResponse struct {
 }
 
 type InstanceThumb struct {
-	InstanceId    string `json:"instance_id"`
-	IpInner       string `json:"ip_inner"`
-	IpOuter       string `json:"ip_outer"`
-	Provider      string `json:"provider"`
-	CreateAt      string `json:"create_at"`
-	Status        string `json:"status"`
-	StartupTime   int    `json:"startup_time"`
-	ClusterName   string `json:"cluster_name"`
-	InstanceType  string `json:"instance_type"`
-	LoginName     string `json:"login_name"`
-	LoginPassword string `json:"login_password"`
+	InstanceId   string `json:"instance_id"`
+	IpInner      string `json:"ip_inner"`
+	IpOuter      string `json:"ip_outer"`
+	Provider     string `json:"provider"`
+	CreateAt     string `json:"create_at"`
+	Status       string `json:"status"`
+	StartupTime  int    `json:"startup_time"`
+	ClusterName  string `json:"cluster_name"`
+	InstanceType string `json:"instance_type"`
+	LoginName    string `json:"login_name"`
+	KeyPairName  string `json:"key_pair_name"`
+	Protected    bool   `json:"protected"`
+	HealthStatus string `json:"health_status"`
+}
+
+type InstanceHealthEventThumb struct {
+	EventId   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Message   string `json:"message"`
+	CreateAt  string `json:"create_at"`
+}
+
+type InstanceHealthResponse struct {
+	InstanceId     string                     `json:"instance_id"`
+	HealthStatus   string                     `json:"health_status"`
+	HealthFailures int                        `json:"health_failures"`
+	HealthCheckAt  string                     `json:"health_check_at"`
+	EventList      []InstanceHealthEventThumb `json:"event_list"`
 }
 
 type InstanceUsage struct {
@@ -194,3 +376,8 @@ type InstanceStatResponse struct {
 	InstanceTypeDesc string `json:"instance_type_desc"`
 	InstanceCount    int64  `json:"instance_count"`
 }
+
+type ProviderThumb struct {
+	Provider      string `json:"provider"`
+	DefaultRegion string `json:"default_region"`
+}
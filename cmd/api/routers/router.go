@@ -5,6 +5,7 @@ import (
 
 	"github.com/galaxy-future/BridgX/cmd/api/handler"
 	"github.com/galaxy-future/BridgX/cmd/api/middleware/authorization"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/idempotency"
 	"github.com/galaxy-future/BridgX/config"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
@@ -51,35 +52,66 @@ func Init() *gin.Engine {
 			clusterPath.GET("instance_stat", handler.GetInstanceStat)
 			clusterPath.GET("name/:name", handler.GetClusterByName)
 			clusterPath.GET("describe_all", handler.ListClusters)
-			clusterPath.POST("create", handler.CreateCluster)
+			clusterPath.POST("create", idempotency.CheckIdempotencyKey(), handler.CreateCluster)
 			clusterPath.POST("edit", handler.EditCluster)
 			clusterPath.POST("add_tags", handler.AddClusterTags)
-			clusterPath.POST("expand", handler.ExpandCluster)
-			clusterPath.POST("shrink", handler.ShrinkCluster)
+			clusterPath.POST("expand", idempotency.CheckIdempotencyKey(), handler.ExpandCluster)
+			clusterPath.POST("shrink", idempotency.CheckIdempotencyKey(), handler.ShrinkCluster)
+			clusterPath.POST("rolling_update", idempotency.CheckIdempotencyKey(), handler.RollingUpdateCluster)
+			clusterPath.POST("set_expect_count", handler.SetExpectCount)
 			clusterPath.DELETE("delete/:ids", handler.DeleteClusters)
 		}
+		autoscalingPath := v1Api.Group("autoscaling/")
+		{
+			autoscalingPath.POST("policy/create", handler.CreateAutoscalingPolicy)
+			autoscalingPath.GET("policy/list", handler.ListAutoscalingPolicies)
+			autoscalingPath.POST("policy/enable/:id", handler.EnableAutoscalingPolicy)
+			autoscalingPath.POST("policy/disable/:id", handler.DisableAutoscalingPolicy)
+			autoscalingPath.DELETE("policy/delete/:id", handler.DeleteAutoscalingPolicy)
+			autoscalingPath.GET("decision/list", handler.ListAutoscalingDecisions)
+			autoscalingPath.POST("metric/push", handler.PushMetric)
+		}
+		scheduledActionPath := v1Api.Group("scheduled_action/")
+		{
+			scheduledActionPath.POST("create", handler.CreateScheduledAction)
+			scheduledActionPath.GET("list", handler.ListScheduledActions)
+			scheduledActionPath.POST("pause/:id", handler.PauseScheduledAction)
+			scheduledActionPath.POST("resume/:id", handler.ResumeScheduledAction)
+			scheduledActionPath.DELETE("delete/:id", handler.DeleteScheduledAction)
+		}
 		vpcPath := v1Api.Group("vpc/")
 		{
-			vpcPath.POST("create", handler.CreateVpc)
+			vpcPath.POST("create", idempotency.CheckIdempotencyKey(), handler.CreateVpc)
 			vpcPath.GET("describe", handler.DescribeVpc)
 		}
 		subnetPath := v1Api.Group("subnet/")
 		{
-			subnetPath.POST("create", handler.CreateSwitch)
+			subnetPath.POST("create", idempotency.CheckIdempotencyKey(), handler.CreateSwitch)
 			subnetPath.GET("describe", handler.DescribeSwitch)
 
 		}
 		groupPath := v1Api.Group("security_group/")
 		{
-			groupPath.POST("create", handler.CreateSecurityGroup)
+			groupPath.POST("create", idempotency.CheckIdempotencyKey(), handler.CreateSecurityGroup)
 			groupPath.GET("describe", handler.DescribeSecurityGroup)
 			groupPath.POST("rule/add", handler.AddSecurityGroupRule)
-			groupPath.POST("create_with_rule", handler.CreateSecurityGroupWithRules)
+			groupPath.POST("create_with_rule", idempotency.CheckIdempotencyKey(), handler.CreateSecurityGroupWithRules)
+		}
+		keyPairPath := v1Api.Group("key_pair/")
+		{
+			keyPairPath.POST("create", handler.CreateKeyPair)
+			keyPairPath.POST("import", handler.ImportKeyPair)
+			keyPairPath.GET("list", handler.ListKeyPairs)
+			keyPairPath.DELETE("delete/:name", handler.DeleteKeyPair)
 		}
 		networkPath := v1Api.Group("network_config/")
 		{
 			networkPath.POST("create", handler.CreateNetworkConfig)
 		}
+		providerPath := v1Api.Group("provider/")
+		{
+			providerPath.GET("list", handler.ListProviders)
+		}
 		regionPath := v1Api.Group("region/")
 		{
 			regionPath.GET("list", handler.ListRegions)
@@ -99,6 +131,9 @@ func Init() *gin.Engine {
 			instancePath.GET("describe_all", handler.GetInstanceList)
 			instancePath.GET("usage_total", handler.GetInstanceUsageTotal)
 			instancePath.GET("usage_statistics", handler.GetInstanceUsageStatistics)
+			instancePath.POST("protect", handler.ProtectInstances)
+			instancePath.POST("unprotect", handler.UnprotectInstances)
+			instancePath.GET("health", handler.GetInstanceHealth)
 		}
 		taskPath := v1Api.Group("task/")
 		{
@@ -107,6 +142,12 @@ func Init() *gin.Engine {
 			taskPath.GET("describe", handler.GetTaskDescribe)
 			taskPath.GET("describe_all", handler.GetTaskDescribeAll)
 			taskPath.GET("instances", handler.GetTaskInstances)
+			taskPath.POST("cancel", handler.CancelTask)
+			taskPath.POST("retry", handler.RetryTask)
+			taskPath.POST("pause", handler.PauseTask)
+			taskPath.POST("resume", handler.ResumeTask)
+			taskPath.GET("queue", handler.GetTaskQueue)
+			taskPath.GET(":id/events", handler.GetTaskEvents)
 		}
 		userPath := v1Api.Group("user/")
 		{
@@ -45,10 +45,23 @@ func AddFixedIntervalSecondsXJob(interval int, job XJob) {
 	im.Store(job.UniqueKey(), id)
 }
 
+// AddCronXJob 按 cron 表达式注册任务, 支持 CRON_TZ= 前缀指定时区
+func AddCronXJob(spec string, job XJob) error {
+	id, err := cronServer.AddJob(spec, job)
+	if err != nil {
+		return err
+	}
+	jm.Store(job.UniqueKey(), job)
+	im.Store(job.UniqueKey(), id)
+	return nil
+}
+
 func RemoveXJob(key string) {
 	id, ok := im.Load(key)
 	if ok {
 		cronServer.Remove(id.(cron.EntryID))
+		im.Delete(key)
+		jm.Delete(key)
 	}
 }
 
@@ -12,6 +12,11 @@ import (
 	"github.com/galaxy-future/BridgX/internal/bcc"
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/logs"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/alibaba"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/aws"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/fake"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/huawei"
+	_ "github.com/galaxy-future/BridgX/pkg/cloud/tencent"
 )
 
 func main() {
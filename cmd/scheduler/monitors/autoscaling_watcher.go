@@ -0,0 +1,37 @@
+package monitors
+
+import (
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.uber.org/atomic"
+)
+
+//AutoscalingWatcher 负责定时评估集群的弹性伸缩策略，指标超出阈值时创建扩缩容任务
+type AutoscalingWatcher struct {
+	clusterName  string
+	VersionNo    *atomic.String
+	LockerClient *clients.EtcdClient
+}
+
+func (w *AutoscalingWatcher) Run() {
+	err := w.LockerClient.SyncRun(constants.DefaultAutoscalingWatcherInterval, constants.GetClusterScheduleLockKey(w.clusterName), func() error {
+		return service.EvaluateAutoscaling(w.clusterName)
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to evaluate autoscaling policies of cluster %v err:%v", w.clusterName, err)
+	}
+}
+
+func (w *AutoscalingWatcher) UniqueKey() string {
+	return "autoscaling-" + w.clusterName
+}
+
+func (w *AutoscalingWatcher) GetVersionNo() string {
+	return w.VersionNo.Load()
+}
+func (w *AutoscalingWatcher) SetVersionNo(v string) {
+	w.VersionNo.Store(v)
+}
@@ -44,25 +44,46 @@ func (m ClusterMonitor) Run() {
 }
 
 func (m ClusterMonitor) addClusterMonitorJobs(cluster *model.Cluster) {
-	//instanceCountJob := &InstanceCountWatchJob{
-	//	ClusterName: cluster.ClusterName,
-	//	VersionNo:   atomic.NewString(""),
-	//}
-	//crond.AddFixedIntervalSecondsXJob(constants.DefaultInstanceCountWatcherInterval, instanceCountJob)
+	instanceCountJob := &InstanceCountWatchJob{
+		ClusterName:  cluster.ClusterName,
+		VersionNo:    atomic.NewString(""),
+		LockerClient: m.LockerClient,
+	}
+	crond.AddFixedIntervalSecondsXJob(constants.DefaultInstanceCountWatcherInterval, instanceCountJob)
 
 	cleanerJob := &InstanceCleaner{
 		clusterName: cluster.ClusterName,
 		VersionNo:   atomic.NewString(""),
 	}
 	crond.AddFixedIntervalSecondsXJob(constants.DefaultInstanceCleanerRunningInterval, cleanerJob)
+
+	spotReclaimJob := &SpotReclaimWatcher{
+		clusterName:  cluster.ClusterName,
+		VersionNo:    atomic.NewString(""),
+		LockerClient: m.LockerClient,
+	}
+	crond.AddFixedIntervalSecondsXJob(constants.DefaultSpotReclaimWatcherInterval, spotReclaimJob)
+
+	autoscalingJob := &AutoscalingWatcher{
+		clusterName:  cluster.ClusterName,
+		VersionNo:    atomic.NewString(""),
+		LockerClient: m.LockerClient,
+	}
+	crond.AddFixedIntervalSecondsXJob(constants.DefaultAutoscalingWatcherInterval, autoscalingJob)
+
+	healthCheckJob := &HealthCheckWatcher{
+		clusterName:  cluster.ClusterName,
+		VersionNo:    atomic.NewString(""),
+		LockerClient: m.LockerClient,
+	}
+	crond.AddFixedIntervalSecondsXJob(constants.DefaultHealthCheckWatcherInterval, healthCheckJob)
 }
 
 func (m ClusterMonitor) removeClusterMonitorJobs(cluster *model.Cluster) {
-	//instanceCountJob := &InstanceCountWatchJob{
-	//	ClusterName: cluster.ClusterName,
-	//	VersionNo:   atomic.NewString(""),
-	//}
-	//crond.RemoveXJob(instanceCountJob.UniqueKey())
+	instanceCountJob := &InstanceCountWatchJob{
+		ClusterName: cluster.ClusterName,
+	}
+	crond.RemoveXJob(instanceCountJob.UniqueKey())
 
 	cleanerJob := &InstanceCleaner{
 		clusterName:  cluster.ClusterName,
@@ -70,4 +91,19 @@ func (m ClusterMonitor) removeClusterMonitorJobs(cluster *model.Cluster) {
 		LockerClient: m.LockerClient,
 	}
 	crond.RemoveXJob(cleanerJob.UniqueKey())
+
+	spotReclaimJob := &SpotReclaimWatcher{
+		clusterName: cluster.ClusterName,
+	}
+	crond.RemoveXJob(spotReclaimJob.UniqueKey())
+
+	autoscalingJob := &AutoscalingWatcher{
+		clusterName: cluster.ClusterName,
+	}
+	crond.RemoveXJob(autoscalingJob.UniqueKey())
+
+	healthCheckJob := &HealthCheckWatcher{
+		clusterName: cluster.ClusterName,
+	}
+	crond.RemoveXJob(healthCheckJob.UniqueKey())
 }
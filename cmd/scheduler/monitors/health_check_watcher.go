@@ -0,0 +1,39 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.uber.org/atomic"
+)
+
+//HealthCheckWatcher 负责定时检查集群实例的健康状态，开启自动替换时替换不健康的实例
+type HealthCheckWatcher struct {
+	clusterName  string
+	VersionNo    *atomic.String
+	LockerClient *clients.EtcdClient
+}
+
+func (w *HealthCheckWatcher) Run() {
+	err := w.LockerClient.SyncRun(constants.DefaultHealthCheckWatcherInterval, constants.GetHealthCheckLockKey(w.clusterName), func() error {
+		return service.CheckClusterHealth(context.Background(), w.clusterName)
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to check instance health of cluster %v err:%v", w.clusterName, err)
+	}
+}
+
+func (w *HealthCheckWatcher) UniqueKey() string {
+	return "health-check-" + w.clusterName
+}
+
+func (w *HealthCheckWatcher) GetVersionNo() string {
+	return w.VersionNo.Load()
+}
+func (w *HealthCheckWatcher) SetVersionNo(v string) {
+	w.VersionNo.Store(v)
+}
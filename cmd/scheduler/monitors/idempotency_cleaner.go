@@ -0,0 +1,23 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+)
+
+//IdempotencyCleaner 定期删除超过保留期的 Idempotency-Key 记录
+type IdempotencyCleaner struct {
+}
+
+func (m IdempotencyCleaner) Run() {
+	cnt, err := service.CleanExpiredIdempotencyRecords(context.Background())
+	if err != nil {
+		logs.Logger.Errorf("failed to clean expired idempotency records, err: %v", err)
+		return
+	}
+	if cnt > 0 {
+		logs.Logger.Infof("cleaned %d expired idempotency records", cnt)
+	}
+}
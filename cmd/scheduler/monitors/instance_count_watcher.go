@@ -56,40 +56,97 @@ func genVersionNo(e, w int) string {
 	return fmt.Sprintf("%v-%v-%v", e, w, time.Now().Minute())
 }
 
+//scheduleJob 声明式模式下把活跃实例数量收敛到期望数量, 在 BridgX 之外被释放的实例先标记为已删除再补齐
 func scheduleJob(clusterName string) error {
 	snapshot, err := model.GetClusterSnapshot(clusterName)
 	if err != nil {
 		return err
 	}
-	//如果存在任务，或者集群不需要调度不需要调度任务
-	if len(snapshot.RunningTask) != 0 || snapshot.Cluster.ExpectCount == len(snapshot.ActiveInstances) {
+	//如果存在任务，或者集群不是声明式模式不需要调度任务
+	if !snapshot.Cluster.Declarative || len(snapshot.RunningTask) != 0 {
 		return nil
 	}
-
-	//扩容
-	if snapshot.Cluster.ExpectCount > len(snapshot.ActiveInstances) {
-		_, err := service.CreateExpandTask(context.Background(), snapshot.Cluster.ClusterName, snapshot.Cluster.ExpectCount-len(snapshot.ActiveInstances), "EXPECT", 0)
+	tags, err := model.GetTagsByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	info, err := service.ConvertToClusterInfo(&snapshot.Cluster, tags)
+	if err != nil {
+		return fmt.Errorf("failed to convert cluster to cluster info , %w", err)
+	}
+	removed, err := service.RemoveVanishedInstances(info)
+	if err != nil {
+		return err
+	}
+	plan := planConverge(snapshot.Cluster.ExpectCount, snapshot.ActiveInstances, removed)
+	if plan.skipped > 0 {
+		logs.Logger.Warnf("cluster %v need shrink %v more instances, but they are protected", clusterName, plan.skipped)
+	}
+	if plan.expand > 0 {
+		_, err := service.CreateExpandTask(context.Background(), clusterName, plan.expand, constants.TaskNameExpectCount, 0)
 		if err != nil {
 			logs.Logger.Errorf("CreateExpandTask err:%v", err)
 			return err
 		}
+		return nil
+	}
+	if plan.shrink == 0 {
+		return nil
+	}
+	_, err = service.CreateShrinkTask(context.Background(), clusterName, plan.shrink, strings.Join(plan.deleteIPs, ","), constants.TaskNameExpectCount, 0)
+	if err != nil {
+		logs.Logger.Errorf("CreateShrinkTask err:%v", err)
+		return err
+	}
+	return nil
+}
+
+//convergePlan 收敛到期望数量需要创建的任务, expand 和 shrink 最多一个不为 0
+type convergePlan struct {
+	expand    int
+	shrink    int
+	deleteIPs []string //缩容时优先释放的 DELETING 实例 IP, 不为空时 shrink 为 IP 数量
+	skipped   int      //开启了缩容保护而不能释放的数量
+}
+
+//planConverge 去掉已被回收的实例后计算扩缩容数量. 缩容优先释放已标记为 DELETING 的实例,
+//有这样的实例时本轮只释放它们, 剩下的数量下一轮再收敛; 开启了缩容保护的实例不释放
+func planConverge(expectCount int, instances []model.Instance, removed []string) convergePlan {
+	removedIds := make(map[string]bool, len(removed))
+	for _, id := range removed {
+		removedIds[id] = true
 	}
-	//缩容
-	if snapshot.Cluster.ExpectCount < len(snapshot.ActiveInstances) {
-		var deleteIPs []string
-		for _, instance := range snapshot.ActiveInstances {
-			if instance.Status == constants.Deleting {
-				deleteIPs = append(deleteIPs, instance.IpInner)
-			}
+	active := make([]model.Instance, 0, len(instances))
+	unprotected := 0
+	for _, instance := range instances {
+		if removedIds[instance.InstanceId] {
+			continue
 		}
-		if len(snapshot.ActiveInstances)-len(deleteIPs) != snapshot.Cluster.ExpectCount {
-			return fmt.Errorf("can not schedule shrink task becasue expect count !=  instance count - deleting instance count")
+		active = append(active, instance)
+		if !instance.Protected {
+			unprotected++
 		}
-		_, err := service.CreateShrinkTask(context.Background(), snapshot.Cluster.ClusterName, len(deleteIPs), strings.Join(deleteIPs, ","), "EXPECT", 0)
-		if err != nil {
-			logs.Logger.Errorf("CreateShrinkTask err:%v", err)
-			return err
+	}
+	plan := convergePlan{}
+	if expectCount >= len(active) {
+		plan.expand = expectCount - len(active)
+		return plan
+	}
+	plan.shrink = len(active) - expectCount
+	if plan.shrink > unprotected {
+		plan.skipped = plan.shrink - unprotected
+		plan.shrink = unprotected
+	}
+	for _, instance := range active {
+		if instance.Protected || instance.Status != constants.Deleting || instance.IpInner == "" {
+			continue
+		}
+		if len(plan.deleteIPs) < plan.shrink {
+			plan.deleteIPs = append(plan.deleteIPs, instance.IpInner)
 		}
 	}
-	return nil
+	if len(plan.deleteIPs) > 0 {
+		plan.shrink = len(plan.deleteIPs)
+	}
+	return plan
 }
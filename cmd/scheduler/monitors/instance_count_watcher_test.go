@@ -0,0 +1,42 @@
+package monitors
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestPlanConverge(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1", Status: constants.Running, IpInner: "10.0.0.1"},
+		{InstanceId: "i-2", Status: constants.Deleting, IpInner: "10.0.0.2"},
+		{InstanceId: "i-3", Status: constants.Running, IpInner: "10.0.0.3", Protected: true},
+		{InstanceId: "i-4", Status: constants.Deleting, IpInner: "10.0.0.4", Protected: true},
+		{InstanceId: "i-5", Status: constants.Deleting, IpInner: "10.0.0.5"},
+		{InstanceId: "i-6", Status: constants.Running, IpInner: "10.0.0.6"},
+	}
+	cases := []struct {
+		name        string
+		expectCount int
+		removed     []string
+		want        convergePlan
+	}{
+		{"converged", 6, nil, convergePlan{}},
+		{"expand", 8, nil, convergePlan{expand: 2}},
+		{"expand after reclaim", 6, []string{"i-1", "i-6"}, convergePlan{expand: 2}},
+		{"reclaim converged", 5, []string{"i-6"}, convergePlan{}},
+		{"prefer deleting", 5, nil, convergePlan{shrink: 1, deleteIPs: []string{"10.0.0.2"}}},
+		{"all deleting first", 2, nil, convergePlan{shrink: 2, deleteIPs: []string{"10.0.0.2", "10.0.0.5"}}},
+		{"reclaimed deleting skipped", 4, []string{"i-2"}, convergePlan{shrink: 1, deleteIPs: []string{"10.0.0.5"}}},
+		{"by count", 2, []string{"i-2", "i-5"}, convergePlan{shrink: 2}},
+		{"protected clamp", 0, []string{"i-2", "i-5"}, convergePlan{shrink: 2, skipped: 2}},
+		{"protected clamp with deleting", 0, nil, convergePlan{shrink: 2, deleteIPs: []string{"10.0.0.2", "10.0.0.5"}, skipped: 2}},
+	}
+	for _, cs := range cases {
+		if got := planConverge(cs.expectCount, instances, cs.removed); !reflect.DeepEqual(got, cs.want) {
+			t.Errorf("%s: got %+v, want %+v", cs.name, got, cs.want)
+		}
+	}
+}
@@ -26,7 +26,7 @@ func (cleaner *InstanceCleaner) Run() {
 			return err
 		}
 		//查看是否有正在执行的任务，如果有任务执行，则不进行任何清理
-		tasks, err := model.GetTaskByStatus(cleaner.clusterName, []string{constants.TaskStatusInit, constants.TaskStatusRunning})
+		tasks, err := model.GetTaskByStatus(cleaner.clusterName, constants.TaskStatusUnfinished)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,87 @@
+package monitors
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/cmd/scheduler/crond"
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/spf13/cast"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.uber.org/atomic"
+)
+
+var scheduledActionJobs = make(map[string]bool)
+
+//ScheduledActionMonitor 负责加载定时任务，为每个启用的定时任务按 cron 表达式注册执行任务，表达式变更、暂停或删除后重新注册或移除
+type ScheduledActionMonitor struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m ScheduledActionMonitor) Run() {
+	actions, err := model.GetScheduledActions(context.Background(), "")
+	if err != nil {
+		return
+	}
+	active := make(map[string]bool, len(actions))
+	for i := range actions {
+		action := &actions[i]
+		if action.Status != constants.ScheduledActionStatusEnable {
+			continue
+		}
+		job := &ScheduledActionJob{
+			Id:           action.Id,
+			LockerClient: m.LockerClient,
+		}
+		key := job.UniqueKey()
+		active[key] = true
+		//执行时会重新读取定时任务，只有 cron 表达式或时区变化才需要重新注册
+		spec := service.ScheduledActionSpec(action)
+		if old := crond.GetXJob(key); old != nil && old.GetVersionNo() == spec {
+			continue
+		}
+		crond.RemoveXJob(key)
+		job.VersionNo = atomic.NewString(spec)
+		if err = crond.AddCronXJob(spec, job); err != nil {
+			logs.Logger.Errorf("failed to add scheduled action %v spec %v err:%v", action.Id, spec, err)
+		}
+	}
+	for key := range scheduledActionJobs {
+		if !active[key] {
+			crond.RemoveXJob(key)
+		}
+	}
+	scheduledActionJobs = active
+}
+
+//ScheduledActionJob 按 cron 表达式触发，执行一次定时任务
+type ScheduledActionJob struct {
+	Id           int64
+	VersionNo    *atomic.String
+	LockerClient *clients.EtcdClient
+}
+
+func (j *ScheduledActionJob) Run() {
+	fireAt := time.Now().Truncate(time.Minute)
+	err := j.LockerClient.SyncRun(constants.DefaultCleanMaxRunningTTL, constants.GetScheduledActionLockKey(j.Id), func() error {
+		return service.RunScheduledAction(j.Id, fireAt)
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to run scheduled action %v err:%v", j.Id, err)
+	}
+}
+
+func (j *ScheduledActionJob) UniqueKey() string {
+	return "scheduled-action-" + cast.ToString(j.Id)
+}
+
+func (j *ScheduledActionJob) GetVersionNo() string {
+	return j.VersionNo.Load()
+}
+func (j *ScheduledActionJob) SetVersionNo(v string) {
+	j.VersionNo.Store(v)
+}
@@ -0,0 +1,68 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.uber.org/atomic"
+)
+
+//SpotReclaimWatcher 负责发现被云厂商回收的抢占式实例，并创建扩容任务补齐被回收的实例数量,
+//云厂商查询不到已释放实例的计费方式, 在 BridgX 之外被释放的实例同样会被补齐
+type SpotReclaimWatcher struct {
+	clusterName  string
+	VersionNo    *atomic.String
+	LockerClient *clients.EtcdClient
+}
+
+func (w *SpotReclaimWatcher) Run() {
+	err := w.LockerClient.SyncRun(constants.DefaultCleanMaxRunningTTL, constants.GetClusterScheduleLockKey(w.clusterName), func() error {
+		cluster, err := model.GetByClusterName(w.clusterName)
+		if err != nil {
+			return err
+		}
+		//声明式模式下由 InstanceCountWatchJob 补齐实例
+		if cluster.SpotConfig == "" || cluster.Declarative {
+			return nil
+		}
+		//有任务执行时实例状态还在变化，等待下一轮检查
+		tasks, err := model.GetTaskByStatus(w.clusterName, constants.TaskStatusUnfinished)
+		if err != nil {
+			return err
+		}
+		if len(tasks) != 0 {
+			return clients.ErrReviewFailed
+		}
+
+		tags, err := model.GetTagsByClusterName(cluster.ClusterName)
+		if err != nil {
+			return err
+		}
+		info, err := service.ConvertToClusterInfo(cluster, tags)
+		if err != nil {
+			return fmt.Errorf("failed to convert cluster to cluster info , %w", err)
+		}
+		_, err = service.BackfillVanishedInstances(context.Background(), info, constants.TaskNameSpotReclaim)
+		return err
+	})
+	if err != nil && err != concurrency.ErrLocked && err != clients.ErrReviewFailed {
+		logs.Logger.Errorf("failed to backfill reclaimed spot instances of cluster %v err:%v", w.clusterName, err)
+	}
+}
+
+func (w *SpotReclaimWatcher) UniqueKey() string {
+	return "spot-reclaim-" + w.clusterName
+}
+
+func (w *SpotReclaimWatcher) GetVersionNo() string {
+	return w.VersionNo.Load()
+}
+func (w *SpotReclaimWatcher) SetVersionNo(v string) {
+	w.VersionNo.Store(v)
+}
@@ -4,9 +4,10 @@ import (
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
 )
 
-//TaskKiller 负责将执行时间超过最大执行时间的任务设置为失败
+//TaskKiller 负责将执行时间超过最大执行时间的任务设置为失败, 取消中的任务设置为已取消; 执行任务的协程检查到状态变化后停止
 type TaskKiller struct {
 }
 
@@ -20,14 +21,27 @@ func (m TaskKiller) Run() {
 		return
 	}
 
-	var taskIds []int64
+	var failedIds, cancelledIds []int64
 	for _, task := range tasks {
-		taskIds = append(taskIds, task.Id)
+		if task.Status == constants.TaskStatusCancelling {
+			cancelledIds = append(cancelledIds, task.Id)
+		} else {
+			failedIds = append(failedIds, task.Id)
+		}
 	}
+	killTasks(failedIds, constants.TaskStatusFailed)
+	killTasks(cancelledIds, constants.TaskStatusCancelled)
+}
 
-	err = model.UpdateTaskStatus(taskIds, constants.TaskStatusFailed)
-	if err != nil {
+func killTasks(taskIds []int64, status string) {
+	if len(taskIds) == 0 {
+		return
+	}
+	if err := model.UpdateTaskStatus(taskIds, status); err != nil {
 		logs.Logger.Error(err)
+		return
+	}
+	for _, id := range taskIds {
+		service.RecordTaskEvent(id, constants.TaskEventFinished, "task exceeded max running duration, marked as %s", status)
 	}
-
 }
@@ -1,15 +1,18 @@
 package monitors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/pool"
+	"github.com/galaxy-future/BridgX/internal/service"
 	"go.etcd.io/etcd/client/v3/concurrency"
 	"gorm.io/gorm"
 )
@@ -19,6 +22,7 @@ type TaskMonitor struct {
 }
 
 func (m TaskMonitor) Run() {
+	m.dispatchQueuedTasks()
 	tasks := make([]model.Task, 0)
 
 	err := model.QueryAll(map[string]interface{}{"status": constants.TaskStatusInit}, &tasks, "")
@@ -38,6 +42,23 @@ func (m TaskMonitor) Run() {
 	}
 }
 
+//dispatchQueuedTasks 各集群上一个任务结束后, 让队首的排队任务出队
+func (m TaskMonitor) dispatchQueuedTasks() {
+	clusterNames, err := model.GetQueuedClusterNames(context.Background())
+	if err != nil {
+		return
+	}
+	for _, clusterName := range clusterNames {
+		name := clusterName
+		err = m.LockerClient.SyncRun(constants.DefaultTaskMonitorInterval, constants.GetTaskQueueLockKey(name), func() error {
+			return service.DispatchQueuedTask(context.Background(), name)
+		})
+		if err != nil && err != concurrency.ErrLocked {
+			logs.Logger.Errorf("failed to dispatch queued task, cluster: %v, err: %v", name, err)
+		}
+	}
+}
+
 func scheduleTask(task model.Task) error {
 	//ji检查任务是否已经被执行过了
 	var newTask model.Task
@@ -49,17 +70,27 @@ func scheduleTask(task model.Task) error {
 		return clients.ErrReviewFailed
 	}
 
-	//执行任务
-	task.Status = constants.TaskStatusRunning
-	err = model.Save(&task)
+	//执行任务, 只有仍处于 INIT 的任务才会被执行, 避免覆盖已取消的任务
+	now := time.Now()
+	ok, err := model.UpdateTaskIfStatus(context.Background(), task.Id, []string{constants.TaskStatusInit}, map[string]interface{}{
+		"status":    constants.TaskStatusRunning,
+		"update_at": now,
+	})
 	if err != nil {
 		return err
 	}
+	if !ok {
+		return clients.ErrReviewFailed
+	}
+	task.Status = constants.TaskStatusRunning
+	task.UpdateAt = &now
 	switch task.TaskAction {
 	case constants.TaskActionExpand:
 		pool.ExpandTasksChan <- &task
 	case constants.TaskActionShrink:
 		pool.ShrinkTasksChan <- &task
+	case constants.TaskActionRollingUpdate:
+		pool.RollingUpdateTasksChan <- &task
 	default:
 		return fmt.Errorf("unknown task action, action : %v", task.TaskAction)
 	}
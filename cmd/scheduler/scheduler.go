@@ -7,6 +7,7 @@ import (
 	"github.com/galaxy-future/BridgX/config"
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/service"
 )
 
 var schedulers []*types.Scheduler
@@ -16,6 +17,7 @@ func Init() error {
 	if err != nil {
 		return err
 	}
+	service.InitMetricSources(config.GlobalConfig.MetricCfg)
 	schedulers = []*types.Scheduler{
 		{
 			//扫库，查看是否有待执行的Task，分配Task到WorkerPool
@@ -24,13 +26,20 @@ func Init() error {
 				LockerClient: locker,
 			},
 		},
-		// 自动监控当前实例数量与预期实例数量是否相等并执行扩缩容，待启用
+		// 发现集群变更，为每个集群启动实例数量收敛、实例清理、抢占式实例回收、弹性伸缩和健康检查等定时任务
 		{
 			Interval: constants.DefaultClusterMonitorInterval,
 			Monitor: &monitors.ClusterMonitor{
 				LockerClient: locker,
 			},
 		},
+		{
+			//加载集群定时任务，按 cron 表达式定时设置集群期望实例数量
+			Interval: constants.DefaultScheduledActionMonitorInterval,
+			Monitor: &monitors.ScheduledActionMonitor{
+				LockerClient: locker,
+			},
+		},
 		{
 			Interval: constants.DefaultKillExpireRunningTaskInterval,
 			Monitor:  &monitors.TaskKiller{},
@@ -38,6 +47,10 @@ func Init() error {
 		{
 			Interval: constants.DefaultQueryOrderInterval,
 			Monitor:  &monitors.QueryOrderJobs{},
+		},
+		{
+			Interval: constants.DefaultIdempotencyCleanerInterval,
+			Monitor:  &monitors.IdempotencyCleaner{},
 		}}
 	return nil
 }
@@ -42,14 +42,17 @@ func composeConfigFileName(basePath string, suffix string) string {
 }
 
 type Config struct {
-	DebugMode         bool           `yaml:"DebugMode"`
-	NeedPublishConfig bool           `yaml:"NeedPublishConfig"`
-	ServerPort        int            `yaml:"ServerPort"`
-	CostCfg           CostConfig     `yaml:"CostConfig"`
-	WriteDB           DBConfig       `yaml:"WriteDB"`
-	ReadDB            DBConfig       `yaml:"ReadDB"`
-	EtcdConfig        *EtcdConfig    `yaml:"EtcdConfig"`
-	JwtToken          JwtTokenConfig `yaml:"JwtToken"`
+	DebugMode         bool              `yaml:"DebugMode"`
+	NeedPublishConfig bool              `yaml:"NeedPublishConfig"`
+	ServerPort        int               `yaml:"ServerPort"`
+	CostCfg           CostConfig        `yaml:"CostConfig"`
+	MetricCfg         MetricConfig      `yaml:"MetricConfig"`
+	IdempotencyCfg    IdempotencyConfig `yaml:"IdempotencyConfig"`
+	ProtectionCfg     ProtectionConfig  `yaml:"ProtectionConfig"`
+	WriteDB           DBConfig          `yaml:"WriteDB"`
+	ReadDB            DBConfig          `yaml:"ReadDB"`
+	EtcdConfig        *EtcdConfig       `yaml:"EtcdConfig"`
+	JwtToken          JwtTokenConfig    `yaml:"JwtToken"`
 }
 
 type JwtTokenConfig struct {
@@ -77,6 +80,23 @@ type EtcdConfig struct {
 	DailTimeout time.Duration `yaml:"DailTimeout"`
 }
 
+// MetricConfig 弹性伸缩使用的指标数据源
+type MetricConfig struct {
+	PrometheusAddress string `yaml:"PrometheusAddress"` //为空时不启用 prometheus 数据源
+}
+
+// IdempotencyConfig 写接口 Idempotency-Key 的保留时间和处理中请求的租期
+type IdempotencyConfig struct {
+	KeyTTLSec int `yaml:"KeyTTLSec"` //为 0 时使用默认值 86400
+	LeaseSec  int `yaml:"LeaseSec"`  //为 0 时使用默认值 60
+}
+
+// ProtectionConfig 云上带有该标签的实例不会被清理残留实例的任务释放
+type ProtectionConfig struct {
+	TagKey   string `yaml:"TagKey"`   //为空时使用默认值 bridgx-protected
+	TagValue string `yaml:"TagValue"` //为空时使用默认值 true
+}
+
 type CostConfig struct {
 	QueryOrderIntvalSec          int `yaml:"QueryOrderIntvalSec"`
 	QueryAlibabaCloudOrderPerMin int `yaml:"QueryAlibabaCloudOrderPerMin"`
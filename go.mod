@@ -7,7 +7,9 @@ require (
 	github.com/alibabacloud-go/ecs-20140526/v2 v2.1.0
 	github.com/alibabacloud-go/tea v1.1.15
 	github.com/alibabacloud-go/vpc-20160428/v2 v2.0.0
+	github.com/aws/aws-sdk-go v1.42.22
 	github.com/gin-contrib/pprof v1.3.0
+	github.com/gin-contrib/sse v0.1.0
 	github.com/gin-gonic/gin v1.7.4
 	github.com/huaweicloud/huaweicloud-sdk-go-v3 v0.0.68
 	github.com/json-iterator/go v1.1.12
@@ -15,6 +17,7 @@ require (
 	github.com/sony/sonyflake v1.0.0
 	github.com/spf13/cast v1.4.1
 	github.com/stretchr/testify v1.7.0
+	github.com/tencentcloud/tencentcloud-sdk-go v1.0.162
 	github.com/tovenja/cron/v3 v3.0.2
 	go.etcd.io/etcd/client/v3 v3.5.1
 	go.uber.org/atomic v1.7.0
@@ -48,7 +51,6 @@ require (
 	github.com/bytedance/gopkg v0.0.0-20211014123740-7f50af4459eb
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/emirpasic/gods v1.12.0
-	github.com/gin-contrib/sse v0.1.0 // indirect
 	github.com/go-playground/locales v0.14.0 // indirect
 	github.com/go-playground/universal-translator v0.18.0 // indirect
 	github.com/go-playground/validator/v10 v10.9.0 // indirect
@@ -57,7 +59,7 @@ require (
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.2 // indirect
-	github.com/jmespath/go-jmespath v0.0.0-20180206201540-c2b33e8439af // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
@@ -138,6 +138,7 @@ CREATE TABLE `cluster`
     `cluster_name`    varchar(64) COLLATE utf8mb4_bin  NOT NULL,
     `cluster_desc`    varchar(128) COLLATE utf8mb4_bin NOT NULL,
     `expect_count`    int(7) NOT NULL DEFAULT '0',
+    `declarative`     tinyint(1) NOT NULL DEFAULT '0',
     `status`          varchar(32) COLLATE utf8mb4_bin           DEFAULT NULL,
     `region_id`       varchar(64) COLLATE utf8mb4_bin           DEFAULT NULL,
     `zone_id`         varchar(64) COLLATE utf8mb4_bin           DEFAULT NULL,
@@ -145,10 +146,18 @@ CREATE TABLE `cluster`
     `charge_type`     varchar(32) COLLATE utf8mb4_bin           DEFAULT NULL,
     `image`           varchar(512) COLLATE utf8mb4_bin          DEFAULT NULL,
     `provider`        varchar(64) COLLATE utf8mb4_bin           DEFAULT NULL,
-    `password`        varchar(128) COLLATE utf8mb4_bin          DEFAULT NULL,
+    `password`        varchar(256) COLLATE utf8mb4_bin          DEFAULT NULL,
+    `key_pair_name`   varchar(128) COLLATE utf8mb4_bin          DEFAULT NULL,
     `account_key`     varchar(128) COLLATE utf8mb4_bin          DEFAULT NULL,
     `network_config`  varchar(4096) COLLATE utf8mb4_bin         DEFAULT NULL,
     `storage_config`  varchar(4096) COLLATE utf8mb4_bin         DEFAULT NULL,
+    `charge_config`   varchar(512) COLLATE utf8mb4_bin          DEFAULT NULL,
+    `spot_config`     varchar(512) COLLATE utf8mb4_bin          DEFAULT NULL,
+    `user_data`       text COLLATE utf8mb4_bin,
+    `instance_type_config` varchar(1024) COLLATE utf8mb4_bin    DEFAULT NULL,
+    `termination_config` varchar(512) COLLATE utf8mb4_bin       DEFAULT NULL,
+    `lifecycle_config` varchar(1024) COLLATE utf8mb4_bin        DEFAULT NULL,
+    `health_check_config` varchar(1024) COLLATE utf8mb4_bin     DEFAULT NULL,
     `create_at`       timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
     `update_at`       timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
     `create_by`       varchar(32) COLLATE utf8mb4_bin           DEFAULT '',
@@ -194,6 +203,8 @@ CREATE TABLE `instance`
     `task_id`        bigint(20) NOT NULL DEFAULT '-1',
     `shrink_task_id` bigint(20) NOT NULL DEFAULT '-1',
     `instance_id`    varchar(255)         DEFAULT NULL,
+    `zone_id`        varchar(64)          DEFAULT NULL,
+    `instance_type`  varchar(64)          DEFAULT NULL,
     `status`         varchar(32) NOT NULL DEFAULT 'UNDEFINED',
     `ip_inner`       varchar(255)         DEFAULT NULL,
     `ip_outer`       varchar(255)         DEFAULT NULL,
@@ -201,6 +212,10 @@ CREATE TABLE `instance`
     `update_at`      timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     `delete_at`      timestamp NULL DEFAULT NULL,
     `running_at`     timestamp NULL DEFAULT NULL,
+    `protected`      tinyint(1)  NOT NULL DEFAULT '0',
+    `health_status`  varchar(16) NOT NULL DEFAULT '' COMMENT 'HEALTHY, UNHEALTHY',
+    `health_failures` int(11)    NOT NULL DEFAULT '0' COMMENT '连续检查失败次数',
+    `health_check_at` timestamp NULL DEFAULT NULL,
     PRIMARY KEY (`id`),
     KEY              `idx_ip_inner` (`ip_inner`),
     KEY              `instance_cluster_name_status_index` (`cluster_name`,`status`),
@@ -269,11 +284,19 @@ CREATE TABLE `task`
     `task_result`    text COLLATE utf8mb4_bin,
     `err_msg`        text COLLATE utf8mb4_bin,
     `support_cancel` tinyint(1) DEFAULT NULL,
+    `parent_task_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '重试任务记录被重试的任务id',
+    `retry_task_id`  bigint(20) NOT NULL DEFAULT '0' COMMENT '被重试的任务记录重试任务id, 每个任务只能被重试一次',
+    `requested_count` int(11) NOT NULL DEFAULT '0',
+    `created_count`  int(11) NOT NULL DEFAULT '0' COMMENT '扩容已创建实例数',
+    `released_count` int(11) NOT NULL DEFAULT '0' COMMENT '缩容已释放实例数',
+    `running_count`  int(11) NOT NULL DEFAULT '0',
+    `failed_count`   int(11) NOT NULL DEFAULT '0',
     `finish_time`    timestamp NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
     `create_at`      timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     `update_at`      timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`),
-    KEY              `task_task_filter_index` (`task_filter`)
+    KEY              `task_task_filter_index` (`task_filter`),
+    KEY              `task_parent_task_id_index` (`parent_task_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -298,6 +321,152 @@ CREATE TABLE `user`
     PRIMARY KEY (`id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
 
+--
+-- Table structure for table `autoscaling_policy`
+--
+
+DROP TABLE IF EXISTS `autoscaling_policy`;
+CREATE TABLE `autoscaling_policy`
+(
+    `id`                 bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_name`       varchar(64) COLLATE utf8mb4_bin   NOT NULL,
+    `policy_name`        varchar(64) COLLATE utf8mb4_bin   NOT NULL,
+    `policy_type`        varchar(32) COLLATE utf8mb4_bin   NOT NULL COMMENT 'TargetTracking, StepScaling',
+    `metric_source`      varchar(32) COLLATE utf8mb4_bin   NOT NULL COMMENT 'prometheus, push',
+    `metric_name`        varchar(128) COLLATE utf8mb4_bin  NOT NULL DEFAULT '',
+    `metric_query`       varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    `target_value`       double                            NOT NULL DEFAULT '0',
+    `steps`              varchar(2048) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    `min_count`          int(11) NOT NULL DEFAULT '0',
+    `max_count`          int(11) NOT NULL DEFAULT '0',
+    `scale_out_cooldown` int(11) NOT NULL DEFAULT '0' COMMENT '扩容冷却时间,单位 秒',
+    `scale_in_cooldown`  int(11) NOT NULL DEFAULT '0' COMMENT '缩容冷却时间,单位 秒',
+    `enabled`            tinyint(1) NOT NULL DEFAULT '1',
+    `last_scale_at`      timestamp NULL DEFAULT NULL,
+    `create_by`          varchar(32) COLLATE utf8mb4_bin            DEFAULT '',
+    `update_by`          varchar(32) COLLATE utf8mb4_bin            DEFAULT '',
+    `create_at`          timestamp                         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`          timestamp                         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY                  `autoscaling_policy_cluster_name_index` (`cluster_name`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `autoscaling_decision`
+--
+
+DROP TABLE IF EXISTS `autoscaling_decision`;
+CREATE TABLE `autoscaling_decision`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_name`  varchar(64) COLLATE utf8mb4_bin   NOT NULL,
+    `policy_id`     bigint(20) NOT NULL,
+    `metric_value`  double                            NOT NULL DEFAULT '0',
+    `current_count` int(11) NOT NULL DEFAULT '0',
+    `desired_count` int(11) NOT NULL DEFAULT '0',
+    `action`        varchar(32) COLLATE utf8mb4_bin   NOT NULL COMMENT 'EXPAND, SHRINK, FAILED',
+    `task_id`       bigint(20) NOT NULL DEFAULT '0',
+    `reason`        varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    `create_at`     timestamp                         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY             `autoscaling_decision_cluster_name_index` (`cluster_name`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `cluster_metric`
+--
+
+DROP TABLE IF EXISTS `cluster_metric`;
+CREATE TABLE `cluster_metric`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_name` varchar(64) COLLATE utf8mb4_bin  NOT NULL,
+    `metric_name`  varchar(128) COLLATE utf8mb4_bin NOT NULL,
+    `value`        double                           NOT NULL DEFAULT '0',
+    `report_at`    timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `cluster_metric_cluster_name_metric_name_uindex` (`cluster_name`, `metric_name`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `scheduled_action`
+--
+
+DROP TABLE IF EXISTS `scheduled_action`;
+CREATE TABLE `scheduled_action`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_name` varchar(64) COLLATE utf8mb4_bin NOT NULL,
+    `action_name`  varchar(64) COLLATE utf8mb4_bin NOT NULL,
+    `cron`         varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '标准 5 段 cron 表达式',
+    `timezone`     varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT 'Asia/Shanghai',
+    `expect_count` int(11) NOT NULL DEFAULT '0',
+    `status`       varchar(16) COLLATE utf8mb4_bin NOT NULL DEFAULT 'ENABLE' COMMENT 'ENABLE, PAUSED',
+    `last_run_at`  timestamp NULL DEFAULT NULL,
+    `create_by`    varchar(32) COLLATE utf8mb4_bin          DEFAULT '',
+    `update_by`    varchar(32) COLLATE utf8mb4_bin          DEFAULT '',
+    `create_at`    timestamp                       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`    timestamp                       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY            `scheduled_action_cluster_name_index` (`cluster_name`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `task_event`
+--
+
+DROP TABLE IF EXISTS `task_event`;
+CREATE TABLE `task_event`
+(
+    `id`         bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `task_id`    bigint(20) NOT NULL,
+    `event_type` varchar(32) COLLATE utf8mb4_bin NOT NULL,
+    `message`    text COLLATE utf8mb4_bin,
+    `create_at`  timestamp                       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY          `task_event_task_id_index` (`task_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `instance_health_event`
+--
+
+DROP TABLE IF EXISTS `instance_health_event`;
+CREATE TABLE `instance_health_event`
+(
+    `id`           bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `cluster_name` varchar(64) COLLATE utf8mb4_bin  NOT NULL,
+    `instance_id`  varchar(255) COLLATE utf8mb4_bin NOT NULL,
+    `event_type`   varchar(32) COLLATE utf8mb4_bin  NOT NULL COMMENT 'FAILED, UNHEALTHY, RECOVERED, HEALED',
+    `message`      varchar(1024) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    `create_at`    timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY            `instance_health_event_instance_id_index` (`instance_id`),
+    KEY            `instance_health_event_cluster_name_type_index` (`cluster_name`,`event_type`,`create_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+--
+-- Table structure for table `idempotency_record`
+--
+
+DROP TABLE IF EXISTS `idempotency_record`;
+CREATE TABLE `idempotency_record`
+(
+    `id`            bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id`       bigint(20) NOT NULL DEFAULT '0',
+    `idem_key`      varchar(128) COLLATE utf8mb4_bin NOT NULL,
+    `request_path`  varchar(128) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    `request_hash`  char(64) COLLATE utf8mb4_bin     NOT NULL DEFAULT '',
+    `status_code`   int(11) NOT NULL DEFAULT '0' COMMENT '0表示第一次请求还在处理中',
+    `resource_id`   varchar(64) COLLATE utf8mb4_bin  NOT NULL DEFAULT '',
+    `response_body` text COLLATE utf8mb4_bin,
+    `expire_at`     timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `create_at`     timestamp                        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idempotency_record_user_id_idem_key_uindex` (`user_id`, `idem_key`),
+    KEY             `idempotency_record_expire_at_index` (`expire_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
 drop table if exists `order_202101`;
 create table `order_202101`
 (
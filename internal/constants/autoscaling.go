@@ -0,0 +1,25 @@
+package constants
+
+// 弹性伸缩策略类型
+const (
+	// AutoscalingTargetTracking 按指标与目标值的比例调整实例数量
+	AutoscalingTargetTracking = "TargetTracking"
+	// AutoscalingStepScaling 按指标所在区间增减固定数量
+	AutoscalingStepScaling = "StepScaling"
+)
+
+// 弹性伸缩决策结果
+const (
+	AutoscalingActionExpand = "EXPAND"
+	AutoscalingActionShrink = "SHRINK"
+	AutoscalingActionFailed = "FAILED"
+)
+
+// TaskNameAutoscaling 弹性伸缩创建的扩缩容任务名称
+const TaskNameAutoscaling = "AUTOSCALING"
+
+// AutoscalingTolerance 目标追踪策略的容忍度, 指标与目标值偏差不超过 10% 时不调整
+const AutoscalingTolerance = 0.1
+
+// DefaultPushMetricTTL 上报的指标超过该时间(秒)未更新视为无数据
+const DefaultPushMetricTTL = 300
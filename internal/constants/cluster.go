@@ -22,3 +22,95 @@ const (
 	ClusterStatusEnable  = "ENABLE"
 	ClusterStatusDisable = "DISABLE"
 )
+
+// 抢占式实例策略
+const (
+	// SpotOnly 只创建抢占式实例
+	SpotOnly = "SpotOnly"
+	// SpotWithFallback 抢占式实例创建失败时改为按量实例
+	SpotWithFallback = "SpotWithFallback"
+)
+
+// 缩容时挑选待释放实例的终止策略
+const (
+	// TerminationZoneBalanced 先释放不在可用区配置中的实例, 再从 实例数/权重 最大的可用区释放
+	TerminationZoneBalanced = "ZONE_BALANCED"
+	TerminationOldestFirst  = "OLDEST_FIRST"
+	TerminationNewestFirst  = "NEWEST_FIRST"
+	// TerminationClosestToBillingHour 按量实例按小时计费, 优先释放最接近下一个整点计费周期的实例
+	TerminationClosestToBillingHour = "CLOSEST_TO_BILLING_HOUR"
+	// TerminationDeprecatedFirst 优先释放镜像或规格已不是集群当前配置的实例
+	TerminationDeprecatedFirst = "DEPRECATED_FIRST"
+	// TerminationPendingFirst 优先释放还没有进入运行状态的实例
+	TerminationPendingFirst = "PENDING_FIRST"
+)
+
+// DefaultTerminationPolicies 集群未配置终止策略时使用, 同一可用区先释放后创建的实例
+var DefaultTerminationPolicies = []string{TerminationZoneBalanced, TerminationNewestFirst}
+
+// 生命周期钩子
+const (
+	LifecycleHookPreTerminate = "PRE_TERMINATE"
+	LifecycleHookPostLaunch   = "POST_LAUNCH"
+
+	// LifecycleResultContinue 超时后继续释放实例或把实例标记为 RUNNING
+	LifecycleResultContinue = "CONTINUE"
+	// LifecycleResultAbandon 超时后放弃: 待释放的实例恢复为摘除前的状态, 未就绪的新实例被释放
+	LifecycleResultAbandon = "ABANDON"
+
+	// LifecycleHookInterval 检查钩子结果的间隔, 单位秒
+	LifecycleHookInterval = 5
+	// DefaultLifecycleHookTimeout 单位秒
+	DefaultLifecycleHookTimeout = 300
+	// MaxLifecycleHookTimeout 单位秒
+	MaxLifecycleHookTimeout = 3600
+	// LifecycleWebhookTimeout 单次调用 webhook 的超时时间, 单位秒
+	LifecycleWebhookTimeout = 10
+)
+
+// 扩缩容预检的检查项
+const (
+	PlanCheckInstanceCount = "INSTANCE_COUNT"
+	PlanCheckPrePaid       = "PREPAID_INSTANCE"
+	PlanCheckInstanceType  = "INSTANCE_TYPE"
+	PlanCheckSubnetIp      = "SUBNET_IP"
+	PlanCheckCloudDryRun   = "CLOUD_DRY_RUN"
+	PlanCheckProtected     = "PROTECTED_INSTANCE"
+)
+
+// 扩缩容预检的检查结果
+const (
+	PlanCheckPassed  = "PASSED"
+	PlanCheckFailed  = "FAILED"
+	PlanCheckSkipped = "SKIPPED"
+)
+
+// 实例健康检查方式
+const (
+	HealthCheckTCP  = "TCP"
+	HealthCheckHTTP = "HTTP"
+	// HealthCheckCloud 云厂商中的实例状态为 Running 视为健康
+	HealthCheckCloud = "CLOUD"
+
+	// DefaultHealthCheckTimeout 单次检查的超时时间, 单位秒
+	DefaultHealthCheckTimeout = 3
+	// DefaultUnhealthyThreshold 连续失败多少次视为不健康
+	DefaultUnhealthyThreshold = 3
+	// DefaultHealthCheckGracePeriod 实例进入运行状态后开始检查的时间, 单位秒
+	DefaultHealthCheckGracePeriod = 300
+	// DefaultMaxHealPerHour 每小时最多自动替换的实例数量
+	DefaultMaxHealPerHour = 5
+	// DefaultMaxUnhealthyPercent 不健康实例超过活跃实例的该比例时不自动替换, 避免错误的发布清空集群
+	DefaultMaxUnhealthyPercent = 50
+	// HealthCheckConcurrency 同时检查的实例数量
+	HealthCheckConcurrency = 20
+)
+
+// TaskNameAutoHeal 替换不健康实例的扩缩容任务名称
+const TaskNameAutoHeal = "AUTO_HEAL"
+
+// TaskNameSpotReclaim 抢占式实例被回收后补齐实例的扩容任务名称
+const TaskNameSpotReclaim = "SPOT_RECLAIM"
+
+// TaskNameExpectCount 声明式模式下收敛到期望实例数量的扩缩容任务名称
+const TaskNameExpectCount = "EXPECT"
@@ -0,0 +1,15 @@
+package constants
+
+import "time"
+
+//IdempotencyKeyHeader 写接口的幂等请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+//DefaultIdempotencyKeyTTL 未配置 KeyTTLSec 时 Idempotency-Key 的保留时间
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+//DefaultIdempotencyLease 未配置 LeaseSec 时处理中请求的租期, 超过后视为已中断
+const DefaultIdempotencyLease = time.Minute
+
+//MaxIdempotencyKeyLength Idempotency-Key 的最大长度
+const MaxIdempotencyKeyLength = 128
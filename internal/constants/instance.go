@@ -11,3 +11,26 @@ const (
 	Deleted   Status = "DELETED"
 	Deleting  Status = "DELETING"
 )
+
+//实例健康状态, 未检查时为空
+const (
+	HealthStatusHealthy   = "HEALTHY"
+	HealthStatusUnhealthy = "UNHEALTHY"
+)
+
+//实例健康事件类型
+const (
+	HealthEventFailed    = "FAILED"
+	HealthEventUnhealthy = "UNHEALTHY"
+	HealthEventRecovered = "RECOVERED"
+	HealthEventHealed    = "HEALED"
+)
+
+//MaxInstanceHealthEvents 单次查询实例健康事件的最大条数
+const MaxInstanceHealthEvents = 100
+
+//未配置 ProtectionConfig 时云上实例的保护标签
+const (
+	DefaultProtectionTagKey   = "bridgx-protected"
+	DefaultProtectionTagValue = "true"
+)
@@ -5,6 +5,7 @@ const (
 	QueryOrderStartTime = "query_order_start_time"
 )
 
+// 订单计费方式, PostPaid 为包年包月订单(阿里云 Subscription、腾讯云 prePay), PayAsYouGo 为按量付费订单
 const (
 	PostPaid   = "PostPaid"
 	PayAsYouGo = "PayAsYouGo"
@@ -11,9 +11,23 @@ const DefaultTFailedTaskInterval = 15
 const DefaultInstanceCountWatcherInterval = 10
 const DefaultKillExpireRunningTaskInterval = 10
 const DefaultInstanceCleanerRunningInterval = 600
+const DefaultSpotReclaimWatcherInterval = 60
+const DefaultAutoscalingWatcherInterval = 30
+const DefaultScheduledActionMonitorInterval = 30
 const DefaultQueryOrderInterval = 300
+const DefaultIdempotencyCleanerInterval = 3600
+const DefaultHealthCheckWatcherInterval = 30
 const DefaultTaskMaxRunningDuration = 20 * time.Minute
 
+//DefaultRollingUpdateMaxDuration 滚动更新任务的最长执行时间, 包括暂停的时间
+const DefaultRollingUpdateMaxDuration = 24 * time.Hour
+
+//DefaultTaskHeartbeatInterval 长时间执行的任务刷新 update_at 的间隔（秒）, 需要小于 DefaultTaskMaxRunningDuration
+const DefaultTaskHeartbeatInterval = 60
+
+//DefaultTaskCancelCheckInterval 执行中的任务检查是否被取消的间隔（秒）
+const DefaultTaskCancelCheckInterval = 5
+
 //DefaultCleanMaxRunningTTL 默认清理任务最大执行时间（秒）
 const DefaultCleanMaxRunningTTL = 30
 
@@ -21,8 +35,26 @@ const TaskMonitorETCDLockKeyPrefix = "bridgx/task/locks/"
 const ClusterMonitorETCDLockKeyPrefix = "bridgx/cluster/locks/"
 const ClusterMonitorETCDReviewKeyPrefix = "bridgx/cluster/reviews/"
 const ClusterInstancesCountWatcherETCDReviewKeyPrefix = "bridgx/cluster/instance-count-watcher/"
+const ScheduledActionETCDLockKeyPrefix = "bridgx/scheduled-action/locks/"
+const TaskQueueETCDLockKeyPrefix = "bridgx/task-queue/locks/"
+const HealthCheckETCDLockKeyPrefix = "bridgx/health-check/locks/"
 
 //GetClusterScheduleLockKey 对于Cluster调度任务/执行任务时 需要获取锁的key
 func GetClusterScheduleLockKey(clusterName string) string {
 	return fmt.Sprintf("%v/%v", ClusterInstancesCountWatcherETCDReviewKeyPrefix, clusterName)
 }
+
+//GetScheduledActionLockKey 执行定时任务时需要获取锁的key，保证多个调度器只有一个执行
+func GetScheduledActionLockKey(id int64) string {
+	return fmt.Sprintf("%v%v", ScheduledActionETCDLockKeyPrefix, id)
+}
+
+//GetTaskQueueLockKey 分发集群排队任务时需要获取锁的key，保证同一集群的队列只由一个调度器分发
+func GetTaskQueueLockKey(clusterName string) string {
+	return fmt.Sprintf("%v%v", TaskQueueETCDLockKeyPrefix, clusterName)
+}
+
+//GetHealthCheckLockKey 检查集群实例健康状态时需要获取锁的key，保证同一集群只由一个调度器检查
+func GetHealthCheckLockKey(clusterName string) string {
+	return fmt.Sprintf("%v%v", HealthCheckETCDLockKeyPrefix, clusterName)
+}
@@ -0,0 +1,10 @@
+package constants
+
+// 定时任务状态
+const (
+	ScheduledActionStatusEnable = "ENABLE"
+	ScheduledActionStatusPaused = "PAUSED"
+)
+
+// DefaultScheduledActionTimezone 未指定时区时按该时区解析 cron 表达式, 与部署镜像的时区一致
+const DefaultScheduledActionTimezone = "Asia/Shanghai"
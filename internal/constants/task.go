@@ -3,12 +3,71 @@ package constants
 const (
 	TaskActionExpand = "EXPAND"
 	TaskActionShrink = "SHRINK"
+	//TaskActionRollingUpdate 分批把集群中规格或镜像不是当前配置的实例替换为新实例
+	TaskActionRollingUpdate = "ROLLING_UPDATE"
 )
 
 const (
+	TaskStatusQueued         = "QUEUED"
 	TaskStatusInit           = "INIT"
 	TaskStatusRunning        = "RUNNING"
 	TaskStatusSuccess        = "SUCCESS"
 	TaskStatusFailed         = "FAILED"
 	TaskStatusPartialSuccess = "PARTIAL_SUCCESS"
+	TaskStatusCancelling     = "CANCELLING"
+	TaskStatusPaused         = "PAUSED"
+	TaskStatusCancelled      = "CANCELLED"
+	TaskStatusCoalesced      = "COALESCED"
 )
+
+//任务步骤事件类型
+const (
+	TaskEventQueued          = "QUEUED"
+	TaskEventCoalesced       = "COALESCED"
+	TaskEventStarted         = "STARTED"
+	TaskEventBatchSubmitted  = "BATCH_SUBMITTED"
+	TaskEventBatchReturned   = "BATCH_RETURNED"
+	TaskEventWaitingIP       = "WAITING_IP"
+	TaskEventDBSaved         = "DB_SAVED"
+	TaskEventConfigPublished = "CONFIG_PUBLISHED"
+	TaskEventRepaired        = "REPAIRED"
+	TaskEventReleased        = "RELEASED"
+	TaskEventDraining        = "DRAINING"
+	TaskEventLifecycleHook   = "LIFECYCLE_HOOK"
+	TaskEventRollingBatch    = "ROLLING_BATCH"
+	TaskEventHealthCheck     = "HEALTH_CHECK"
+	TaskEventRolledBack      = "ROLLED_BACK"
+	TaskEventPaused          = "PAUSED"
+	TaskEventResumed         = "RESUMED"
+	TaskEventFinished        = "FINISHED"
+)
+
+//任务进度计数, 对应 task 表中的字段
+const (
+	TaskProgressRequested = "requested_count"
+	TaskProgressCreated   = "created_count"
+	TaskProgressReleased  = "released_count"
+	TaskProgressRunning   = "running_count"
+	TaskProgressFailed    = "failed_count"
+)
+
+//DefaultTaskEventPollInterval 推送任务事件时查询新事件的间隔, 单位秒
+const DefaultTaskEventPollInterval = 2
+
+//MaxTaskEventsPerQuery 单次查询任务事件的最大条数
+const MaxTaskEventsPerQuery = 500
+
+//MaxTaskRetryChainLength 查询任务重试链时最多返回的任务数量
+const MaxTaskRetryChainLength = 50
+
+//TaskStatusUnfinished 未结束的任务状态, 集群有未结束的任务时自动扩缩容不创建新任务, 也不做实例清理
+var TaskStatusUnfinished = []string{TaskStatusQueued, TaskStatusInit, TaskStatusRunning, TaskStatusCancelling, TaskStatusPaused}
+
+//TaskStatusActive 已出队的未结束任务状态, 同一集群同时只有一个任务处于这些状态
+var TaskStatusActive = []string{TaskStatusInit, TaskStatusRunning, TaskStatusCancelling, TaskStatusPaused}
+
+//DefaultRollingUpdateBatchSize 滚动更新每批替换的实例数量
+const DefaultRollingUpdateBatchSize = 1
+
+//RollingUpdateHealthCheckInterval 滚动更新等待新实例通过健康检查时的检查间隔, 单位秒
+const RollingUpdateHealthCheckInterval = 10
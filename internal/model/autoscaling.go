@@ -0,0 +1,128 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//AutoscalingPolicy 集群弹性伸缩策略
+type AutoscalingPolicy struct {
+	Base
+	ClusterName  string
+	PolicyName   string
+	PolicyType   string //TargetTracking, StepScaling
+	MetricSource string //prometheus, push
+	MetricName   string
+	MetricQuery  string  //数据源的查询语句, 例如 PromQL
+	TargetValue  float64 //目标追踪策略的目标值
+	Steps        string  //步进策略的区间, json 格式的 []types.ScalingStep
+	MinCount     int
+	MaxCount     int
+
+	ScaleOutCooldown int //扩容冷却时间, 单位秒
+	ScaleInCooldown  int //缩容冷却时间, 单位秒
+	Enabled          bool
+	LastScaleAt      *time.Time
+
+	CreateBy string
+	UpdateBy string
+}
+
+func (AutoscalingPolicy) TableName() string {
+	return "autoscaling_policy"
+}
+
+//AutoscalingDecision 弹性伸缩决策记录, 只记录触发了扩缩容的决策
+type AutoscalingDecision struct {
+	Id           int64 `gorm:"primary_key"`
+	ClusterName  string
+	PolicyId     int64
+	MetricValue  float64
+	CurrentCount int
+	DesiredCount int
+	Action       string //EXPAND, SHRINK, FAILED
+	TaskId       int64
+	Reason       string
+	CreateAt     *time.Time
+}
+
+func (AutoscalingDecision) TableName() string {
+	return "autoscaling_decision"
+}
+
+//ClusterMetric 业务上报的集群指标, 每个集群每个指标只保留最新值
+type ClusterMetric struct {
+	Id          int64 `gorm:"primary_key"`
+	ClusterName string
+	MetricName  string
+	Value       float64
+	ReportAt    *time.Time
+}
+
+func (ClusterMetric) TableName() string {
+	return "cluster_metric"
+}
+
+//GetAutoscalingPolicies 查询集群的弹性伸缩策略, onlyEnabled 为 true 时只返回启用的策略
+func GetAutoscalingPolicies(ctx context.Context, clusterName string, onlyEnabled bool) ([]AutoscalingPolicy, error) {
+	policies := make([]AutoscalingPolicy, 0)
+	query := clients.ReadDBCli.WithContext(ctx).Where("cluster_name = ?", clusterName)
+	if onlyEnabled {
+		query = query.Where("enabled = ?", true)
+	}
+	if err := query.Order("id").Find(&policies).Error; err != nil {
+		logErr("GetAutoscalingPolicies from read db", err)
+		return nil, err
+	}
+	return policies, nil
+}
+
+//UpdatePolicyLastScaleAt 记录策略最近一次触发扩缩容的时间, 用于计算冷却时间
+func UpdatePolicyLastScaleAt(ctx context.Context, id int64, t time.Time) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&AutoscalingPolicy{}).
+		Where("id = ?", id).
+		Update("last_scale_at", t).Error
+	if err != nil {
+		logErr("UpdatePolicyLastScaleAt to write db", err)
+	}
+	return err
+}
+
+//ListAutoscalingDecisions 按时间倒序分页查询集群的决策记录
+func ListAutoscalingDecisions(ctx context.Context, clusterName string, pageNum, pageSize int) ([]AutoscalingDecision, int64, error) {
+	decisions := make([]AutoscalingDecision, 0)
+	query := clients.ReadDBCli.WithContext(ctx).Model(&AutoscalingDecision{}).Where("cluster_name = ?", clusterName)
+	total, err := QueryWhere(query, pageNum, pageSize, &decisions, "id desc", true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decisions, total, nil
+}
+
+//SaveClusterMetric 保存上报的指标, 已存在时覆盖
+func SaveClusterMetric(ctx context.Context, metric *ClusterMetric) error {
+	err := clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cluster_name"}, {Name: "metric_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "report_at"}),
+	}).Create(metric).Error
+	if err != nil {
+		logErr("SaveClusterMetric to write db", err)
+	}
+	return err
+}
+
+//GetClusterMetric 查询集群指标的最新值
+func GetClusterMetric(ctx context.Context, clusterName, metricName string) (*ClusterMetric, error) {
+	var metric ClusterMetric
+	err := clients.ReadDBCli.WithContext(ctx).
+		Where("cluster_name = ? AND metric_name = ?", clusterName, metricName).
+		First(&metric).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metric, nil
+}
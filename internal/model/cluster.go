@@ -3,12 +3,15 @@ package model
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/encrypt"
 )
 
 type Cluster struct {
@@ -16,6 +19,7 @@ type Cluster struct {
 	ClusterName  string //uniq_key
 	ClusterDesc  string
 	ExpectCount  int
+	Declarative  bool //为 true 时调度器持续把活跃实例数量收敛到 ExpectCount
 	Status       string //ENABLE, DISABLE
 	RegionId     string
 	ZoneId       string
@@ -24,17 +28,32 @@ type Cluster struct {
 	ChargeType string
 	Image      string
 	Provider   string
-	Password   string
+	Password   string //使用 AccountKey 加密存储
+	//KeyPairName 使用密钥对登录时的密钥对名称, 不为空时不保存密码
+	KeyPairName string
 
 	//Advanced Config
 	NetworkConfig string
 	StorageConfig string
+	ChargeConfig  string
+	SpotConfig    string
+	UserData      string
 	AccountKey    string
+	//InstanceTypeConfig 备选实例规格
+	InstanceTypeConfig string
+	//TerminationConfig 缩容时的终止策略
+	TerminationConfig string
+	//LifecycleConfig 扩缩容时的生命周期钩子
+	LifecycleConfig string
+	//HealthCheckConfig 实例健康检查和自动替换
+	HealthCheckConfig string
 
 	CreateBy      string
 	UpdateBy      string
 	DeleteUniqKey int64
 	DeletedAt     gorm.DeletedAt
+
+	plainPassword string
 }
 
 type ClusterSnapshot struct {
@@ -47,6 +66,91 @@ func (Cluster) TableName() string {
 	return "cluster"
 }
 
+//passwordCipherPrefix 加密后的密码前缀, 没有前缀的是加密上线前保存的明文密码
+const passwordCipherPrefix = "enc:v1:"
+
+func (c *Cluster) AfterFind(tx *gorm.DB) (err error) {
+	if c == nil {
+		return nil
+	}
+	//兼容加密上线前保存的明文密码, 下次保存时加密
+	if c.AccountKey != "" && strings.HasPrefix(c.Password, passwordCipherPrefix) {
+		res, err := encrypt.AESDecrypt(c.AccountKey, strings.TrimPrefix(c.Password, passwordCipherPrefix))
+		if err != nil {
+			logs.Logger.Errorf("decrypt cluster password failed.err: %s", err.Error())
+			return err
+		}
+		c.Password = res
+	}
+	return nil
+}
+
+//BeforeSave 写入加密后的密码, AfterSave 恢复明文, 调用方的结构体保存前后不变, 重复保存不会重复加密
+func (c *Cluster) BeforeSave(tx *gorm.DB) (err error) {
+	if c == nil {
+		return nil
+	}
+	if strings.HasPrefix(c.Password, passwordCipherPrefix) {
+		//上次保存失败时 AfterSave 没有执行, 密码已经是密文
+		return nil
+	}
+	c.plainPassword = ""
+	if c.AccountKey != "" && c.Password != "" {
+		res, err := encrypt.AESEncrypt(c.AccountKey, c.Password)
+		if err != nil {
+			logs.Logger.Errorf("encrypt cluster password failed.err: %s", err.Error())
+			return err
+		}
+		c.plainPassword = c.Password
+		c.Password = passwordCipherPrefix + res
+	}
+	return nil
+}
+
+func (c *Cluster) AfterSave(tx *gorm.DB) (err error) {
+	if c == nil || c.plainPassword == "" {
+		return nil
+	}
+	c.Password = c.plainPassword
+	c.plainPassword = ""
+	return nil
+}
+
+//CountClustersByKeyPair 统计引用了指定密钥对的集群数量
+func CountClustersByKeyPair(ctx context.Context, accountKey, regionId, keyPairName string) (int64, error) {
+	var cnt int64
+	err := clients.ReadDBCli.WithContext(ctx).Model(&Cluster{}).
+		Where("account_key = ? AND region_id = ? AND key_pair_name = ?", accountKey, regionId, keyPairName).
+		Count(&cnt).Error
+	if err != nil {
+		logErr("CountClustersByKeyPair from read db", err)
+		return 0, err
+	}
+	return cnt, nil
+}
+
+//UpdateExpectCount 设置集群期望实例数量和是否开启声明式模式
+func UpdateExpectCount(ctx context.Context, clusterName string, expectCount int, declarative bool) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&Cluster{}).
+		Where("cluster_name = ?", clusterName).
+		Updates(map[string]interface{}{
+			"expect_count": expectCount,
+			"declarative":  declarative,
+			"update_at":    time.Now(),
+		}).Error
+	if err != nil {
+		logErr("UpdateExpectCount to write db", err)
+	}
+	return err
+}
+
+//incrExpectCount 声明式模式下扩缩容时同步调整期望实例数量, 非声明式集群不变
+func incrExpectCount(db *gorm.DB, clusterName string, delta int) error {
+	return db.Model(&Cluster{}).
+		Where("cluster_name = ? AND declarative = ?", clusterName, true).
+		Update("expect_count", gorm.Expr("GREATEST(expect_count + ?, 0)", delta)).Error
+}
+
 // GetByClusterName find first record that match given conditions
 func GetByClusterName(clusterName string) (*Cluster, error) {
 	var out Cluster
@@ -109,7 +213,7 @@ func GetClusterSnapshot(clusterName string) (*ClusterSnapshot, error) {
 		logErr("GetActiveInstancesByClusterName from read db", err)
 		return nil, err
 	}
-	tasks, err := GetTaskByStatus(cluster.ClusterName, []string{constants.TaskStatusInit, constants.TaskStatusRunning})
+	tasks, err := GetTaskByStatus(cluster.ClusterName, constants.TaskStatusUnfinished)
 	if err != nil {
 		logErr("GetActiveTaskByClusterName from read db", err)
 		return nil, err
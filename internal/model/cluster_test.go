@@ -0,0 +1,70 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterPasswordLegacyPlaintext(t *testing.T) {
+	for _, password := range []string{"Passw0rd!", "0123456789abcdef0123456789abcdef"} {
+		c := &Cluster{AccountKey: "ak", Password: password}
+		if err := c.AfterFind(nil); err != nil {
+			t.Fatalf("AfterFind %q: %v", password, err)
+		}
+		if c.Password != password {
+			t.Errorf("legacy password %q changed to %q", password, c.Password)
+		}
+	}
+}
+
+func TestClusterPasswordSave(t *testing.T) {
+	password := "0123456789abcdef0123456789abcdef"
+	c := &Cluster{AccountKey: "ak", Password: password}
+	stored := make([]string, 0, 2)
+	for i := 0; i < 2; i++ {
+		if err := c.BeforeSave(nil); err != nil {
+			t.Fatalf("BeforeSave: %v", err)
+		}
+		if !strings.HasPrefix(c.Password, passwordCipherPrefix) {
+			t.Fatalf("save %d stored %q without cipher prefix", i, c.Password)
+		}
+		stored = append(stored, c.Password)
+		if err := c.AfterSave(nil); err != nil {
+			t.Fatalf("AfterSave: %v", err)
+		}
+		if c.Password != password {
+			t.Fatalf("save %d left caller password %q", i, c.Password)
+		}
+	}
+	for _, s := range stored {
+		found := &Cluster{AccountKey: "ak", Password: s}
+		if err := found.AfterFind(nil); err != nil {
+			t.Fatalf("AfterFind: %v", err)
+		}
+		if found.Password != password {
+			t.Errorf("decrypted %q, want %q", found.Password, password)
+		}
+	}
+}
+
+func TestClusterPasswordSaveEncrypted(t *testing.T) {
+	c := &Cluster{AccountKey: "ak", Password: "secret"}
+	if err := c.BeforeSave(nil); err != nil {
+		t.Fatalf("BeforeSave: %v", err)
+	}
+	stored := c.Password
+	//保存失败时 AfterSave 不执行, 再次保存不能重复加密
+	if err := c.BeforeSave(nil); err != nil {
+		t.Fatalf("BeforeSave: %v", err)
+	}
+	if c.Password != stored {
+		t.Fatalf("encrypted twice: %q", c.Password)
+	}
+	if err := c.AfterSave(nil); err != nil || c.Password != "secret" {
+		t.Errorf("AfterSave got %q, %v", c.Password, err)
+	}
+	found := &Cluster{AccountKey: "ak", Password: stored}
+	if err := found.AfterFind(nil); err != nil || found.Password != "secret" {
+		t.Errorf("AfterFind got %q, %v", found.Password, err)
+	}
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//IdempotencyRecord 带 Idempotency-Key 的写请求, 保留期内重复提交时返回第一次请求的响应
+type IdempotencyRecord struct {
+	Id           int64 `gorm:"primary_key"`
+	UserId       int64
+	IdemKey      string
+	RequestPath  string
+	RequestHash  string //请求方法、路径和请求体的 sha256
+	StatusCode   int    //为 0 表示第一次请求还在处理中
+	ResourceId   string //请求创建的资源或任务id
+	ResponseBody string
+	ExpireAt     *time.Time
+	CreateAt     *time.Time //登记时间, 处理中的记录被接管时更新
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_record"
+}
+
+//GetIdempotencyRecord 查询用户的 Idempotency-Key 记录, 从主库读取避免读到从库延迟的旧数据
+func GetIdempotencyRecord(ctx context.Context, userId int64, key string) (*IdempotencyRecord, error) {
+	var record IdempotencyRecord
+	err := clients.WriteDBCli.WithContext(ctx).
+		Where("user_id = ? AND idem_key = ?", userId, key).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+//CreateIdempotencyRecord 登记 Idempotency-Key, 相同的 key 已存在时返回 false
+func CreateIdempotencyRecord(ctx context.Context, record *IdempotencyRecord) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if res.Error != nil {
+		logErr("CreateIdempotencyRecord to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+//TakeOverIdempotencyRecord 接管 leaseStart 之前登记且还在处理中的记录, 并发接管时只有一个请求返回 true
+func TakeOverIdempotencyRecord(ctx context.Context, id int64, leaseStart, now, expireAt time.Time) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Model(&IdempotencyRecord{}).
+		Where("id = ? AND status_code = 0 AND create_at < ?", id, leaseStart).
+		Updates(map[string]interface{}{
+			"create_at": now,
+			"expire_at": expireAt,
+		})
+	if res.Error != nil {
+		logErr("TakeOverIdempotencyRecord to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+//SaveIdempotencyResponse 保存第一次请求的响应
+func SaveIdempotencyResponse(ctx context.Context, id int64, statusCode int, resourceId, body string) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&IdempotencyRecord{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"resource_id":   resourceId,
+			"response_body": body,
+		}).Error
+	if err != nil {
+		logErr("SaveIdempotencyResponse to write db", err)
+	}
+	return err
+}
+
+func DeleteIdempotencyRecord(ctx context.Context, id int64) error {
+	err := clients.WriteDBCli.WithContext(ctx).Delete(&IdempotencyRecord{}, id).Error
+	if err != nil {
+		logErr("DeleteIdempotencyRecord to write db", err)
+	}
+	return err
+}
+
+//DeleteExpiredIdempotencyRecords 删除 before 之前过期的记录
+func DeleteExpiredIdempotencyRecords(ctx context.Context, before time.Time) (int64, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Where("expire_at < ?", before).Delete(&IdempotencyRecord{})
+	if res.Error != nil {
+		logErr("DeleteExpiredIdempotencyRecords to write db", res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
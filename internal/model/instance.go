@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -23,11 +24,18 @@ type Instance struct {
 	IpOuter      string
 	InstanceId   string
 	ClusterName  string
+	ZoneId       string //实例所在可用区
+	InstanceType string //实例实际使用的规格, 可能是集群的备选规格
 	TaskId       int64 //扩容任务ID
 	ShrinkTaskId int64 //缩容任务ID
+	Protected    bool  //缩容时不释放
 	CreateAt     *time.Time
 	DeleteAt     *time.Time
 	RunningAt    *time.Time
+
+	HealthStatus   string //HEALTHY, UNHEALTHY, 未检查时为空
+	HealthFailures int    //连续检查失败次数
+	HealthCheckAt  *time.Time
 }
 
 func (Instance) TableName() string {
@@ -123,6 +131,96 @@ func GetActiveInstancesWithCount(clusterName string, count int) ([]Instance, err
 	return instances, nil
 }
 
+//GetActiveInstancesByInstanceIds 按实例ID获取状态不为deleted状态的节点
+func GetActiveInstancesByInstanceIds(ctx context.Context, instanceIds []string) ([]Instance, error) {
+	var instances []Instance
+	if err := clients.ReadDBCli.WithContext(ctx).Where("instance_id IN (?) AND status != ? ", instanceIds, constants.Deleted).Find(&instances).Error; err != nil {
+		logErr("GetActiveInstancesByInstanceIds from read db", err)
+		return instances, err
+	}
+	return instances, nil
+}
+
+//GetInstanceStatuses 获取未删除实例的当前状态, 从主库读取避免读到从库延迟的旧状态
+func GetInstanceStatuses(ctx context.Context, instanceIds []string) (map[string]constants.Status, error) {
+	var instances []Instance
+	err := clients.WriteDBCli.WithContext(ctx).Select("instance_id", "status").
+		Where("instance_id IN (?) AND status != ?", instanceIds, constants.Deleted).
+		Find(&instances).Error
+	if err != nil {
+		logErr("GetInstanceStatuses from write db", err)
+		return nil, err
+	}
+	statuses := make(map[string]constants.Status, len(instances))
+	for _, instance := range instances {
+		statuses[instance.InstanceId] = instance.Status
+	}
+	return statuses, nil
+}
+
+//GetActiveInstancesByTaskId 获取扩容任务创建的状态不为deleted状态的节点
+func GetActiveInstancesByTaskId(ctx context.Context, taskId int64) ([]Instance, error) {
+	var instances []Instance
+	if err := clients.ReadDBCli.WithContext(ctx).Where("task_id = ? AND status != ? ", taskId, constants.Deleted).Find(&instances).Error; err != nil {
+		logErr("GetActiveInstancesByTaskId from read db", err)
+		return instances, err
+	}
+	return instances, nil
+}
+
+//SetInstancesProtected 设置实例是否缩容保护, 使用 map 更新以便可以设置为 false
+func SetInstancesProtected(ctx context.Context, instanceIds []string, protected bool) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&Instance{}).
+		Where("instance_id IN (?) AND status != ?", instanceIds, constants.Deleted).
+		Updates(map[string]interface{}{"protected": protected}).Error
+	if err != nil {
+		logErr("SetInstancesProtected to write db", err)
+	}
+	return err
+}
+
+var errInstanceNotRunning = errors.New("instance is not running")
+
+//DeleteInstancesWithTask 在一个事务里把运行中的实例标记为已删除并创建补齐实例数量的任务,
+//任一实例已不在运行中(例如正在被缩容)时回滚并返回 false
+func DeleteInstancesWithTask(ctx context.Context, instanceIds []string, task *Task) (bool, error) {
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Instance{}).
+			Where("instance_id IN (?) AND status = ?", instanceIds, constants.Running).
+			Updates(map[string]interface{}{"status": constants.Deleted, "delete_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != int64(len(instanceIds)) {
+			return errInstanceNotRunning
+		}
+		return tx.Create(task).Error
+	})
+	if errors.Is(err, errInstanceNotRunning) {
+		return false, nil
+	}
+	if err != nil {
+		logErr("DeleteInstancesWithTask to write db", err)
+		return false, err
+	}
+	return true, nil
+}
+
+//UpdateInstanceHealth 记录实例最近一次健康检查的结果
+func UpdateInstanceHealth(ctx context.Context, instanceId, healthStatus string, failures int, checkAt time.Time) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&Instance{}).
+		Where("instance_id = ?", instanceId).
+		Updates(map[string]interface{}{
+			"health_status":   healthStatus,
+			"health_failures": failures,
+			"health_check_at": checkAt,
+		}).Error
+	if err != nil {
+		logErr("UpdateInstanceHealth to write db", err)
+	}
+	return err
+}
+
 //GetActiveInstancesByClusters 获取clusters下状态不为deleted状态的count个节点
 func GetActiveInstancesByClusters(ctx context.Context, clusterName []string) ([]Instance, error) {
 	var instances []Instance
@@ -184,10 +282,31 @@ type InstanceTypeCondition struct {
 	Provider string
 	RegionId string
 	ZoneId   string
+	Family   string
 	Core     int
 	Memory   int
 }
 
+//GetInstanceTypesByCond 查询已激活的指定核数内存的规格, 空条件不过滤
+func GetInstanceTypesByCond(ctx context.Context, cond InstanceTypeCondition) (ins []InstanceType, err error) {
+	query := clients.ReadDBCli.WithContext(ctx).Table(InstanceType{}.TableName()).
+		Where("i_status = ? AND core = ? AND memory = ?", InstanceTypeStatusActivated, cond.Core, cond.Memory)
+	if cond.Provider != "" {
+		query = query.Where("provider = ?", cond.Provider)
+	}
+	if cond.RegionId != "" {
+		query = query.Where("region_id = ?", cond.RegionId)
+	}
+	if cond.ZoneId != "" {
+		query = query.Where("zone_id = ?", cond.ZoneId)
+	}
+	if cond.Family != "" {
+		query = query.Where("family = ?", cond.Family)
+	}
+	err = query.Order("id").Find(&ins).Error
+	return ins, err
+}
+
 func ScanInstanceType(ctx context.Context) (ins []InstanceType, err error) {
 	err = clients.ReadDBCli.WithContext(ctx).Table(InstanceType{}.TableName()).
 		Where("i_status = ?", InstanceTypeStatusActivated).
@@ -203,6 +322,20 @@ func GetInstanceTypeByName(ctx context.Context, instanceTypeName string) (*Insta
 	return &ins, err
 }
 
+//GetInstanceTypeZones 查询已激活的规格在地域内可用的可用区
+func GetInstanceTypeZones(ctx context.Context, provider, regionId, typeName string) ([]string, error) {
+	zones := make([]string, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Table(InstanceType{}.TableName()).
+		Where("i_status = ? AND provider = ? AND region_id = ? AND type_name = ?", InstanceTypeStatusActivated, provider, regionId, typeName).
+		Distinct().
+		Pluck("zone_id", &zones).Error
+	if err != nil {
+		logErr("GetInstanceTypeZones from read db", err)
+		return nil, err
+	}
+	return zones, nil
+}
+
 type InstanceSearchCond struct {
 	Ip           string
 	InstanceId   string
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//InstanceHealthEvent 实例健康检查的失败记录和健康状态变化
+type InstanceHealthEvent struct {
+	Id          int64 `gorm:"primary_key"`
+	ClusterName string
+	InstanceId  string
+	EventType   string //FAILED, UNHEALTHY, RECOVERED, HEALED
+	Message     string
+	CreateAt    *time.Time
+}
+
+func (InstanceHealthEvent) TableName() string {
+	return "instance_health_event"
+}
+
+//GetInstanceHealthEvents 按时间倒序查询实例最近的 limit 条健康事件
+func GetInstanceHealthEvents(ctx context.Context, instanceId string, limit int) ([]InstanceHealthEvent, error) {
+	events := make([]InstanceHealthEvent, 0)
+	err := clients.ReadDBCli.WithContext(ctx).
+		Where("instance_id = ?", instanceId).
+		Order("id DESC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		logErr("GetInstanceHealthEvents from read db", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+//CountClusterHealthEvents 统计集群 since 之后某类健康事件的数量
+func CountClusterHealthEvents(ctx context.Context, clusterName, eventType string, since time.Time) (int64, error) {
+	var count int64
+	err := clients.ReadDBCli.WithContext(ctx).Model(&InstanceHealthEvent{}).
+		Where("cluster_name = ? AND event_type = ? AND create_at >= ?", clusterName, eventType, since).
+		Count(&count).Error
+	if err != nil {
+		logErr("CountClusterHealthEvents from read db", err)
+	}
+	return count, err
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//ScheduledAction 集群定时任务, 按 cron 表达式定时设置集群期望实例数量
+type ScheduledAction struct {
+	Base
+	ClusterName string
+	ActionName  string
+	Cron        string //标准 5 段 cron 表达式, 例如 0 8 * * 1-5
+	Timezone    string //IANA 时区, 例如 Asia/Shanghai
+	ExpectCount int
+	Status      string //ENABLE, PAUSED
+	LastRunAt   *time.Time
+
+	CreateBy string
+	UpdateBy string
+}
+
+func (ScheduledAction) TableName() string {
+	return "scheduled_action"
+}
+
+//GetScheduledActions 查询定时任务, clusterName 为空时查询所有集群
+func GetScheduledActions(ctx context.Context, clusterName string) ([]ScheduledAction, error) {
+	actions := make([]ScheduledAction, 0)
+	query := clients.ReadDBCli.WithContext(ctx)
+	if clusterName != "" {
+		query = query.Where("cluster_name = ?", clusterName)
+	}
+	if err := query.Order("id").Find(&actions).Error; err != nil {
+		logErr("GetScheduledActions from read db", err)
+		return nil, err
+	}
+	return actions, nil
+}
+
+//ClaimScheduledActionRun 把最近执行时间更新为本次触发时间, 返回 false 表示本次触发已被其他调度器执行
+func ClaimScheduledActionRun(ctx context.Context, id int64, fireAt time.Time) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Model(&ScheduledAction{}).
+		Where("id = ? AND (last_run_at IS NULL OR last_run_at < ?)", id, fireAt).
+		Update("last_run_at", fireAt)
+	if res.Error != nil {
+		logErr("ClaimScheduledActionRun to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+//UpdateScheduledActionStatus 暂停或恢复定时任务, 定时任务不存在时返回 false
+func UpdateScheduledActionStatus(ctx context.Context, id int64, status, updateBy string, now time.Time) (bool, error) {
+	db := clients.WriteDBCli.WithContext(ctx)
+	res := db.Model(&ScheduledAction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":    status,
+			"update_by": updateBy,
+			"update_at": now,
+		})
+	if res.Error != nil {
+		logErr("UpdateScheduledActionStatus to write db", res.Error)
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+	//同一秒内重复设置相同的状态时没有行被修改
+	var count int64
+	if err := db.Model(&ScheduledAction{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		logErr("UpdateScheduledActionStatus from write db", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+//DeleteScheduledAction 删除定时任务, 定时任务不存在时返回 false
+func DeleteScheduledAction(ctx context.Context, id int64) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Delete(&ScheduledAction{}, id)
+	if res.Error != nil {
+		logErr("DeleteScheduledAction to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
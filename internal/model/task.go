@@ -2,25 +2,36 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/spf13/cast"
+	"gorm.io/gorm"
 )
 
 type Task struct {
 	Base
 	TaskName      string     `json:"task_name"`
-	Status        string     `json:"status"`      //INIT, RUNNING, SUCCESS, FAILED
-	TaskAction    string     `json:"task_action"` //expand, shrink
+	Status        string     `json:"status"`      //QUEUED, INIT, RUNNING, SUCCESS, FAILED, PARTIAL_SUCCESS, CANCELLING, CANCELLED, COALESCED, PAUSED
+	TaskAction    string     `json:"task_action"` //expand, shrink, rolling_update
 	TaskFilter    string     `json:"task_filter"` //任务过滤，业务标识（如集群名等）
 	TaskInfo      string     `json:"task_info"`   //不同任务需要的不同的参数
 	ErrMsg        string     `json:"err_msg"`
 	TaskResult    string     `json:"task_result"`
 	SupportCancel bool       `json:"support_cancel"`
+	ParentTaskId  int64      `json:"parent_task_id"` //重试任务记录被重试的任务id
+	RetryTaskId   int64      `json:"retry_task_id"`  //被重试的任务记录重试任务id, 每个任务只能被重试一次
 	FinishTime    *time.Time `json:"finish_time"`
+
+	//执行进度, 扩容任务 CreatedCount 为已创建的实例数量, 缩容任务 ReleasedCount 为已释放的实例数量
+	RequestedCount int `json:"requested_count"`
+	CreatedCount   int `json:"created_count"`
+	ReleasedCount  int `json:"released_count"`
+	RunningCount   int `json:"running_count"`
+	FailedCount    int `json:"failed_count"`
 }
 
 func (Task) TableName() string {
@@ -48,6 +59,30 @@ type ShrinkTaskInfo struct {
 	UserId         int64  `json:"user_id"`
 }
 
+//ShrinkTaskRes 缩容挑选的待释放实例, 按 IP 缩容时没有终止策略
+type ShrinkTaskRes struct {
+	TerminationPolicies []string `json:"termination_policies,omitempty"`
+	InstanceIdList      []string `json:"instance_id_list"`
+}
+
+//RollingUpdateTaskInfo 滚动更新的参数, MaxSurge 和 MaxUnavailable 限制每批替换时 working_ips 比原来多或少的数量
+type RollingUpdateTaskInfo struct {
+	ClusterName    string `json:"cluster_name"`
+	BatchSize      int    `json:"batch_size"`
+	MaxSurge       int    `json:"max_surge"`
+	MaxUnavailable int    `json:"max_unavailable"`
+	TaskExecHost   string `json:"task_exec_host"`
+	TaskSubmitHost string `json:"task_submit_host"`
+	UserId         int64  `json:"user_id"`
+}
+
+//RollingUpdateTaskRes 已被替换的旧实例和替换后的新实例, 开启了缩容保护的旧实例不替换
+type RollingUpdateTaskRes struct {
+	ReplacedInstanceIdList  []string `json:"replaced_instance_id_list"`
+	NewInstanceIdList       []string `json:"new_instance_id_list"`
+	ProtectedInstanceIdList []string `json:"protected_instance_id_list,omitempty"`
+}
+
 func CountByTaskStatus(taskFilter string, statuses []string) (int64, error) {
 	var cnt int64
 	if err := clients.ReadDBCli.Model(&Task{}).Where("task_filter = ? AND status IN (?)", taskFilter, statuses).Count(&cnt).Error; err != nil {
@@ -67,10 +102,11 @@ func GetTaskByStatus(clusterName string, statuses []string) ([]Task, error) {
 	return tasks, nil
 }
 
-//GetExpireRunningTask 获取执行状态为Running并且最后更新时间（应该为执行时间）大于指定时间的所有的task
+//GetExpireRunningTask 获取执行状态为Running、Cancelling或Paused并且最后更新时间（应该为执行时间）大于指定时间的所有的task
 func GetExpireRunningTask(duration time.Duration) ([]Task, error) {
 	var tasks []Task
-	if err := clients.ReadDBCli.Where("status = ? AND update_at < ?  ", constants.TaskStatusRunning, time.Now().Add(-duration)).Find(&tasks).Error; err != nil {
+	statuses := []string{constants.TaskStatusRunning, constants.TaskStatusCancelling, constants.TaskStatusPaused}
+	if err := clients.ReadDBCli.Where("status IN (?) AND update_at < ?  ", statuses, time.Now().Add(-duration)).Find(&tasks).Error; err != nil {
 		logErr("GetExpireRunningTask from read db", err)
 		return tasks, err
 	}
@@ -87,6 +123,162 @@ func UpdateTaskStatus(taskIds []int64, status string) error {
 	return nil
 }
 
+//UpdateTaskIfStatus 任务当前状态在 statuses 中时才更新, 返回 false 表示状态已被其他流程修改
+func UpdateTaskIfStatus(ctx context.Context, id int64, statuses []string, updates map[string]interface{}) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Model(&Task{}).
+		Where("id = ? AND status IN (?)", id, statuses).
+		Updates(updates)
+	if res.Error != nil {
+		logErr("UpdateTaskIfStatus to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+//GetQueuedTasks 按入队顺序查询集群排队中的任务
+func GetQueuedTasks(ctx context.Context, clusterName string) ([]Task, error) {
+	tasks := make([]Task, 0)
+	err := clients.ReadDBCli.WithContext(ctx).
+		Where("task_filter = ? AND status = ?", clusterName, constants.TaskStatusQueued).
+		Order("id").
+		Find(&tasks).Error
+	if err != nil {
+		logErr("GetQueuedTasks from read db", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+//GetQueuedClusterNames 查询有排队任务的集群
+func GetQueuedClusterNames(ctx context.Context) ([]string, error) {
+	names := make([]string, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Model(&Task{}).
+		Distinct("task_filter").
+		Where("status = ?", constants.TaskStatusQueued).
+		Find(&names).Error
+	if err != nil {
+		logErr("GetQueuedClusterNames from read db", err)
+		return nil, err
+	}
+	return names, nil
+}
+
+//CountQueuedTasksBefore 查询集群中排在指定任务之前的排队任务数量
+func CountQueuedTasksBefore(ctx context.Context, clusterName string, id int64) (int64, error) {
+	var cnt int64
+	err := clients.ReadDBCli.WithContext(ctx).Model(&Task{}).
+		Where("task_filter = ? AND status = ? AND id < ?", clusterName, constants.TaskStatusQueued, id).
+		Count(&cnt).Error
+	if err != nil {
+		logErr("CountQueuedTasksBefore from read db", err)
+		return 0, err
+	}
+	return cnt, nil
+}
+
+var errTaskNotQueued = errors.New("task is not queued")
+
+//DequeueTasks 在一个事务里把被合并的排队任务按 coalescedUpdates 更新, 把出队的任务按 dispatchUpdates 更新,
+//dispatchId 为 0 表示没有任务出队. 任一任务已不在排队中(例如被取消)时回滚并返回 false
+func DequeueTasks(ctx context.Context, dispatchId int64, dispatchUpdates map[string]interface{}, coalescedIds []int64, coalescedUpdates map[string]interface{}) (bool, error) {
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(coalescedIds) > 0 {
+			res := tx.Model(&Task{}).
+				Where("id IN (?) AND status = ?", coalescedIds, constants.TaskStatusQueued).
+				Updates(coalescedUpdates)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected != int64(len(coalescedIds)) {
+				return errTaskNotQueued
+			}
+		}
+		if dispatchId == 0 {
+			return nil
+		}
+		res := tx.Model(&Task{}).
+			Where("id = ? AND status = ?", dispatchId, constants.TaskStatusQueued).
+			Updates(dispatchUpdates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return errTaskNotQueued
+		}
+		return nil
+	})
+	if errors.Is(err, errTaskNotQueued) {
+		return false, nil
+	}
+	if err != nil {
+		logErr("DequeueTasks to write db", err)
+		return false, err
+	}
+	return true, nil
+}
+
+//IncrTaskProgress 累加任务的进度计数, 并发的批次各自累加, 不会互相覆盖
+func IncrTaskProgress(ctx context.Context, id int64, column string, delta int) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&Task{}).
+		Where("id = ?", id).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+	if err != nil {
+		logErr("IncrTaskProgress to write db", err)
+	}
+	return err
+}
+
+var errTaskRetried = errors.New("task has been retried")
+
+//CreateRetryTask 在一个事务里记录被重试任务的 retry_task_id 并创建重试任务,
+//被重试任务已被重试或状态已不在 statuses 中时回滚并返回 false
+func CreateRetryTask(ctx context.Context, task *Task, statuses []string) (bool, error) {
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Task{}).
+			Where("id = ? AND retry_task_id = 0 AND status IN (?)", task.ParentTaskId, statuses).
+			Updates(map[string]interface{}{"retry_task_id": task.Id})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return errTaskRetried
+		}
+		return tx.Create(task).Error
+	})
+	if errors.Is(err, errTaskRetried) {
+		return false, nil
+	}
+	if err != nil {
+		logErr("CreateRetryTask to write db", err)
+		return false, err
+	}
+	return true, nil
+}
+
+//CreateTaskWithExpectDelta 创建任务, 声明式集群在同一事务中把期望实例数量调整 delta
+func CreateTaskWithExpectDelta(ctx context.Context, task *Task, clusterName string, delta int) error {
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return incrExpectCount(tx, clusterName, delta)
+	})
+	if err != nil {
+		logErr("CreateTaskWithExpectDelta to write db", err)
+	}
+	return err
+}
+
+//GetRetryTasks 获取重试指定任务创建的子任务
+func GetRetryTasks(ctx context.Context, parentTaskId int64) ([]Task, error) {
+	tasks := make([]Task, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Where("parent_task_id = ?", parentTaskId).Order("id").Find(&tasks).Error; err != nil {
+		logErr("GetRetryTasks from read db", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
 func GetTaskCount(ctx context.Context, clusterNames []string) (int64, error) {
 	var cnt int64
 	if err := clients.ReadDBCli.WithContext(ctx).Model(&Task{}).Where("task_filter IN (?) ", clusterNames).Count(&cnt).Error; err != nil {
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//TaskEvent 任务执行过程中的步骤事件
+type TaskEvent struct {
+	Id        int64 `gorm:"primary_key"`
+	TaskId    int64
+	EventType string //STARTED, BATCH_SUBMITTED, BATCH_RETURNED, WAITING_IP, DB_SAVED, CONFIG_PUBLISHED, REPAIRED, RELEASED, FINISHED
+	Message   string
+	CreateAt  *time.Time
+}
+
+func (TaskEvent) TableName() string {
+	return "task_event"
+}
+
+//GetTaskEvents 按发生顺序查询任务 id 大于 afterId 的事件, 最多返回 limit 条
+func GetTaskEvents(ctx context.Context, taskId, afterId int64, limit int) ([]TaskEvent, error) {
+	events := make([]TaskEvent, 0)
+	err := clients.ReadDBCli.WithContext(ctx).
+		Where("task_id = ? AND id > ?", taskId, afterId).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		logErr("GetTaskEvents from read db", err)
+		return nil, err
+	}
+	return events, nil
+}
@@ -2,6 +2,7 @@ package pool
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
@@ -14,7 +15,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 )
 
-func doExpand(task *model.Task) {
+func doExpand(ctx context.Context, task *model.Task) {
 	logs.Logger.Infof("Executing Task:%v, %v [%v], task info:%v", task.Id, task.TaskAction, task.TaskFilter, task.TaskInfo)
 	taskInfo := &model.ExpandTaskInfo{}
 	err := jsoniter.UnmarshalFromString(task.TaskInfo, taskInfo)
@@ -24,6 +25,7 @@ func doExpand(task *model.Task) {
 	}
 	taskInfo.TaskExecHost = utils.PrivateIPv4()
 	task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+	taskStarted(task, taskInfo.TaskExecHost, taskInfo.Count)
 	cluster, err := model.GetByClusterName(taskInfo.ClusterName)
 	if err != nil {
 		taskFailed(task, err)
@@ -35,14 +37,22 @@ func doExpand(task *model.Task) {
 		taskFailed(task, err)
 		return
 	}
-	instances, err := service.ExpandCluster(clusterInfo, taskInfo.Count, task.Id)
+	instances, err := service.ExpandCluster(ctx, clusterInfo, taskInfo.Count, task.Id)
+	//被取消时 ExpandCluster 已经释放了本次创建的实例
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		taskInterrupted(task, ctx.Err())
+		return
+	}
+	instanceIds := make([]string, 0)
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, instance.Id)
+	}
+	if len(instanceIds) > 0 {
+		task.TaskResult, _ = jsoniter.MarshalToString(model.ExpandTaskRes{InstanceIdList: instanceIds})
+	}
 	if len(instances) == taskInfo.Count {
-		taskSuccess(task, "")
+		taskSuccess(task, task.TaskResult)
 	} else {
-		instanceIds := make([]string, 0)
-		for _, instance := range instances {
-			instanceIds = append(instanceIds, instance.Id)
-		}
 		_ = service.RepairCluster(clusterInfo, task.Id, instanceIds)
 		if len(instances) == 0 {
 			taskFailed(task, err)
@@ -54,7 +64,7 @@ func doExpand(task *model.Task) {
 
 // DoExpand for test
 func DoExpand(task *model.Task) {
-	doExpand(task)
+	doExpand(context.Background(), task)
 }
 
 func taskPartialSuccess(task *model.Task, err error) {
@@ -62,20 +72,15 @@ func taskPartialSuccess(task *model.Task, err error) {
 	if err != nil {
 		task.ErrMsg = err.Error()
 	}
-	ft := time.Now()
-	task.FinishTime = &ft
-	_ = model.Save(task)
+	finishTask(task)
 	logs.Logger.Warnf("Task PartialSuccess:%v, %v, %v", task.Id, task.TaskAction, task.TaskInfo)
-	_ = model.Save(task)
 }
 
 func taskSuccess(task *model.Task, s string) {
 	task.TaskResult = s
 	task.Status = constants.TaskStatusSuccess
-	ft := time.Now()
-	task.FinishTime = &ft
 	logs.Logger.Warnf("Task Success:%v, %v, %v", task.Id, task.TaskAction, task.TaskInfo)
-	_ = model.Save(task)
+	finishTask(task)
 }
 
 func taskFailed(task *model.Task, err error) {
@@ -83,13 +88,113 @@ func taskFailed(task *model.Task, err error) {
 	if err != nil {
 		task.ErrMsg = err.Error()
 	}
+	finishTask(task)
+	logs.Logger.Warnf("Task Failed:%v, %v, %v", task.Id, task.TaskAction, task.TaskInfo)
+}
+
+//taskInterrupted 任务被中断, 用户取消时记为 CANCELLED, 超时或被 TaskKiller 标记失败时记为 FAILED
+func taskInterrupted(task *model.Task, err error) {
+	current := &model.Task{}
+	if gErr := model.Get(task.Id, current); gErr == nil && current.Status == constants.TaskStatusCancelling {
+		task.Status = constants.TaskStatusCancelled
+		finishTask(task)
+		logs.Logger.Warnf("Task Cancelled:%v, %v, %v", task.Id, task.TaskAction, task.TaskInfo)
+		return
+	}
+	taskFailed(task, err)
+}
+
+//taskStarted 记录任务开始执行的事件和请求的实例数量
+func taskStarted(task *model.Task, host string, count int) {
+	service.RecordTaskEvent(task.Id, constants.TaskEventStarted, "task started on %s, requested %d instances", host, count)
+	service.AddTaskProgress(task.Id, constants.TaskProgressRequested, count)
+}
+
+//finishTask 只更新执行中或取消中的任务, 避免覆盖 TaskKiller 已经设置的结果
+func finishTask(task *model.Task) {
 	ft := time.Now()
 	task.FinishTime = &ft
-	_ = model.Save(task)
-	logs.Logger.Warnf("Task Failed:%v, %v, %v", task.Id, task.TaskAction, task.TaskInfo)
+	if task.Status == constants.TaskStatusFailed || task.Status == constants.TaskStatusPartialSuccess {
+		task.FailedCount = calcFailedCount(task)
+	}
+	ok, _ := model.UpdateTaskIfStatus(context.Background(), task.Id, []string{constants.TaskStatusRunning, constants.TaskStatusCancelling, constants.TaskStatusPaused}, map[string]interface{}{
+		"status":       task.Status,
+		"task_info":    task.TaskInfo,
+		"task_result":  task.TaskResult,
+		"err_msg":      task.ErrMsg,
+		"failed_count": task.FailedCount,
+		"finish_time":  ft,
+		"update_at":    ft,
+	})
+	if !ok {
+		return
+	}
+	if task.ErrMsg != "" {
+		service.RecordTaskEvent(task.Id, constants.TaskEventFinished, "task finished with status %s, error: %s", task.Status, task.ErrMsg)
+	} else {
+		service.RecordTaskEvent(task.Id, constants.TaskEventFinished, "task finished with status %s", task.Status)
+	}
+}
+
+//calcFailedCount 任务结束时没有完成的实例数量, 扩容以获得 IP 的实例为准, 缩容以已释放的实例为准, 滚动更新以已替换的实例为准
+func calcFailedCount(task *model.Task) int {
+	current := &model.Task{}
+	if err := model.Get(task.Id, current); err != nil {
+		return 0
+	}
+	done := current.RunningCount
+	switch task.TaskAction {
+	case constants.TaskActionShrink:
+		done = current.ReleasedCount
+	case constants.TaskActionRollingUpdate:
+		res := model.RollingUpdateTaskRes{}
+		_ = jsoniter.UnmarshalFromString(task.TaskResult, &res)
+		done = len(res.ReplacedInstanceIdList)
+	}
+	if current.RequestedCount > done {
+		return current.RequestedCount - done
+	}
+	return 0
 }
 
-func doShrink(task *model.Task) {
+//watchTask 定期检查任务状态, 任务不再是执行中或暂停中(被取消或被 TaskKiller 标记失败)时取消 ctx
+func watchTask(ctx context.Context, cancel context.CancelFunc, taskId int64) {
+	ticker := time.NewTicker(constants.DefaultTaskCancelCheckInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			task := &model.Task{}
+			if err := model.Get(taskId, task); err != nil {
+				continue
+			}
+			if task.Status != constants.TaskStatusRunning && task.Status != constants.TaskStatusPaused {
+				logs.Logger.Infof("Task %v is %v, stop executing", taskId, task.Status)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+//heartbeatTask 定期刷新执行中、暂停中或取消中任务的 update_at
+func heartbeatTask(ctx context.Context, taskId int64) {
+	ticker := time.NewTicker(constants.DefaultTaskHeartbeatInterval * time.Second)
+	defer ticker.Stop()
+	statuses := []string{constants.TaskStatusRunning, constants.TaskStatusPaused, constants.TaskStatusCancelling}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = model.UpdateTaskIfStatus(ctx, taskId, statuses, map[string]interface{}{"update_at": time.Now()})
+		}
+	}
+}
+
+func doShrink(ctx context.Context, task *model.Task) {
 	logs.Logger.Infof("Executing Task:%v, %v [%v], task info:%v", task.Id, task.TaskAction, task.TaskFilter, task.TaskInfo)
 	taskInfo := &model.ShrinkTaskInfo{}
 	err := jsoniter.UnmarshalFromString(task.TaskInfo, taskInfo)
@@ -99,6 +204,7 @@ func doShrink(task *model.Task) {
 	}
 	taskInfo.TaskExecHost = utils.PrivateIPv4()
 	task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+	taskStarted(task, taskInfo.TaskExecHost, taskInfo.Count)
 	cluster, err := model.GetByClusterName(taskInfo.ClusterName)
 	if err != nil {
 		taskFailed(task, err)
@@ -110,17 +216,78 @@ func doShrink(task *model.Task) {
 		taskFailed(task, err)
 		return
 	}
+	var res model.ShrinkTaskRes
 	deletingIPs := calcDeletingIPs(taskInfo.IPs)
 	if deletingIPs > 0 {
-		err = service.ShrinkClusterBySpecificIps(clusterInfo, taskInfo.IPs, taskInfo.Count, task.Id)
+		res, err = service.ShrinkClusterBySpecificIps(ctx, clusterInfo, taskInfo.IPs, taskInfo.Count, task.Id)
 	} else {
-		err = service.ShrinkCluster(clusterInfo, taskInfo.Count, task.Id)
+		res, err = service.ShrinkCluster(ctx, clusterInfo, taskInfo.Count, task.Id)
+	}
+	if len(res.InstanceIdList) > 0 {
+		task.TaskResult, _ = jsoniter.MarshalToString(res)
 	}
+	if ctx.Err() != nil {
+		taskInterrupted(task, ctx.Err())
+		return
+	}
+	//受保护的实例导致释放数量不足时, 已释放了部分实例的任务为部分成功
+	if errors.Is(err, service.ErrProtectedShortfall) && len(res.InstanceIdList) > 0 {
+		taskPartialSuccess(task, err)
+		return
+	}
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	taskSuccess(task, task.TaskResult)
+}
+
+func doRollingUpdate(ctx context.Context, task *model.Task) {
+	logs.Logger.Infof("Executing Task:%v, %v [%v], task info:%v", task.Id, task.TaskAction, task.TaskFilter, task.TaskInfo)
+	taskInfo := &model.RollingUpdateTaskInfo{}
+	err := jsoniter.UnmarshalFromString(task.TaskInfo, taskInfo)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	taskInfo.TaskExecHost = utils.PrivateIPv4()
+	task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+	cluster, err := model.GetByClusterName(taskInfo.ClusterName)
 	if err != nil {
 		taskFailed(task, err)
 		return
 	}
-	taskSuccess(task, "")
+	tags, _ := service.GetClusterTagsByClusterName(context.Background(), taskInfo.ClusterName)
+	clusterInfo, err := service.ConvertToClusterInfo(cluster, tags)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	outdated, protected, err := service.OutdatedInstances(clusterInfo)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	taskStarted(task, taskInfo.TaskExecHost, len(outdated))
+	res, err := service.RollingUpdateCluster(ctx, clusterInfo, taskInfo, outdated, task.Id)
+	for _, instance := range protected {
+		res.ProtectedInstanceIdList = append(res.ProtectedInstanceIdList, instance.InstanceId)
+	}
+	task.TaskResult, _ = jsoniter.MarshalToString(res)
+	//被取消时正在替换的批次已经回滚
+	if ctx.Err() != nil {
+		taskInterrupted(task, ctx.Err())
+		return
+	}
+	if err != nil {
+		if len(res.ReplacedInstanceIdList) == 0 {
+			taskFailed(task, err)
+		} else {
+			taskPartialSuccess(task, err)
+		}
+		return
+	}
+	taskSuccess(task, task.TaskResult)
 }
 
 func calcDeletingIPs(IPs string) int {
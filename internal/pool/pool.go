@@ -1,18 +1,26 @@
 package pool
 
 import (
+	"context"
+	"time"
+
 	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
 )
 
 var expandWorkerPool gopool.Pool
 var shrinkWorkerPool gopool.Pool
+var rollingUpdateWorkerPool gopool.Pool
 var ExpandTasksChan = make(chan *model.Task, 100)
 var ShrinkTasksChan = make(chan *model.Task, 100)
+var RollingUpdateTasksChan = make(chan *model.Task, 100)
 
 func init() {
 	expandWorkerPool = gopool.NewPool("expand-worker-pool", 100, gopool.NewConfig())
 	shrinkWorkerPool = gopool.NewPool("shrink-worker-pool", 100, gopool.NewConfig())
+	rollingUpdateWorkerPool = gopool.NewPool("rolling-update-worker-pool", 100, gopool.NewConfig())
 	go daemon()
 }
 
@@ -22,15 +30,48 @@ func daemon() {
 		case et, ok := <-ExpandTasksChan:
 			if ok {
 				expandWorkerPool.Go(func() {
-					doExpand(et)
+					runTask(et, doExpand)
 				})
 			}
 		case st, ok := <-ShrinkTasksChan:
 			if ok {
 				shrinkWorkerPool.Go(func() {
-					doShrink(st)
+					runTask(st, doShrink)
+				})
+			}
+		case rt, ok := <-RollingUpdateTasksChan:
+			if ok {
+				rollingUpdateWorkerPool.Go(func() {
+					runLongTask(rt, doRollingUpdate)
 				})
 			}
 		}
 	}
 }
+
+//runTask 任务最长执行 DefaultTaskMaxRunningDuration, 执行期间被取消时 ctx 也会被取消.
+//集群配置了生命周期钩子时再加上等待钩子的最长时间, 并定期刷新 update_at
+func runTask(task *model.Task, do func(ctx context.Context, task *model.Task)) {
+	if d := service.LifecycleHookDuration(task); d > 0 {
+		runWithHeartbeat(task, constants.DefaultTaskMaxRunningDuration+d, do)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultTaskMaxRunningDuration)
+	defer cancel()
+	go watchTask(ctx, cancel, task.Id)
+	do(ctx, task)
+}
+
+//runLongTask 滚动更新最长执行 DefaultRollingUpdateMaxDuration
+func runLongTask(task *model.Task, do func(ctx context.Context, task *model.Task)) {
+	runWithHeartbeat(task, constants.DefaultRollingUpdateMaxDuration, do)
+}
+
+//runWithHeartbeat 执行期间定期刷新 update_at, 避免超过 DefaultTaskMaxRunningDuration 的任务被 TaskKiller 标记失败
+func runWithHeartbeat(task *model.Task, maxDuration time.Duration, do func(ctx context.Context, task *model.Task)) {
+	ctx, cancel := context.WithTimeout(context.Background(), maxDuration)
+	defer cancel()
+	go watchTask(ctx, cancel, task.Id)
+	go heartbeatTask(ctx, task.Id)
+	do(ctx, task)
+}
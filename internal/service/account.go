@@ -8,7 +8,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/types"
-	"github.com/galaxy-future/BridgX/pkg/cloud/alibaba"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
 )
 
 func GetAccounts(provider, accountName, accountKey string, pageNum, pageSize int) ([]model.Account, int64, error) {
@@ -50,8 +50,12 @@ func GetDefaultAccount(provider string) (*types.OrgKeys, error) {
 	return &account, err
 }
 
-func CheckAccountValid(ak, sk string) error {
-	cli, err := alibaba.New(ak, sk, DefaultRegion)
+func CheckAccountValid(provider, ak, sk string) error {
+	driver, err := cloud.GetProviderDriver(provider)
+	if err != nil {
+		return err
+	}
+	cli, err := driver(ak, sk, getDefaultRegion(provider))
 	if err != nil {
 		return err
 	}
@@ -130,6 +134,9 @@ func DeleteCloudAccount(ctx context.Context, ids []int64, orgId int64) error {
 	if err != nil {
 		return err
 	}
+	for _, account := range accounts {
+		evictProviders(account.AccountKey)
+	}
 	return nil
 }
 
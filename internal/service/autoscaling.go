@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/metrics"
+	jsoniter "github.com/json-iterator/go"
+	"gorm.io/gorm"
+)
+
+func init() {
+	metrics.RegisterSource(metrics.SourcePush, pushSource{})
+}
+
+// InitMetricSources 按配置注册需要地址的指标数据源
+func InitMetricSources(cfg config.MetricConfig) {
+	if cfg.PrometheusAddress != "" {
+		metrics.RegisterSource(metrics.SourcePrometheus, metrics.NewPrometheus(cfg.PrometheusAddress))
+	}
+}
+
+// pushSource 读取业务通过 PushMetric 上报的最新指标, 超过 DefaultPushMetricTTL 未上报视为无数据
+type pushSource struct{}
+
+func (pushSource) Query(ctx context.Context, req metrics.QueryRequest) (float64, error) {
+	metric, err := model.GetClusterMetric(ctx, req.ClusterName, req.MetricName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, metrics.ErrNoData
+	}
+	if err != nil {
+		return 0, err
+	}
+	if metric.ReportAt == nil || time.Since(*metric.ReportAt) > constants.DefaultPushMetricTTL*time.Second {
+		return 0, metrics.ErrNoData
+	}
+	return metric.Value, nil
+}
+
+// PushMetric 业务上报集群的负载指标
+func PushMetric(ctx context.Context, clusterName, metricName string, value float64) error {
+	now := time.Now()
+	return model.SaveClusterMetric(ctx, &model.ClusterMetric{
+		ClusterName: clusterName,
+		MetricName:  metricName,
+		Value:       value,
+		ReportAt:    &now,
+	})
+}
+
+func CreateAutoscalingPolicy(ctx context.Context, policy *model.AutoscalingPolicy) error {
+	if _, err := model.GetByClusterName(policy.ClusterName); err != nil {
+		return err
+	}
+	if err := checkAutoscalingPolicy(policy); err != nil {
+		return err
+	}
+	now := time.Now()
+	policy.CreateAt = &now
+	policy.UpdateAt = &now
+	return model.Create(policy)
+}
+
+func checkAutoscalingPolicy(policy *model.AutoscalingPolicy) error {
+	if policy.PolicyName == "" {
+		return errors.New("missing policy name")
+	}
+	if _, err := metrics.GetSource(policy.MetricSource); err != nil {
+		return err
+	}
+	if policy.MetricSource == metrics.SourcePush && policy.MetricName == "" {
+		return errors.New("metric name is required for push metric source")
+	}
+	if policy.MetricSource != metrics.SourcePush && policy.MetricQuery == "" {
+		return errors.New("missing metric query")
+	}
+	if policy.MinCount < 0 || policy.MaxCount <= 0 || policy.MinCount > policy.MaxCount {
+		return errors.New("invalid min count or max count")
+	}
+	if policy.ScaleOutCooldown < 0 || policy.ScaleInCooldown < 0 {
+		return errors.New("invalid cooldown")
+	}
+	switch policy.PolicyType {
+	case constants.AutoscalingTargetTracking:
+		if policy.TargetValue <= 0 {
+			return errors.New("target value must be positive")
+		}
+	case constants.AutoscalingStepScaling:
+		steps, err := parseScalingSteps(policy.Steps)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			return errors.New("missing scaling steps")
+		}
+		for _, step := range steps {
+			if step.LowerBound != nil && step.UpperBound != nil && *step.LowerBound >= *step.UpperBound {
+				return errors.New("lower bound must be less than upper bound")
+			}
+		}
+	default:
+		return errors.New("invalid policy type")
+	}
+	return nil
+}
+
+func parseScalingSteps(s string) ([]types.ScalingStep, error) {
+	steps := make([]types.ScalingStep, 0)
+	if s == "" {
+		return steps, nil
+	}
+	if err := jsoniter.UnmarshalFromString(s, &steps); err != nil {
+		return nil, fmt.Errorf("invalid scaling steps: %w", err)
+	}
+	return steps, nil
+}
+
+func ListAutoscalingPolicies(ctx context.Context, clusterName string) ([]model.AutoscalingPolicy, error) {
+	return model.GetAutoscalingPolicies(ctx, clusterName, false)
+}
+
+func SetAutoscalingPolicyEnabled(ctx context.Context, id int64, enabled bool) error {
+	return model.Updates(&model.AutoscalingPolicy{}, []int64{id}, map[string]interface{}{
+		"enabled":   enabled,
+		"update_at": time.Now(),
+	})
+}
+
+func DeleteAutoscalingPolicy(ctx context.Context, id int64) error {
+	return model.Delete(&model.AutoscalingPolicy{Base: model.Base{Id: id}})
+}
+
+func ListAutoscalingDecisions(ctx context.Context, clusterName string, pageNum, pageSize int) ([]model.AutoscalingDecision, int64, error) {
+	return model.ListAutoscalingDecisions(ctx, clusterName, pageNum, pageSize)
+}
+
+// desiredCount 根据指标计算策略期望的实例数量, 结果限制在 [MinCount, MaxCount] 内
+func desiredCount(policy *model.AutoscalingPolicy, steps []types.ScalingStep, current int, value float64) int {
+	desired := current
+	switch policy.PolicyType {
+	case constants.AutoscalingTargetTracking:
+		ratio := value / policy.TargetValue
+		if current > 0 && math.Abs(ratio-1) > constants.AutoscalingTolerance {
+			desired = int(math.Ceil(float64(current) * ratio))
+		}
+	case constants.AutoscalingStepScaling:
+		for _, step := range steps {
+			if step.Contains(value) {
+				desired = current + step.Adjustment
+				break
+			}
+		}
+	}
+	if desired < policy.MinCount {
+		desired = policy.MinCount
+	}
+	if desired > policy.MaxCount {
+		desired = policy.MaxCount
+	}
+	return desired
+}
+
+// inCooldown 策略上次触发扩缩容后, 扩容和缩容分别在各自的冷却时间内不再触发
+func inCooldown(policy *model.AutoscalingPolicy, expand bool, now time.Time) bool {
+	if policy.LastScaleAt == nil {
+		return false
+	}
+	cooldown := policy.ScaleInCooldown
+	if expand {
+		cooldown = policy.ScaleOutCooldown
+	}
+	return now.Sub(*policy.LastScaleAt) < time.Duration(cooldown)*time.Second
+}
+
+type scalingProposal struct {
+	policy  *model.AutoscalingPolicy
+	value   float64
+	desired int
+}
+
+// EvaluateAutoscaling 计算集群所有启用策略的期望数量并取最大值, 即任一策略要求扩容就扩容, 所有策略都允许缩容才缩容
+func EvaluateAutoscaling(clusterName string) error {
+	ctx := context.Background()
+	policies, err := model.GetAutoscalingPolicies(ctx, clusterName, true)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+	//有任务执行时实例数量还在变化，等待下一轮检查
+	if hasUnfinishedTask(clusterName) {
+		return nil
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	current := len(instances)
+	var best *scalingProposal
+	for i := range policies {
+		policy := &policies[i]
+		value, err := queryPolicyMetric(ctx, policy)
+		if err != nil {
+			logs.Logger.Warnf("[EvaluateAutoscaling] cluster: %s, policy: %d, query metric error: %v", clusterName, policy.Id, err)
+			continue
+		}
+		steps, err := parseScalingSteps(policy.Steps)
+		if err != nil {
+			logs.Logger.Errorf("[EvaluateAutoscaling] cluster: %s, policy: %d, error: %v", clusterName, policy.Id, err)
+			continue
+		}
+		desired := desiredCount(policy, steps, current, value)
+		if best == nil || desired > best.desired {
+			best = &scalingProposal{policy: policy, value: value, desired: desired}
+		}
+	}
+	if best == nil || best.desired == current {
+		return nil
+	}
+	now := time.Now()
+	expand := best.desired > current
+	if inCooldown(best.policy, expand, now) {
+		return nil
+	}
+	return applyScalingProposal(ctx, clusterName, current, best, now)
+}
+
+func queryPolicyMetric(ctx context.Context, policy *model.AutoscalingPolicy) (float64, error) {
+	source, err := metrics.GetSource(policy.MetricSource)
+	if err != nil {
+		return 0, err
+	}
+	return source.Query(ctx, metrics.QueryRequest{
+		ClusterName: policy.ClusterName,
+		MetricName:  policy.MetricName,
+		Query:       policy.MetricQuery,
+	})
+}
+
+// applyScalingProposal 创建扩缩容任务并记录决策, 声明式集群同步调整期望数量
+func applyScalingProposal(ctx context.Context, clusterName string, current int, proposal *scalingProposal, now time.Time) error {
+	decision := &model.AutoscalingDecision{
+		ClusterName:  clusterName,
+		PolicyId:     proposal.policy.Id,
+		MetricValue:  proposal.value,
+		CurrentCount: current,
+		DesiredCount: proposal.desired,
+		CreateAt:     &now,
+	}
+	delta := proposal.desired - current
+	var err error
+	if delta > 0 {
+		decision.Action = constants.AutoscalingActionExpand
+		decision.TaskId, err = CreateExpandTaskWithExpectCount(ctx, clusterName, delta, constants.TaskNameAutoscaling, 0)
+	} else {
+		decision.Action = constants.AutoscalingActionShrink
+		decision.TaskId, err = CreateShrinkTaskWithExpectCount(ctx, clusterName, -delta, "", constants.TaskNameAutoscaling, 0)
+	}
+	decision.Reason = fmt.Sprintf("%s policy %s: metric value %v", proposal.policy.PolicyType, proposal.policy.PolicyName, proposal.value)
+	if err != nil {
+		decision.Action = constants.AutoscalingActionFailed
+		decision.Reason += ", error: " + err.Error()
+	} else if uErr := model.UpdatePolicyLastScaleAt(ctx, proposal.policy.Id, now); uErr != nil {
+		logs.Logger.Errorf("[EvaluateAutoscaling] update policy %d last scale time error: %v", proposal.policy.Id, uErr)
+	}
+	logs.Logger.Infof("[EvaluateAutoscaling] cluster: %s, decision: %+v", clusterName, decision)
+	if cErr := model.Create(decision); cErr != nil {
+		logs.Logger.Errorf("[EvaluateAutoscaling] save decision error: %v", cErr)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+func TestDesiredCount(t *testing.T) {
+	policy := &model.AutoscalingPolicy{PolicyType: constants.AutoscalingTargetTracking, TargetValue: 50, MinCount: 1, MaxCount: 10}
+	tests := []struct {
+		current int
+		value   float64
+		want    int
+	}{
+		{current: 4, value: 75, want: 6},
+		{current: 4, value: 52, want: 4},
+		{current: 4, value: 20, want: 2},
+		{current: 4, value: 500, want: 10},
+		{current: 4, value: 0, want: 1},
+	}
+	for _, tt := range tests {
+		if got := desiredCount(policy, nil, tt.current, tt.value); got != tt.want {
+			t.Errorf("target tracking current %d value %v got %d, want %d", tt.current, tt.value, got, tt.want)
+		}
+	}
+
+	low, high := 30.0, 80.0
+	steps := []types.ScalingStep{
+		{UpperBound: &low, Adjustment: -1},
+		{LowerBound: &high, Adjustment: 2},
+	}
+	policy = &model.AutoscalingPolicy{PolicyType: constants.AutoscalingStepScaling, MinCount: 2, MaxCount: 5}
+	if got := desiredCount(policy, steps, 4, 90); got != 5 {
+		t.Errorf("step scaling expand got %d", got)
+	}
+	if got := desiredCount(policy, steps, 3, 10); got != 2 {
+		t.Errorf("step scaling shrink got %d", got)
+	}
+	if got := desiredCount(policy, steps, 2, 10); got != 2 {
+		t.Errorf("step scaling at min count got %d", got)
+	}
+	if got := desiredCount(policy, steps, 3, 50); got != 3 {
+		t.Errorf("step scaling without matched step got %d", got)
+	}
+}
+
+func TestInCooldown(t *testing.T) {
+	now := time.Now()
+	policy := &model.AutoscalingPolicy{ScaleOutCooldown: 60, ScaleInCooldown: 300}
+	if inCooldown(policy, true, now) {
+		t.Error("policy never scaled should not be in cooldown")
+	}
+	last := now.Add(-2 * time.Minute)
+	policy.LastScaleAt = &last
+	if inCooldown(policy, true, now) {
+		t.Error("expand should be out of cooldown")
+	}
+	if !inCooldown(policy, false, now) {
+		t.Error("shrink should be in cooldown")
+	}
+}
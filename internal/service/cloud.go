@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime/debug"
 	"strconv"
 	"sync"
@@ -17,12 +18,26 @@ import (
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
-	"github.com/galaxy-future/BridgX/pkg/cloud/alibaba"
 )
 
 var clientMap sync.Map
 
-func ExpandAndRepair(c *types.ClusterInfo, num int, taskId int64) ([]string, error) {
+// ExpandedInstance 扩容创建的实例及其所在可用区
+type ExpandedInstance struct {
+	InstanceId   string
+	ZoneId       string
+	InstanceType string
+}
+
+func expandedInstanceIds(instances []ExpandedInstance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	return ids
+}
+
+func ExpandAndRepair(ctx context.Context, c *types.ClusterInfo, num int, taskId int64) ([]ExpandedInstance, error) {
 	tags := []cloud.Tag{{
 		Key:   cloud.TaskId,
 		Value: strconv.FormatInt(taskId, 10),
@@ -31,25 +46,29 @@ func ExpandAndRepair(c *types.ClusterInfo, num int, taskId int64) ([]string, err
 			Key:   cloud.ClusterName,
 			Value: c.Name,
 		}}
-	expandInstanceIds := make([]string, 0)
+	expandInstances := make([]ExpandedInstance, 0)
 	needExpandNum := num
 	var err error
-	var ids []string
-	for k := 0; k < constants.Retry; k++ {
-		ids, err = Expand(c, tags, needExpandNum)
+	var instances []ExpandedInstance
+	for k := 0; k < constants.Retry && ctx.Err() == nil; k++ {
+		instances, err = expand(ctx, c, tags, needExpandNum, len(expandInstances), taskId)
 		if err != nil {
 			logs.Logger.Errorf("[ExpandCLuster] Expand retry error, times: %d, error: %s", k, err.Error())
 		}
-		expandInstanceIds = append(expandInstanceIds, ids...)
-		if len(expandInstanceIds) == num {
+		expandInstances = append(expandInstances, instances...)
+		if len(expandInstances) == num {
 			break
 		}
-		needExpandNum -= len(ids)
+		needExpandNum -= len(instances)
+	}
+	//被取消时由 ExpandCluster 释放全部实例
+	if len(expandInstances) != num && ctx.Err() == nil {
+		_ = repairTaskInstances(c, taskId, expandedInstanceIds(expandInstances))
 	}
-	if len(expandInstanceIds) != num {
-		_ = RepairCluster(c, taskId, expandInstanceIds)
+	if ctx.Err() != nil {
+		err = ctx.Err()
 	}
-	return expandInstanceIds, err
+	return expandInstances, err
 }
 
 func RepairCluster(c *types.ClusterInfo, taskId int64, instanceIds []string) (err error) {
@@ -91,7 +110,8 @@ func RepairCluster(c *types.ClusterInfo, taskId int64, instanceIds []string) (er
 	if err != nil {
 		logs.Logger.Errorf("[RepairCluster] taskId: %d, ClusterName: %s, UpdateDB InstanceIds error: %s", taskId, c.Name, err.Error())
 	}
-
+	RecordTaskEvent(taskId, constants.TaskEventRepaired, "repair released %d instances not saved to db, marked %d instances deleted",
+		len(onlyCouldIds), len(onlyMemoryIds))
 	return nil
 }
 
@@ -121,50 +141,154 @@ func cloudDiff(cloudIds, memoryIds []string) (onlyCouldIds, onlyMemoryIds []stri
 }
 
 func Expand(clusterInfo *types.ClusterInfo, tags []cloud.Tag, num int) (instanceIds []string, err error) {
-	batch := getBatch(num, constants.BatchMax)
-	createdBatch := make(chan []string, batch)
-	createdError := make(chan error, batch)
-	cur := num
+	instances, err := expand(context.Background(), clusterInfo, tags, num, 0, 0)
+	return expandedInstanceIds(instances), err
+}
+
+// expand 按权重把实例分配到集群的各个可用区, 库存或配额不足时该可用区换用下一个候选规格,
+// 候选规格都不可用的可用区不再使用, 剩余数量重新分配到其他可用区.
+// offset 为本次任务已经创建的实例数量, 用于计算用户数据中的实例序号. ctx 被取消后不再创建下一轮实例
+func expand(ctx context.Context, clusterInfo *types.ClusterInfo, tags []cloud.Tag, num, offset int, taskId int64) (instances []ExpandedInstance, err error) {
 	provider, err := getProvider(clusterInfo.Provider, clusterInfo.AccountKey, clusterInfo.RegionId)
 	if err != nil {
 		return
 	}
 	params, err := generateParams(clusterInfo, tags)
-	for ; cur > 0; cur -= constants.BatchMax {
-		go func(cur int) {
-			var bErr error
+	if err != nil {
+		return
+	}
+	zones := clusterZones(clusterInfo)
+	counts := zoneInstanceCount(clusterInfo)
+	candidates := zoneInstanceTypes(clusterInfo, zones)
+	//typeIndex 各可用区当前使用的候选规格下标
+	typeIndex := make(map[string]int)
+	for len(instances) < num && ctx.Err() == nil {
+		available := make([]types.ZoneConfig, 0, len(zones))
+		instanceTypes := make([]string, 0, len(zones))
+		for _, zone := range zones {
+			if i := typeIndex[zone.ZoneId]; i < len(candidates[zone.ZoneId]) {
+				available = append(available, zone)
+				instanceTypes = append(instanceTypes, candidates[zone.ZoneId][i])
+			}
+		}
+		if len(available) == 0 {
+			break
+		}
+		plan := planZones(available, counts, num-len(instances))
+		results, bErr := expandInZones(provider, clusterInfo, tags, params, available, instanceTypes, plan, offset+len(instances), taskId)
+		if bErr != nil {
+			return instances, bErr
+		}
+		fallback := make(map[string]bool)
+		for _, res := range results {
+			for _, id := range res.ids {
+				instances = append(instances, ExpandedInstance{InstanceId: id, ZoneId: res.zoneId, InstanceType: res.instanceType})
+			}
+			counts[res.zoneId] += len(res.ids)
+			if res.err == nil {
+				continue
+			}
+			err = res.err
+			if (cloud.IsInsufficientStock(res.err) || cloud.IsQuotaExceeded(res.err)) && !fallback[res.zoneId] {
+				logs.Logger.Warnf("[cloud.Expand] %s in zone %s is unavailable, cluster: %s, error: %v", res.instanceType, res.zoneId, clusterInfo.Name, res.err)
+				typeIndex[res.zoneId]++
+				fallback[res.zoneId] = true
+			}
+		}
+		//没有规格库存或配额不足时, 由 ExpandAndRepair 负责重试
+		if len(fallback) == 0 {
+			break
+		}
+	}
+	if len(instances) == num {
+		err = nil
+	}
+	return
+}
+
+type zoneBatchResult struct {
+	zoneId       string
+	instanceType string
+	ids          []string
+	err          error
+}
+
+// expandInZones 按分配计划在各可用区并发创建实例, instanceTypes 为各可用区本轮使用的规格
+func expandInZones(provider cloud.Provider, clusterInfo *types.ClusterInfo, tags []cloud.Tag, params cloud.Params,
+	zones []types.ZoneConfig, instanceTypes []string, plan []int, offset int, taskId int64) ([]zoneBatchResult, error) {
+	batchMax := constants.BatchMax
+	// 用户数据引用了实例序号时每台实例的用户数据不同, 只能逐台创建
+	if userDataPerInstance(clusterInfo) {
+		batchMax = 1
+	}
+	type zoneBatch struct {
+		zoneId string
+		params cloud.Params
+		num    int
+	}
+	batches := make([]zoneBatch, 0)
+	index := offset
+	for i, zone := range zones {
+		for cur := plan[i]; cur > 0; cur -= batchMax {
+			p := params
+			network := *params.Network
+			network.SubnetId = zone.SubnetId
+			p.Network = &network
+			p.Zone = zone.ZoneId
+			p.InstanceType = instanceTypes[i]
+			userData, err := buildUserData(clusterInfo, tags, index)
+			if err != nil {
+				return nil, err
+			}
+			p.UserData = userData
+			n := cur
+			if n > batchMax {
+				n = batchMax
+			}
+			batches = append(batches, zoneBatch{zoneId: zone.ZoneId, params: p, num: n})
+			index += n
+		}
+	}
+	created := make(chan zoneBatchResult, len(batches))
+	for i, b := range batches {
+		RecordTaskEvent(taskId, constants.TaskEventBatchSubmitted, "batch %d submitted: zone %s, instance type %s, count %d",
+			i+1, b.zoneId, b.params.InstanceType, b.num)
+		go func(no int, b zoneBatch) {
+			res := zoneBatchResult{zoneId: b.zoneId, instanceType: b.params.InstanceType}
 			defer func() {
 				if bErr := recover(); bErr != nil {
 					logs.Logger.Errorf("[cloud.Expand] recover error. error: %v", bErr)
 					logs.Logger.Errorf("stacktrace from panic: \n" + string(debug.Stack()))
+					res.err = fmt.Errorf("expand panic: %v", bErr)
 				}
+				created <- res
 			}()
-			batchInstanceIds := make([]string, 0)
-			if cur < constants.BatchMax {
-				batchInstanceIds, bErr = provider.BatchCreate(params, cur)
+			res.ids, res.err = batchCreate(provider, clusterInfo, b.params, b.num)
+			if res.err != nil {
+				logs.Logger.Errorf("[cloud.Expand] BatchCreate error. zone: %s, error: %s", b.zoneId, res.err.Error())
+				RecordTaskEvent(taskId, constants.TaskEventBatchReturned, "batch %d returned %d instance ids, error: %v", no, len(res.ids), res.err)
 			} else {
-				batchInstanceIds, bErr = provider.BatchCreate(params, constants.BatchMax)
-			}
-			if bErr != nil {
-				logs.Logger.Errorf("[cloud.Expand] BatchCreate error. error: %s", bErr.Error())
-				createdError <- bErr
-				return
+				RecordTaskEvent(taskId, constants.TaskEventBatchReturned, "batch %d returned %d instance ids", no, len(res.ids))
 			}
-			createdBatch <- batchInstanceIds
-		}(cur)
-	}
-	errs := make([]error, 0)
-	for i := 0; i < batch; i++ {
-		select {
-		case instanceIdSet := <-createdBatch:
-			instanceIds = append(instanceIds, instanceIdSet...)
-		case cErr := <-createdError:
-			// handle errs?
-			errs = append(errs, cErr)
-			err = cErr
-		}
+			AddTaskProgress(taskId, constants.TaskProgressCreated, len(res.ids))
+		}(i+1, b)
 	}
-	return
+	results := make([]zoneBatchResult, 0, len(batches))
+	for i := 0; i < len(batches); i++ {
+		results = append(results, <-created)
+	}
+	return results, nil
+}
+
+// batchCreate 抢占式实例创建失败时(库存不足/出价过低), 按集群策略改为创建按量实例
+func batchCreate(provider cloud.Provider, clusterInfo *types.ClusterInfo, params cloud.Params, num int) ([]string, error) {
+	ids, err := provider.BatchCreate(params, num)
+	if err == nil || len(ids) > 0 || params.Spot == nil || clusterInfo.SpotConfig.Strategy != constants.SpotWithFallback {
+		return ids, err
+	}
+	logs.Logger.Warnf("[cloud.Expand] create spot instances failed, fallback to on-demand. cluster: %s, error: %v", clusterInfo.Name, err)
+	params.Spot = nil
+	return provider.BatchCreate(params, num)
 }
 
 func GetInstanceByTag(c *types.ClusterInfo, tags []cloud.Tag) (instances []cloud.Instance, err error) {
@@ -186,46 +310,78 @@ func generateParams(clusterInfo *types.ClusterInfo, tags []cloud.Tag) (params cl
 	}
 	params.InstanceType = clusterInfo.InstanceType
 	params.Password = clusterInfo.Password
+	params.KeyPairName = clusterInfo.KeyPairName
 	params.Provider = clusterInfo.Provider
 	params.Region = clusterInfo.RegionId
 	params.Zone = clusterInfo.ZoneId
 	params.Disks = clusterInfo.StorageConfig.Disks
 	params.Tags = tags
+	if clusterInfo.ChargeType == cloud.InstanceChargeTypePrePaid && clusterInfo.ChargeConfig != nil {
+		params.ChargeType = cloud.InstanceChargeTypePrePaid
+		params.Period = clusterInfo.ChargeConfig.Period
+		params.PeriodUnit = clusterInfo.ChargeConfig.PeriodUnit
+		params.AutoRenew = clusterInfo.ChargeConfig.AutoRenew
+	} else if clusterInfo.SpotConfig != nil {
+		params.Spot = &cloud.SpotOption{PriceLimit: clusterInfo.SpotConfig.PriceLimit}
+	}
 	return
 }
 
-func getBatch(num, eachMax int) int {
-	if num%eachMax == 0 {
-		return num / eachMax
-	}
-	return num/eachMax + 1
+type clientKey struct {
+	provider string
+	ak       string
+	region   string
 }
 
+// getProvider 按 provider+ak+region 缓存客户端, 构造方式由各云厂商注册的 driver 决定
 func getProvider(provider, ak, regionId string) (cloud.Provider, error) {
-	switch provider {
-	case alibaba.CloudName:
-		return getAlibabaCloudClient(ak, regionId)
-	default:
-		return nil, errors.New("unavailable provider")
-	}
-}
-
-func getAlibabaCloudClient(ak, region string) (cloud.Provider, error) {
-	key := ak + region
-	v, exist := clientMap.Load(key)
-	if exist {
-		cast, ok := v.(*alibaba.AlibabaCloud)
-		if ok {
-			return cast, nil
+	key := clientKey{provider: provider, ak: ak, region: regionId}
+	if v, exist := clientMap.Load(key); exist {
+		if client, ok := v.(cloud.Provider); ok {
+			return client, nil
 		}
 	}
+	driver, err := cloud.GetProviderDriver(provider)
+	if err != nil {
+		return nil, err
+	}
 	sk := model.GetAccountSecretByAccountKey(ak)
 	if sk == "" {
 		return nil, errors.New("no sk found")
 	}
-	client, err := alibaba.New(ak, sk, region)
+	client, err := driver(ak, sk, regionId)
+	if err != nil {
+		return nil, err
+	}
 	clientMap.Store(key, client)
-	return client, err
+	return client, nil
+}
+
+type ProviderInfo struct {
+	Name          string
+	DefaultRegion string
+}
+
+func ListProviders() []ProviderInfo {
+	names := cloud.ProviderNames()
+	providers := make([]ProviderInfo, 0, len(names))
+	for _, name := range names {
+		providers = append(providers, ProviderInfo{
+			Name:          name,
+			DefaultRegion: getDefaultRegion(name),
+		})
+	}
+	return providers
+}
+
+// evictProviders 账号变更后清理该 ak 下缓存的客户端
+func evictProviders(ak string) {
+	clientMap.Range(func(k, v interface{}) bool {
+		if key, ok := k.(clientKey); ok && key.ak == ak {
+			clientMap.Delete(k)
+		}
+		return true
+	})
 }
 
 func Shrink(clusterInfo *types.ClusterInfo, instanceIds []string) error {
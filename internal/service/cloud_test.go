@@ -0,0 +1,50 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud/fake"
+)
+
+func TestGetProviderCached(t *testing.T) {
+	key := clientKey{provider: fake.CloudName, ak: "ak-cached", region: fake.DefaultRegion}
+	client := fake.New("ak-cached", "sk", fake.DefaultRegion)
+	clientMap.Store(key, client)
+	defer clientMap.Delete(key)
+	//命中缓存时不查询账号
+	got, err := getProvider(fake.CloudName, "ak-cached", fake.DefaultRegion)
+	if err != nil || got != client {
+		t.Errorf("got %v %v, want cached client", got, err)
+	}
+}
+
+func TestGetProviderUnknown(t *testing.T) {
+	//未注册的云厂商在查询账号之前返回错误
+	_, err := getProvider("NoSuchCloud", "ak-unknown", "region")
+	if err == nil || !strings.Contains(err.Error(), "unavailable provider") {
+		t.Errorf("want unavailable provider error, got %v", err)
+	}
+}
+
+func TestEvictProviders(t *testing.T) {
+	keys := []clientKey{
+		{provider: fake.CloudName, ak: "ak-evict", region: "r1"},
+		{provider: fake.CloudName, ak: "ak-evict", region: "r2"},
+		{provider: "Other", ak: "ak-evict", region: "r1"},
+		{provider: fake.CloudName, ak: "ak-keep", region: "r1"},
+	}
+	for _, key := range keys {
+		clientMap.Store(key, fake.New(key.ak, "sk", key.region))
+	}
+	defer clientMap.Delete(keys[3])
+	evictProviders("ak-evict")
+	for _, key := range keys[:3] {
+		if _, ok := clientMap.Load(key); ok {
+			t.Errorf("%+v not evicted", key)
+		}
+	}
+	if _, ok := clientMap.Load(keys[3]); !ok {
+		t.Errorf("%+v of another ak evicted", keys[3])
+	}
+}
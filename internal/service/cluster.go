@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -44,7 +45,13 @@ func EditCluster(cluster *model.Cluster, username string) error {
 	}
 	now := time.Now()
 	cluster.Id = clusterInDB.Id
+	//查询集群时不返回密码, 编辑时未填写密码则沿用原密码
+	if cluster.KeyPairName == "" && cluster.Password == "" {
+		cluster.Password = clusterInDB.Password
+	}
 	cluster.Status = clusterInDB.Status
+	cluster.ExpectCount = clusterInDB.ExpectCount
+	cluster.Declarative = clusterInDB.Declarative
 	cluster.CreateAt = clusterInDB.CreateAt
 	cluster.CreateBy = clusterInDB.CreateBy
 	cluster.UpdateAt = &now
@@ -52,6 +59,14 @@ func EditCluster(cluster *model.Cluster, username string) error {
 	return model.Save(cluster)
 }
 
+//SetExpectCount 设置集群期望实例数量, declarative 为 true 时调度器持续扩缩容到期望数量
+func SetExpectCount(ctx context.Context, clusterName string, expectCount int, declarative bool) error {
+	if _, err := model.GetByClusterName(clusterName); err != nil {
+		return err
+	}
+	return model.UpdateExpectCount(ctx, clusterName, expectCount, declarative)
+}
+
 func DeleteClusters(ctx context.Context, ids []int64, orgId int64) error {
 	clusters := make([]model.Cluster, 0)
 	if len(ids) == 0 {
@@ -186,6 +201,48 @@ func ConvertToClusterInfo(m *model.Cluster, tags []model.ClusterTag) (*types.Clu
 	if err != nil {
 		return nil, err
 	}
+	var chargeConfig *types.ChargeConfig
+	if m.ChargeConfig != "" {
+		chargeConfig = &types.ChargeConfig{}
+		if err = jsoniter.UnmarshalFromString(m.ChargeConfig, chargeConfig); err != nil {
+			return nil, err
+		}
+	}
+	var spotConfig *types.SpotConfig
+	if m.SpotConfig != "" {
+		spotConfig = &types.SpotConfig{}
+		if err = jsoniter.UnmarshalFromString(m.SpotConfig, spotConfig); err != nil {
+			return nil, err
+		}
+	}
+	var instanceTypeConfig *types.InstanceTypeConfig
+	if m.InstanceTypeConfig != "" {
+		instanceTypeConfig = &types.InstanceTypeConfig{}
+		if err = jsoniter.UnmarshalFromString(m.InstanceTypeConfig, instanceTypeConfig); err != nil {
+			return nil, err
+		}
+	}
+	var terminationConfig *types.TerminationConfig
+	if m.TerminationConfig != "" {
+		terminationConfig = &types.TerminationConfig{}
+		if err = jsoniter.UnmarshalFromString(m.TerminationConfig, terminationConfig); err != nil {
+			return nil, err
+		}
+	}
+	var lifecycleConfig *types.LifecycleConfig
+	if m.LifecycleConfig != "" {
+		lifecycleConfig = &types.LifecycleConfig{}
+		if err = jsoniter.UnmarshalFromString(m.LifecycleConfig, lifecycleConfig); err != nil {
+			return nil, err
+		}
+	}
+	var healthCheckConfig *types.HealthCheckConfig
+	if m.HealthCheckConfig != "" {
+		healthCheckConfig = &types.HealthCheckConfig{}
+		if err = jsoniter.UnmarshalFromString(m.HealthCheckConfig, healthCheckConfig); err != nil {
+			return nil, err
+		}
+	}
 	var mt = make(map[string]string, 0)
 	for _, clusterTag := range tags {
 		mt[clusterTag.TagKey] = clusterTag.TagValue
@@ -202,97 +259,267 @@ func ConvertToClusterInfo(m *model.Cluster, tags []model.ClusterTag) (*types.Clu
 		Provider:      m.Provider,
 		Username:      constants.DefaultUsername,
 		Password:      m.Password,
+		KeyPairName:   m.KeyPairName,
 		NetworkConfig: networkConfig,
 		StorageConfig: storageConfig,
+		ChargeConfig:  chargeConfig,
+		SpotConfig:    spotConfig,
+		UserData:      m.UserData,
 		AccountKey:    m.AccountKey,
+		ExpectCount:   m.ExpectCount,
+		Declarative:   m.Declarative,
 		Tags:          mt,
+
+		InstanceTypeConfig: instanceTypeConfig,
+		TerminationConfig:  terminationConfig,
+		LifecycleConfig:    lifecycleConfig,
+		HealthCheckConfig:  healthCheckConfig,
 	}
 	return clusterInfo, nil
 }
 
-func ExpandCluster(c *types.ClusterInfo, num int, taskId int64) (instanceIds []cloud.Instance, err error) {
+func ExpandCluster(ctx context.Context, c *types.ClusterInfo, num int, taskId int64) (instanceIds []cloud.Instance, err error) {
 	//调用云厂商接口进行扩容
-	expandInstanceIds, err := ExpandAndRepair(c, num, taskId)
-	if len(expandInstanceIds) == 0 && err != nil {
+	expanded, err := ExpandAndRepair(ctx, c, num, taskId)
+	expandInstanceIds := expandedInstanceIds(expanded)
+	if ctx.Err() != nil {
+		releaseCancelledExpand(c, taskId, expandInstanceIds)
+		return nil, ctx.Err()
+	}
+	if len(expanded) == 0 && err != nil {
 		return nil, err
 	}
 
 	//将扩容的Instance信息保存到DB
-	err = saveExpandInstancesToDB(c, expandInstanceIds, taskId)
+	err = saveExpandInstancesToDB(c, expanded, taskId)
 	if err != nil {
 		logs.Logger.Errorf("[ExpandCluster] Expand error. cluster name: %s, error: %v", c.Name, err)
 		return nil, err
 	}
+	RecordTaskEvent(taskId, constants.TaskEventDBSaved, "%d instances saved to db", len(expanded))
 
 	//查询扩容的Instance的IP并保存
-	expandIPs, expandInstances, err := queryAndSaveExpandIPs(c, err, expandInstanceIds)
+	RecordTaskEvent(taskId, constants.TaskEventWaitingIP, "waiting for IPs of %d instances", len(expandInstanceIds))
+	expandIPs, expandInstances, err := queryAndSaveExpandIPs(ctx, c, err, expandInstanceIds)
+	//配置了 post_launch 钩子时等待实例就绪, 超时放弃的实例已被释放, 不再发布
+	var hookErr error
+	if ctx.Err() == nil {
+		var abandonedIds []string
+		expandInstances, abandonedIds, hookErr = waitInstancesReady(ctx, c, taskId, expandInstances)
+		if len(abandonedIds) > 0 {
+			abandoned := make(map[string]bool, len(abandonedIds))
+			for _, id := range abandonedIds {
+				abandoned[id] = true
+			}
+			expandInstanceIds = excludeInstanceIds(expandInstanceIds, abandoned)
+			expandIPs = instancesIpInner(expandInstances)
+		}
+	}
+	if ctx.Err() != nil {
+		releaseCancelledExpand(c, taskId, expandInstanceIds)
+		return nil, ctx.Err()
+	}
+	AddTaskProgress(taskId, constants.TaskProgressRunning, len(expandIPs))
 	if err != nil {
 		logs.Logger.Errorf("[ExpandCluster] queryAndSaveExpandIPs error. cluster name: %s, error: %v", c.Name, err)
 		return expandInstances, err
 	}
+	if hookErr != nil && !errors.Is(hookErr, ErrLifecycleAbandoned) {
+		logs.Logger.Errorf("[ExpandCluster] waitInstancesReady error. cluster name: %s, error: %v", c.Name, hookErr)
+		return expandInstances, hookErr
+	}
 	//发布扩容信息到配置中心
-	_ = publishExpandConfig(c.Name, expandInstanceIds, expandIPs)
-	return expandInstances, nil
+	if err = publishExpandConfig(c.Name, expandInstanceIds, expandIPs); err == nil && config.GlobalConfig.NeedPublishConfig {
+		RecordTaskEvent(taskId, constants.TaskEventConfigPublished, "published %d instances and %d IPs", len(expandInstanceIds), len(expandIPs))
+	}
+	return expandInstances, hookErr
+}
+
+func instancesIpInner(instances []cloud.Instance) []string {
+	ips := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if instance.IpInner != "" {
+			ips = append(ips, instance.IpInner)
+		}
+	}
+	return ips
+}
+
+//releaseCancelledExpand 扩容任务被取消时释放已创建的实例并标记为已删除,
+//repairTaskInstances 再按任务标签释放还没有记录到 DB 的实例, 遗漏的实例由 InstanceCleaner 清理
+func releaseCancelledExpand(c *types.ClusterInfo, taskId int64, instanceIds []string) {
+	logs.Logger.Infof("[ExpandCluster] task %d cancelled, release instances: %v", taskId, instanceIds)
+	if len(instanceIds) > 0 {
+		if err := Shrink(c, instanceIds); err != nil {
+			logs.Logger.Errorf("[ExpandCluster] release cancelled instances error. cluster name: %s, error: %v", c.Name, err)
+		}
+		now := time.Now()
+		err := model.BatchUpdateByInstanceIds(instanceIds, model.Instance{
+			Status:   constants.Deleted,
+			DeleteAt: &now,
+		})
+		if err != nil {
+			logs.Logger.Errorf("[ExpandCluster] update cancelled instances error. cluster name: %s, error: %v", c.Name, err)
+		}
+		RecordTaskEvent(taskId, constants.TaskEventReleased, "task cancelled, released %d created instances", len(instanceIds))
+	}
+	_ = repairTaskInstances(c, taskId, instanceIds)
+}
+
+//repairTaskInstances 按任务标签释放 instanceIds 之外还没有记录到 DB 的实例. 滚动更新任务每一批都使用同一个任务 ID,
+//DB 中记录的同一任务的活跃实例是之前批次创建的新实例, 已经在提供服务, 不能被释放
+func repairTaskInstances(c *types.ClusterInfo, taskId int64, instanceIds []string) error {
+	active, err := model.GetActiveInstancesByTaskId(context.Background(), taskId)
+	if err != nil {
+		logs.Logger.Errorf("[repairTaskInstances] get task instances error. task id: %d, error: %v", taskId, err)
+		return err
+	}
+	return RepairCluster(c, taskId, repairKeepIds(instanceIds, active))
+}
+
+//repairKeepIds RepairCluster 不释放的实例: instanceIds 和 DB 中记录的同一任务的活跃实例
+func repairKeepIds(instanceIds []string, active []model.Instance) []string {
+	keep := append([]string{}, instanceIds...)
+	for _, instance := range active {
+		keep = append(keep, instance.InstanceId)
+	}
+	return keep
 }
 
-func ShrinkClusterBySpecificIps(c *types.ClusterInfo, deletingIPs string, count int, taskId int64) (err error) {
-	toBeDeletedIds, notExistIds := getMappingInstanceIdList(c.Name, deletingIPs)
+//ShrinkClusterBySpecificIps 释放指定 IP 的实例, 返回的结果中包含待释放的实例
+func ShrinkClusterBySpecificIps(ctx context.Context, c *types.ClusterInfo, deletingIPs string, count int, taskId int64) (res model.ShrinkTaskRes, err error) {
+	instances, notExistIds := getMappingInstanceList(c.Name, deletingIPs)
+	toBeDeletedIds := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		toBeDeletedIds = append(toBeDeletedIds, instance.InstanceId)
+	}
 	if len(toBeDeletedIds) == 0 {
 		logs.Logger.Warnf("%v has no deletingIPs %v", c.Name, deletingIPs)
-		return nil
+		return res, nil
 	}
 	if len(toBeDeletedIds)+len(notExistIds) != count {
 		logs.Logger.Warnf("%v toBeDeleted:%v + alreadyDeleted:%v not match expect_shrink_count:%v", c.Name, toBeDeletedIds, notExistIds, count)
-		return errors.New("need delete instance count NOT MATCH expect delete count")
+		return res, errors.New("need delete instance count NOT MATCH expect delete count")
 	}
+	res.InstanceIdList = toBeDeletedIds
 	logs.Logger.Infof("cluster:%v, DELETING ip list:%v, instances list:%v", c.Name, deletingIPs, toBeDeletedIds)
-	err = Shrink(c, toBeDeletedIds)
+	err = checkProtectedInstances(instances)
 	if err != nil {
-		logs.Logger.Errorf("[ShrinkCluster] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
+		logs.Logger.Errorf("[ShrinkClusterBySpecificIps] cluster name: %s, error: %s", c.Name, err.Error())
 		return
 	}
-	now := time.Now()
-	err = model.BatchUpdateByInstanceIds(toBeDeletedIds, model.Instance{
-		ShrinkTaskId: taskId,
-		Status:       constants.Deleted,
-		DeleteAt:     &now,
-	})
+	err = checkPrePaidInstances(c, toBeDeletedIds)
 	if err != nil {
-		logs.Logger.Errorf("[ShrinkClusterBySpecificIps] update db error. cluster name: %s, error: %s", c.Name, err.Error())
+		logs.Logger.Errorf("[ShrinkClusterBySpecificIps] cluster name: %s, error: %s", c.Name, err.Error())
 		return
 	}
-	_ = publishShrinkConfig(c.Name)
-	return err
+	err = shrinkInBatches(ctx, c, toBeDeletedIds, taskId)
+	if err != nil {
+		logs.Logger.Errorf("[ShrinkClusterBySpecificIps] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
+	}
+	return res, err
 }
 
-func ShrinkCluster(c *types.ClusterInfo, num int, taskId int64) (err error) {
+//ShrinkCluster 按集群的终止策略挑选并释放 num 台实例, 返回的结果中包含使用的策略和待释放的实例.
+//可释放的实例不足 num 台时释放全部可释放的实例并返回 ErrProtectedShortfall
+func ShrinkCluster(ctx context.Context, c *types.ClusterInfo, num int, taskId int64) (res model.ShrinkTaskRes, err error) {
 	logs.Logger.Infof("Shrink %v, with count:%v", c.Name, num)
-	instances, err := model.GetActiveInstancesWithCount(c.Name, num)
+	activeInstances, err := model.GetActiveInstancesByClusterName(c.Name)
 	if err != nil {
 		logs.Logger.Errorf("[ShrinkCluster] Get instanceIdStr error. cluster name: %s, error: %s", c.Name, err.Error())
-		return err
+		return res, err
 	}
+	//开启了缩容保护的实例不参与挑选
+	instances, policies := selectShrinkVictims(c, unprotectedInstances(activeInstances), num)
 	toBeDeletedInstanceIds := make([]string, 0)
 	for _, instance := range instances {
 		toBeDeletedInstanceIds = append(toBeDeletedInstanceIds, instance.InstanceId)
 	}
-	err = Shrink(c, toBeDeletedInstanceIds)
+	res = model.ShrinkTaskRes{TerminationPolicies: policies, InstanceIdList: toBeDeletedInstanceIds}
+	err = checkPrePaidInstances(c, toBeDeletedInstanceIds)
 	if err != nil {
-		logs.Logger.Errorf("[ShrinkCluster] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
+		logs.Logger.Errorf("[ShrinkCluster] cluster name: %s, error: %s", c.Name, err.Error())
 		return
 	}
-	now := time.Now()
-	err = model.BatchUpdateByInstanceIds(toBeDeletedInstanceIds, model.Instance{
-		ShrinkTaskId: taskId,
-		Status:       constants.Deleted,
-		DeleteAt:     &now,
-	})
+	err = shrinkInBatches(ctx, c, toBeDeletedInstanceIds, taskId)
 	if err != nil {
-		logs.Logger.Errorf("[ShrinkCluster] Shrink instance update db error. cluster name: %s, error: %s", c.Name, err.Error())
-		return
+		logs.Logger.Errorf("[ShrinkCluster] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
+		return res, err
 	}
-	_ = publishShrinkConfig(c.Name)
-	return err
+	return res, checkShrinkShortfall(activeInstances, len(instances), num)
+}
+
+//shrinkInBatches 分批释放实例并标记为已删除, ctx 被取消后不再释放下一批
+func shrinkInBatches(ctx context.Context, c *types.ClusterInfo, instanceIds []string, taskId int64) error {
+	deleted := 0
+	defer func() {
+		if deleted == 0 {
+			return
+		}
+		if err := publishShrinkConfig(c.Name); err == nil && config.GlobalConfig.NeedPublishConfig {
+			RecordTaskEvent(taskId, constants.TaskEventConfigPublished, "published rest instances after releasing %d instances", deleted)
+		}
+	}()
+	for start := 0; start < len(instanceIds); start += constants.BatchMax {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		end := start + constants.BatchMax
+		if end > len(instanceIds) {
+			end = len(instanceIds)
+		}
+		batch := instanceIds[start:end]
+		//先摘除流量再释放, 释放失败时恢复为摘除前的状态
+		drained, err := drainInstances(ctx, c, batch, taskId)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			restoreDrainingInstances(c, drained)
+			return ctx.Err()
+		}
+		if err = Shrink(c, batch); err != nil {
+			restoreDrainingInstances(c, drained)
+			return err
+		}
+		now := time.Now()
+		err = model.BatchUpdateByInstanceIds(batch, model.Instance{
+			ShrinkTaskId: taskId,
+			Status:       constants.Deleted,
+			DeleteAt:     &now,
+		})
+		if err != nil {
+			return err
+		}
+		deleted += len(batch)
+		RecordTaskEvent(taskId, constants.TaskEventReleased, "batch %d released %d instances", start/constants.BatchMax+1, len(batch))
+		AddTaskProgress(taskId, constants.TaskProgressReleased, len(batch))
+	}
+	return nil
+}
+
+//ErrPrePaidInstances 包年包月实例不能像按量实例一样强制释放
+var ErrPrePaidInstances = errors.New("prepaid instances can not be released before expiration")
+
+//checkPrePaidInstances 缩容前检查待释放的实例中是否有包年包月实例, 有则拒绝整个缩容
+func checkPrePaidInstances(c *types.ClusterInfo, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	instances, err := GetInstances(c, instanceIds)
+	if err != nil {
+		return err
+	}
+	prePaidIds := make([]string, 0)
+	for _, instance := range instances {
+		if instance.CostWay == cloud.InstanceChargeTypePrePaid {
+			prePaidIds = append(prePaidIds, instance.Id)
+		}
+	}
+	if len(prePaidIds) > 0 {
+		return fmt.Errorf("%w: %v", ErrPrePaidInstances, prePaidIds)
+	}
+	return nil
 }
 
 //CleanClusterUnusedInstances 清除由于系统异常导致的云厂商中残留的机器
@@ -306,6 +533,14 @@ func CleanClusterUnusedInstances(clusterInfo *types.ClusterInfo) (int, error) {
 		return 0, err
 	}
 	instanceIds := calcUnusedInstancesId(instanceInCloud, instancesInBridgx)
+	if len(instanceIds) > 0 {
+		//带有保护标签的实例即使不在 BridgX 中也不释放
+		protectedIds, err := cloudProtectedInstanceIds(clusterInfo)
+		if err != nil {
+			return 0, err
+		}
+		instanceIds = excludeInstanceIds(instanceIds, protectedIds)
+	}
 	if len(instanceIds) > 0 {
 		err := Shrink(clusterInfo, instanceIds)
 		if err != nil {
@@ -315,6 +550,78 @@ func CleanClusterUnusedInstances(clusterInfo *types.ClusterInfo) (int, error) {
 	return len(instanceIds), nil
 }
 
+//RemoveVanishedInstances 云厂商中已不存在的运行中实例标记为已删除并返回实例ID,
+//包括被回收的抢占式实例和在 BridgX 之外被释放的实例, 云厂商查询不到已释放实例的计费方式, 无法区分
+func RemoveVanishedInstances(clusterInfo *types.ClusterInfo) ([]string, error) {
+	instanceIds, err := vanishedInstances(clusterInfo)
+	if err != nil || len(instanceIds) == 0 {
+		return nil, err
+	}
+	logs.Logger.Infof("[RemoveVanishedInstances] cluster: %s, vanished instances: %v", clusterInfo.Name, instanceIds)
+	now := time.Now()
+	err = model.BatchUpdateByInstanceIds(instanceIds, model.Instance{
+		Status:   constants.Deleted,
+		DeleteAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = publishShrinkConfig(clusterInfo.Name)
+	return instanceIds, nil
+}
+
+//BackfillVanishedInstances 云厂商中已不存在的运行中实例标记为已删除, 并在同一个事务中创建扩容任务补齐数量,
+//避免实例已标记删除但任务创建失败导致数量不再补齐. 返回扩容任务ID, 没有需要补齐的实例时返回 0
+func BackfillVanishedInstances(ctx context.Context, clusterInfo *types.ClusterInfo, taskName string) (int64, error) {
+	instanceIds, err := vanishedInstances(clusterInfo)
+	if err != nil || len(instanceIds) == 0 {
+		return 0, err
+	}
+	logs.Logger.Infof("[BackfillVanishedInstances] cluster: %s, vanished instances: %v", clusterInfo.Name, instanceIds)
+	task := newTask(constants.TaskActionExpand, clusterInfo.Name, newExpandTaskInfo(clusterInfo.Name, len(instanceIds), 0), taskName, 0)
+	ok, err := model.DeleteInstancesWithTask(ctx, instanceIds, task)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		//实例状态在检查期间发生变化, 下一轮重新检查
+		return 0, nil
+	}
+	RecordTaskEvent(task.Id, constants.TaskEventQueued, "task queued to backfill %d vanished instances %v", len(instanceIds), instanceIds)
+	_ = publishShrinkConfig(clusterInfo.Name)
+	return task.Id, nil
+}
+
+func vanishedInstances(clusterInfo *types.ClusterInfo) ([]string, error) {
+	instancesInBridgx, err := model.GetActiveInstancesByClusterName(clusterInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+	instanceInCloud, err := GetCloudInstancesByClusterName(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	return calcVanishedInstancesId(instanceInCloud, instancesInBridgx), nil
+}
+
+//calcVanishedInstancesId 只检查已经Running的实例, 刚创建的实例可能还查询不到
+func calcVanishedInstancesId(cloudInstances []cloud.Instance, bridgeXInstances []model.Instance) []string {
+	var vanishedInstanceIds []string
+	cloudInstanceExists := make(map[string]struct{})
+	for _, cloudInstance := range cloudInstances {
+		cloudInstanceExists[cloudInstance.Id] = struct{}{}
+	}
+	for _, bridgxInstance := range bridgeXInstances {
+		if bridgxInstance.Status != constants.Running {
+			continue
+		}
+		if _, exists := cloudInstanceExists[bridgxInstance.InstanceId]; !exists {
+			vanishedInstanceIds = append(vanishedInstanceIds, bridgxInstance.InstanceId)
+		}
+	}
+	return vanishedInstanceIds
+}
+
 func calcUnusedInstancesId(cloudInstances []cloud.Instance, bridgeXInstances []model.Instance) []string {
 	var unusedInstanceIds []string
 	bridgxInstanceExists := make(map[string]struct{})
@@ -323,6 +630,10 @@ func calcUnusedInstancesId(cloudInstances []cloud.Instance, bridgeXInstances []m
 	}
 
 	for _, cloudInstance := range cloudInstances {
+		//包年包月实例无法释放, 不参与清理
+		if cloudInstance.CostWay == cloud.InstanceChargeTypePrePaid {
+			continue
+		}
 		if _, exists := bridgxInstanceExists[cloudInstance.Id]; !exists {
 			unusedInstanceIds = append(unusedInstanceIds, cloudInstance.Id)
 		}
@@ -330,27 +641,35 @@ func calcUnusedInstancesId(cloudInstances []cloud.Instance, bridgeXInstances []m
 	return unusedInstanceIds
 }
 
-func getMappingInstanceIdList(clusterName, deletingIPs string) (toBeDeletedIds, notExistIds []string) {
+func getMappingInstanceList(clusterName, deletingIPs string) (instances []model.Instance, notExistIps []string) {
 	activeInstances, err := model.GetActiveInstancesByClusterName(clusterName)
 	if err != nil || len(activeInstances) == 0 {
 		return nil, nil
 	}
-	m := make(map[string]string, 0)
+	instances, notExistIps = instancesByIps(activeInstances, strings.Split(deletingIPs, ","))
+	logs.Logger.Infof("%v real delete working instances:%v", clusterName, len(instances))
+	return instances, notExistIps
+}
+
+//instancesByIps 按内网 IP 查找实例, 返回找到的实例和不存在的 IP
+func instancesByIps(activeInstances []model.Instance, ips []string) (instances []model.Instance, notExistIps []string) {
+	m := make(map[string]model.Instance, len(activeInstances))
 	for _, instance := range activeInstances {
-		m[instance.IpInner] = instance.InstanceId
+		m[instance.IpInner] = instance
 	}
-	for _, ip := range strings.Split(deletingIPs, ",") {
-		if insId, ok := m[ip]; ok {
-			toBeDeletedIds = append(toBeDeletedIds, insId)
+	instances = make([]model.Instance, 0, len(ips))
+	notExistIps = make([]string, 0)
+	for _, ip := range ips {
+		if instance, ok := m[ip]; ok {
+			instances = append(instances, instance)
 		} else {
-			notExistIds = append(notExistIds, insId)
+			notExistIps = append(notExistIps, ip)
 		}
 	}
-	logs.Logger.Infof("%v real delete working IPs:%v", clusterName, toBeDeletedIds)
 	return
 }
 
-func queryAndSaveExpandIPs(c *types.ClusterInfo, err error, expandInstanceIds []string) ([]string, []cloud.Instance, error) {
+func queryAndSaveExpandIPs(ctx context.Context, c *types.ClusterInfo, err error, expandInstanceIds []string) ([]string, []cloud.Instance, error) {
 	expandIps := make([]string, 0)
 	expandInstances := make([]cloud.Instance, 0)
 	// TODO scheduler
@@ -360,7 +679,11 @@ func queryAndSaveExpandIPs(c *types.ClusterInfo, err error, expandInstanceIds []
 		if err == nil && len(expandInstances) == len(expandInstanceIds) && judgeInstancesIsReady(expandInstances) {
 			break
 		}
-		time.Sleep(constants.Delay * time.Second)
+		select {
+		case <-ctx.Done():
+			return expandIps, expandInstances, ctx.Err()
+		case <-time.After(constants.Delay * time.Second):
+		}
 	}
 	if err != nil {
 		logs.Logger.Errorf("[ExpandCluster] GetInstances error. cluster name: %s, error: %s", c.Name, err.Error())
@@ -370,14 +693,20 @@ func queryAndSaveExpandIPs(c *types.ClusterInfo, err error, expandInstanceIds []
 			expandIps = append(expandIps, instance.IpInner)
 			update := func(attempt uint) error {
 				now := time.Now()
-				return model.UpdateByInstanceId(model.Instance{
+				update := model.Instance{
 					InstanceId:  instance.Id,
 					IpInner:     instance.IpInner,
 					IpOuter:     instance.IpOuter,
 					ClusterName: c.Name,
 					Status:      constants.Running,
 					RunningAt:   &now,
-				})
+				}
+				//等待 post_launch 钩子的实例就绪后再标记为 RUNNING
+				if status := launchStatus(c); status != constants.Running {
+					update.Status = status
+					update.RunningAt = nil
+				}
+				return model.UpdateByInstanceId(update)
 			}
 			err = retry.Retry(update, strategy.Limit(3), strategy.Backoff(backoff.Fibonacci(10*time.Millisecond)))
 		} else {
@@ -390,16 +719,18 @@ func queryAndSaveExpandIPs(c *types.ClusterInfo, err error, expandInstanceIds []
 	return expandIps, expandInstances, err
 }
 
-func saveExpandInstancesToDB(c *types.ClusterInfo, expandInstanceIds []string, taskId int64) error {
+func saveExpandInstancesToDB(c *types.ClusterInfo, expanded []ExpandedInstance, taskId int64) error {
 	instances := make([]model.Instance, 0)
 	now := time.Now()
-	for _, instanceId := range expandInstanceIds {
+	for _, expandInstance := range expanded {
 		instances = append(instances, model.Instance{
-			TaskId:      taskId,
-			InstanceId:  instanceId,
-			Status:      constants.Pending,
-			ClusterName: c.Name,
-			CreateAt:    &now,
+			TaskId:       taskId,
+			InstanceId:   expandInstance.InstanceId,
+			ZoneId:       expandInstance.ZoneId,
+			InstanceType: expandInstance.InstanceType,
+			Status:       constants.Pending,
+			ClusterName:  c.Name,
+			CreateAt:     &now,
 		})
 	}
 	return model.BatchCreateInstance(instances)
@@ -445,25 +776,33 @@ func publishExpandInstanceConfig(clusterName string, expandInstanceIds []string)
 	return bcc.PublishConfig(clusterName, constants.Instances, strings.Join(totalInstanceIds, ","))
 }
 
+//workingInstances 只发布 RUNNING 的实例: DELETING 的实例即将释放, STARTING/PENDING 的实例还没有就绪
+func workingInstances(instances []model.Instance) (instanceIds []string, ips []string) {
+	instanceIds, ips = make([]string, 0), make([]string, 0)
+	for _, instance := range instances {
+		if instance.Status != constants.Running {
+			continue
+		}
+		instanceIds = append(instanceIds, instance.InstanceId)
+		ips = append(ips, instance.IpInner)
+	}
+	return instanceIds, ips
+}
+
 func publishShrinkConfig(clusterName string) error {
 	if !config.GlobalConfig.NeedPublishConfig {
 		logs.Logger.Infof("shrink cluster:%v no need publish config", clusterName)
 		return nil
 	}
 	instances, err := model.GetActiveInstancesByClusterName(clusterName)
-	if err != nil || len(instances) == 0 {
+	if err != nil {
 		return err
 	}
-	restInstanceIds := make([]string, 0)
-	restInstanceIPs := make([]string, 0)
+	restInstanceIds, restInstanceIPs := workingInstances(instances)
 
 	restInstancesStr := constants.HasNoneInstance
 	restIps := constants.HasNoneIP
 
-	for _, instance := range instances {
-		restInstanceIds = append(restInstanceIds, instance.InstanceId)
-		restInstanceIPs = append(restInstanceIPs, instance.IpInner)
-	}
 	if len(restInstanceIds) > 0 {
 		restInstancesStr = strings.Join(restInstanceIds, ",")
 	}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
 )
@@ -26,3 +28,80 @@ func TestCalcUnusedInstancesId(t *testing.T) {
 		t.Errorf("failed in calc ununsed instance want [1] , got %v", unusedInstanceIds)
 	}
 }
+
+func TestCalcVanishedInstancesId(t *testing.T) {
+	cloudInstances := []cloud.Instance{
+		{
+			Id: "1",
+		},
+	}
+	bridgeXInstances := []model.Instance{
+		{
+			InstanceId: "1",
+			Status:     constants.Running,
+		},
+		{
+			InstanceId: "2",
+			Status:     constants.Running,
+		},
+		{
+			InstanceId: "3",
+			Status:     constants.Pending,
+		},
+	}
+	reclaimedInstanceIds := calcVanishedInstancesId(cloudInstances, bridgeXInstances)
+	if len(reclaimedInstanceIds) != 1 || reclaimedInstanceIds[0] != "2" {
+		t.Errorf("failed in calc reclaimed instance want [2] , got %v", reclaimedInstanceIds)
+	}
+}
+
+func TestCalcUnusedInstancesIdSkipPrePaid(t *testing.T) {
+	cloudInstances := []cloud.Instance{
+		{
+			Id:      "1",
+			CostWay: cloud.InstanceChargeTypePrePaid,
+		},
+		{
+			Id:      "2",
+			CostWay: cloud.InstanceChargeTypePostPaid,
+		},
+	}
+	unusedInstanceIds := calcUnusedInstancesId(cloudInstances, nil)
+	if len(unusedInstanceIds) != 1 || unusedInstanceIds[0] != "2" {
+		t.Errorf("failed in calc ununsed instance want [2] , got %v", unusedInstanceIds)
+	}
+}
+
+func TestRepairKeepIds(t *testing.T) {
+	//滚动更新之前批次记录到 DB 的实例和本批的实例都不由 RepairCluster 释放
+	active := []model.Instance{{InstanceId: "i-1"}, {InstanceId: "i-2"}}
+	keep := repairKeepIds([]string{"i-5"}, active)
+	if !reflect.DeepEqual(keep, []string{"i-5", "i-1", "i-2"}) {
+		t.Errorf("keep got %v", keep)
+	}
+	onlyCloud, _ := cloudDiff([]string{"i-1", "i-2", "i-3"}, keep)
+	if !reflect.DeepEqual(onlyCloud, []string{"i-3"}) {
+		t.Errorf("released got %v, want [i-3]", onlyCloud)
+	}
+}
+
+func TestWorkingInstances(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1", IpInner: "10.0.0.1", Status: constants.Running},
+		{InstanceId: "i-2", IpInner: "10.0.0.2", Status: constants.Starting},
+		{InstanceId: "i-3", IpInner: "", Status: constants.Pending},
+		{InstanceId: "i-4", IpInner: "10.0.0.4", Status: constants.Deleting},
+		{InstanceId: "i-5", IpInner: "10.0.0.5", Status: constants.Running},
+	}
+	ids, ips := workingInstances(instances)
+	if !reflect.DeepEqual(ids, []string{"i-1", "i-5"}) {
+		t.Errorf("instance ids got %v, want [i-1 i-5]", ids)
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.5"}) {
+		t.Errorf("ips got %v, want [10.0.0.1 10.0.0.5]", ips)
+	}
+	ids, ips = workingInstances([]model.Instance{{InstanceId: "i-2", Status: constants.Starting}})
+	if len(ids) != 0 || len(ips) != 0 {
+		t.Errorf("got %v %v, want empty", ids, ips)
+	}
+}
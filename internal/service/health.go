@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+// healthProbe 一台实例一次健康检查的结果
+type healthProbe struct {
+	healthy bool
+	message string
+}
+
+// healthCheckDefaults 填充未配置的默认值
+func healthCheckDefaults(cfg types.HealthCheckConfig) types.HealthCheckConfig {
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = constants.DefaultHealthCheckTimeout
+	}
+	if cfg.UnhealthyThreshold == 0 {
+		cfg.UnhealthyThreshold = constants.DefaultUnhealthyThreshold
+	}
+	if cfg.GracePeriodSeconds == 0 {
+		cfg.GracePeriodSeconds = constants.DefaultHealthCheckGracePeriod
+	}
+	if cfg.MaxHealPerHour == 0 {
+		cfg.MaxHealPerHour = constants.DefaultMaxHealPerHour
+	}
+	if cfg.MaxUnhealthyPercent == 0 {
+		cfg.MaxUnhealthyPercent = constants.DefaultMaxUnhealthyPercent
+	}
+	return cfg
+}
+
+// CheckClusterHealth 检查集群中运行超过宽限期的实例, 记录健康状态和失败历史, 开启 auto_heal 时替换不健康的实例
+func CheckClusterHealth(ctx context.Context, clusterName string) error {
+	c, err := clusterInfoByName(ctx, clusterName)
+	if err != nil || c.HealthCheckConfig == nil {
+		return err
+	}
+	cfg := healthCheckDefaults(*c.HealthCheckConfig)
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	targets := healthCheckTargets(instances, cfg, now)
+	probes, err := probeInstances(ctx, c, cfg, targets)
+	if err != nil {
+		return err
+	}
+	updated := make(map[string]model.Instance, len(targets))
+	for i, instance := range targets {
+		updated[instance.InstanceId] = recordInstanceHealth(ctx, instance, cfg, probes[i], now)
+	}
+	if !cfg.AutoHeal {
+		return nil
+	}
+	for i, instance := range instances {
+		if u, ok := updated[instance.InstanceId]; ok {
+			instances[i] = u
+		}
+	}
+	return healUnhealthyInstances(ctx, c, cfg, instances, now)
+}
+
+// healthCheckTargets 只检查运行中且过了宽限期的实例, TCP 和 HTTP 检查还需要有内网 IP
+func healthCheckTargets(instances []model.Instance, cfg types.HealthCheckConfig, now time.Time) []model.Instance {
+	targets := make([]model.Instance, 0, len(instances))
+	grace := time.Duration(cfg.GracePeriodSeconds) * time.Second
+	for _, instance := range instances {
+		if instance.Status != constants.Running || instance.RunningAt == nil || now.Sub(*instance.RunningAt) < grace {
+			continue
+		}
+		if cfg.Type != constants.HealthCheckCloud && instance.IpInner == "" {
+			continue
+		}
+		targets = append(targets, instance)
+	}
+	return targets
+}
+
+// probeInstances 并发检查实例, 返回结果与 instances 一一对应. 查询云厂商失败时不记录结果, 避免把所有实例误判为失败
+func probeInstances(ctx context.Context, c *types.ClusterInfo, cfg types.HealthCheckConfig, instances []model.Instance) ([]healthProbe, error) {
+	probes := make([]healthProbe, len(instances))
+	if len(instances) == 0 {
+		return probes, nil
+	}
+	if cfg.Type == constants.HealthCheckCloud {
+		ids := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			ids = append(ids, instance.InstanceId)
+		}
+		cloudInstances, err := GetInstances(c, ids)
+		if err != nil {
+			return nil, err
+		}
+		return cloudHealthProbes(instances, cloudInstances), nil
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	client := &http.Client{Timeout: timeout}
+	sem := make(chan struct{}, constants.HealthCheckConcurrency)
+	var wg sync.WaitGroup
+	for i := range instances {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			probes[i] = probeInstance(ctx, client, cfg, instances[i].IpInner, timeout)
+		}(i)
+	}
+	wg.Wait()
+	return probes, nil
+}
+
+func probeInstance(ctx context.Context, client *http.Client, cfg types.HealthCheckConfig, ip string, timeout time.Duration) healthProbe {
+	if cfg.Type == constants.HealthCheckTCP {
+		if tcpReachable(ip, cfg.Port, timeout) {
+			return healthProbe{healthy: true}
+		}
+		return healthProbe{message: fmt.Sprintf("tcp port %d is not reachable", cfg.Port)}
+	}
+	url := "http://" + net.JoinHostPort(ip, strconv.Itoa(cfg.Port)) + cfg.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return healthProbe{message: err.Error()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return healthProbe{message: err.Error()}
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return healthProbe{message: fmt.Sprintf("GET %s returned status %d", cfg.Path, resp.StatusCode)}
+	}
+	return healthProbe{healthy: true}
+}
+
+// cloudHealthProbes 云厂商中不存在或状态不是 Running 的实例视为失败
+func cloudHealthProbes(instances []model.Instance, cloudInstances []cloud.Instance) []healthProbe {
+	status := make(map[string]string, len(cloudInstances))
+	for _, instance := range cloudInstances {
+		status[instance.Id] = instance.Status
+	}
+	probes := make([]healthProbe, len(instances))
+	for i, instance := range instances {
+		s, ok := status[instance.InstanceId]
+		switch {
+		case !ok:
+			probes[i] = healthProbe{message: "instance not found in cloud"}
+		case s != cloud.Running:
+			probes[i] = healthProbe{message: "cloud status is " + s}
+		default:
+			probes[i] = healthProbe{healthy: true}
+		}
+	}
+	return probes
+}
+
+// nextInstanceHealth 根据本次检查结果计算实例新的健康状态、连续失败次数和需要记录的事件
+func nextInstanceHealth(instance model.Instance, probe healthProbe, threshold int) (status string, failures int, events []string) {
+	if probe.healthy {
+		if instance.HealthStatus == constants.HealthStatusUnhealthy {
+			events = append(events, constants.HealthEventRecovered)
+		}
+		return constants.HealthStatusHealthy, 0, events
+	}
+	status = instance.HealthStatus
+	failures = instance.HealthFailures + 1
+	events = append(events, constants.HealthEventFailed)
+	if failures >= threshold && status != constants.HealthStatusUnhealthy {
+		status = constants.HealthStatusUnhealthy
+		events = append(events, constants.HealthEventUnhealthy)
+	}
+	return status, failures, events
+}
+
+// recordInstanceHealth 保存检查结果和健康事件, 返回更新后的实例
+func recordInstanceHealth(ctx context.Context, instance model.Instance, cfg types.HealthCheckConfig, probe healthProbe, now time.Time) model.Instance {
+	status, failures, events := nextInstanceHealth(instance, probe, cfg.UnhealthyThreshold)
+	if err := model.UpdateInstanceHealth(ctx, instance.InstanceId, status, failures, now); err != nil {
+		return instance
+	}
+	instance.HealthStatus, instance.HealthFailures, instance.HealthCheckAt = status, failures, &now
+	for _, eventType := range events {
+		message := probe.message
+		switch eventType {
+		case constants.HealthEventUnhealthy:
+			message = fmt.Sprintf("%d consecutive %s checks failed", failures, cfg.Type)
+		case constants.HealthEventRecovered:
+			message = fmt.Sprintf("%s check passed", cfg.Type)
+		}
+		recordHealthEvent(instance, eventType, message, now)
+	}
+	return instance
+}
+
+func recordHealthEvent(instance model.Instance, eventType, message string, now time.Time) {
+	event := &model.InstanceHealthEvent{
+		ClusterName: instance.ClusterName,
+		InstanceId:  instance.InstanceId,
+		EventType:   eventType,
+		Message:     message,
+		CreateAt:    &now,
+	}
+	if err := model.Create(event); err != nil {
+		logs.Logger.Errorf("[recordHealthEvent] instance: %s, event: %s, error: %v", instance.InstanceId, eventType, err)
+	}
+}
+
+// healCandidates 挑选可以替换的不健康实例. 开启了缩容保护的实例不替换; 不健康实例占比超过 max_unhealthy_percent 时
+// 更可能是发布或依赖出了问题, 不替换; 每小时替换的数量不超过 max_heal_per_hour
+func healCandidates(instances []model.Instance, cfg types.HealthCheckConfig, healedInLastHour int) ([]model.Instance, string) {
+	unhealthyCount := 0
+	unhealthy := make([]model.Instance, 0)
+	for _, instance := range instances {
+		if instance.HealthStatus != constants.HealthStatusUnhealthy {
+			continue
+		}
+		unhealthyCount++
+		if instance.Status == constants.Running && !instance.Protected {
+			unhealthy = append(unhealthy, instance)
+		}
+	}
+	if len(unhealthy) == 0 {
+		return nil, ""
+	}
+	if unhealthyCount*100 > cfg.MaxUnhealthyPercent*len(instances) {
+		return nil, fmt.Sprintf("%d of %d instances are unhealthy, more than %d%%", unhealthyCount, len(instances), cfg.MaxUnhealthyPercent)
+	}
+	allowed := cfg.MaxHealPerHour - healedInLastHour
+	if allowed <= 0 {
+		return nil, fmt.Sprintf("%d instances healed in the last hour, reached max_heal_per_hour", healedInLastHour)
+	}
+	if len(unhealthy) > allowed {
+		unhealthy = unhealthy[:allowed]
+	}
+	return unhealthy, ""
+}
+
+// healUnhealthyInstances 按 IP 缩容不健康的实例并创建扩容任务补齐, 声明式集群由 InstanceCountWatchJob 补齐
+func healUnhealthyInstances(ctx context.Context, c *types.ClusterInfo, cfg types.HealthCheckConfig, instances []model.Instance, now time.Time) error {
+	//有任务执行时实例还在变化, 上一轮替换的实例也可能还没释放, 等待下一轮检查
+	if hasUnfinishedTask(c.Name) {
+		return nil
+	}
+	healed, err := model.CountClusterHealthEvents(ctx, c.Name, constants.HealthEventHealed, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	victims, reason := healCandidates(instances, cfg, int(healed))
+	if reason != "" {
+		logs.Logger.Warnf("[healUnhealthyInstances] cluster: %s, skip auto heal: %s", c.Name, reason)
+	}
+	if len(victims) == 0 {
+		return nil
+	}
+	ips := make([]string, 0, len(victims))
+	for _, instance := range victims {
+		ips = append(ips, instance.IpInner)
+	}
+	shrinkTaskId, err := CreateShrinkTask(ctx, c.Name, len(victims), strings.Join(ips, ","), constants.TaskNameAutoHeal, 0)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("released by shrink task %d", shrinkTaskId)
+	if !c.Declarative {
+		expandTaskId, err := CreateExpandTask(ctx, c.Name, len(victims), constants.TaskNameAutoHeal, 0)
+		if err != nil {
+			logs.Logger.Errorf("[healUnhealthyInstances] cluster: %s, create expand task error: %v", c.Name, err)
+		} else {
+			message += fmt.Sprintf(", replaced by expand task %d", expandTaskId)
+		}
+	}
+	for _, instance := range victims {
+		recordHealthEvent(instance, constants.HealthEventHealed, message, now)
+	}
+	return nil
+}
+
+// GetInstanceHealthEvents 实例最近的健康检查失败记录和状态变化
+func GetInstanceHealthEvents(ctx context.Context, instanceId string, limit int) ([]model.InstanceHealthEvent, error) {
+	return model.GetInstanceHealthEvents(ctx, instanceId, limit)
+}
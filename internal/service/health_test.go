@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestNextInstanceHealth(t *testing.T) {
+	cases := []struct {
+		instance     model.Instance
+		probe        healthProbe
+		wantStatus   string
+		wantFailures int
+		wantEvents   []string
+	}{
+		{model.Instance{}, healthProbe{healthy: true}, constants.HealthStatusHealthy, 0, nil},
+		{model.Instance{HealthStatus: constants.HealthStatusHealthy, HealthFailures: 1}, healthProbe{}, constants.HealthStatusHealthy, 2, []string{constants.HealthEventFailed}},
+		{model.Instance{HealthStatus: constants.HealthStatusHealthy, HealthFailures: 2}, healthProbe{}, constants.HealthStatusUnhealthy, 3, []string{constants.HealthEventFailed, constants.HealthEventUnhealthy}},
+		{model.Instance{HealthStatus: constants.HealthStatusUnhealthy, HealthFailures: 3}, healthProbe{}, constants.HealthStatusUnhealthy, 4, []string{constants.HealthEventFailed}},
+		{model.Instance{HealthStatus: constants.HealthStatusUnhealthy, HealthFailures: 4}, healthProbe{healthy: true}, constants.HealthStatusHealthy, 0, []string{constants.HealthEventRecovered}},
+	}
+	for i, cs := range cases {
+		status, failures, events := nextInstanceHealth(cs.instance, cs.probe, 3)
+		if status != cs.wantStatus || failures != cs.wantFailures || !reflect.DeepEqual(events, cs.wantEvents) {
+			t.Errorf("case %d got %s %d %v", i, status, failures, events)
+		}
+	}
+}
+
+func TestHealCandidates(t *testing.T) {
+	unhealthy := func(id string, protected bool) model.Instance {
+		return model.Instance{InstanceId: id, Status: constants.Running, HealthStatus: constants.HealthStatusUnhealthy, Protected: protected}
+	}
+	instances := []model.Instance{
+		unhealthy("i-1", false),
+		unhealthy("i-2", true),
+		unhealthy("i-3", false),
+		{InstanceId: "i-4", Status: constants.Running, HealthStatus: constants.HealthStatusHealthy},
+		{InstanceId: "i-5", Status: constants.Running},
+		{InstanceId: "i-6", Status: constants.Running},
+		{InstanceId: "i-7", Status: constants.Running},
+		{InstanceId: "i-8", Status: constants.Running},
+	}
+	cfg := healthCheckDefaults(types.HealthCheckConfig{MaxHealPerHour: 3})
+	victims, reason := healCandidates(instances, cfg, 0)
+	if ids := victimIds(victims); !reflect.DeepEqual(ids, []string{"i-1", "i-3"}) || reason != "" {
+		t.Errorf("got %v, reason %q", ids, reason)
+	}
+	victims, _ = healCandidates(instances, cfg, 2)
+	if ids := victimIds(victims); !reflect.DeepEqual(ids, []string{"i-1"}) {
+		t.Errorf("rate limited got %v", ids)
+	}
+	if victims, reason = healCandidates(instances, cfg, 3); len(victims) != 0 || reason == "" {
+		t.Errorf("want no victims after reaching max heal per hour, got %v", victims)
+	}
+	cfg.MaxUnhealthyPercent = 30
+	if victims, reason = healCandidates(instances, cfg, 0); len(victims) != 0 || reason == "" {
+		t.Errorf("want no victims when too many unhealthy, got %v", victims)
+	}
+}
+
+func TestHealthCheckTargets(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	instances := []model.Instance{
+		{InstanceId: "i-1", Status: constants.Running, IpInner: "10.0.0.1", RunningAt: at(time.Hour)},
+		{InstanceId: "i-2", Status: constants.Running, IpInner: "10.0.0.2", RunningAt: at(time.Minute)},
+		{InstanceId: "i-3", Status: constants.Deleting, IpInner: "10.0.0.3", RunningAt: at(time.Hour)},
+		{InstanceId: "i-4", Status: constants.Running, RunningAt: at(time.Hour)},
+	}
+	cfg := healthCheckDefaults(types.HealthCheckConfig{Type: constants.HealthCheckTCP})
+	if ids := victimIds(healthCheckTargets(instances, cfg, now)); !reflect.DeepEqual(ids, []string{"i-1"}) {
+		t.Errorf("tcp targets got %v", ids)
+	}
+	cfg.Type = constants.HealthCheckCloud
+	if ids := victimIds(healthCheckTargets(instances, cfg, now)); !reflect.DeepEqual(ids, []string{"i-1", "i-4"}) {
+		t.Errorf("cloud targets got %v", ids)
+	}
+}
+
+func TestProbeInstance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	cfg := healthCheckDefaults(types.HealthCheckConfig{Type: constants.HealthCheckHTTP, Path: "/health"})
+	cfg.Port = server.Listener.Addr().(*net.TCPAddr).Port
+	client := &http.Client{Timeout: time.Second}
+	if probe := probeInstance(context.Background(), client, cfg, host, time.Second); !probe.healthy {
+		t.Errorf("want healthy, got %s", probe.message)
+	}
+	cfg.Path = "/missing"
+	if probe := probeInstance(context.Background(), client, cfg, host, time.Second); probe.healthy {
+		t.Errorf("want unhealthy for port %s path %s", port, cfg.Path)
+	}
+	cfg.Type = constants.HealthCheckTCP
+	if probe := probeInstance(context.Background(), client, cfg, host, time.Second); !probe.healthy {
+		t.Errorf("want tcp healthy, got %s", probe.message)
+	}
+}
+
+func TestCloudHealthProbes(t *testing.T) {
+	instances := []model.Instance{{InstanceId: "i-1"}, {InstanceId: "i-2"}, {InstanceId: "i-3"}}
+	probes := cloudHealthProbes(instances, []cloud.Instance{{Id: "i-1", Status: cloud.Running}, {Id: "i-2", Status: cloud.Stopped}})
+	if !probes[0].healthy || probes[1].healthy || probes[2].healthy {
+		t.Errorf("unexpected probes %+v", probes)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	jsoniter "github.com/json-iterator/go"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIdempotencyKeyReused 相同的 Idempotency-Key 已用于内容不同的请求
+	ErrIdempotencyKeyReused = errors.New("idempotency key has been used by a different request")
+	// ErrIdempotencyKeyInProgress 使用相同 Idempotency-Key 的请求还在处理中
+	ErrIdempotencyKeyInProgress = errors.New("request with the same idempotency key is in progress")
+
+	errIdempotencyLeaseExpired = errors.New("request with the same idempotency key is abandoned")
+)
+
+// IdempotencyRequestHash 计算请求方法、路径和请求体的摘要, 用于判断重复提交的请求内容是否一致
+func IdempotencyRequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BeginIdempotentRequest 登记带 Idempotency-Key 的请求. replay 为 true 时返回的记录是第一次请求的响应,
+// 否则是新登记的记录, 请求处理完成后需要调用 FinishIdempotentRequest
+func BeginIdempotentRequest(ctx context.Context, userId int64, key, path, hash string) (record *model.IdempotencyRecord, replay bool, err error) {
+	now := time.Now()
+	record, err = model.GetIdempotencyRecord(ctx, userId, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	//超过保留期的 key 视为新请求
+	if record != nil && record.ExpireAt != nil && record.ExpireAt.Before(now) {
+		if err = model.DeleteIdempotencyRecord(ctx, record.Id); err != nil {
+			return nil, false, err
+		}
+		record = nil
+	}
+	if record != nil {
+		return resumeIdempotentRequest(ctx, record, hash, now)
+	}
+	expireAt := now.Add(idempotencyKeyTTL())
+	record = &model.IdempotencyRecord{
+		UserId:      userId,
+		IdemKey:     key,
+		RequestPath: path,
+		RequestHash: hash,
+		ExpireAt:    &expireAt,
+		CreateAt:    &now,
+	}
+	created, err := model.CreateIdempotencyRecord(ctx, record)
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return record, false, nil
+	}
+	//并发提交的相同 key 已经登记
+	record, err = model.GetIdempotencyRecord(ctx, userId, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return resumeIdempotentRequest(ctx, record, hash, now)
+}
+
+// resumeIdempotentRequest 第一次请求已完成时重放响应; 第一次请求超过租期仍在处理中时视为已中断(例如进程退出), 由本次请求接管
+func resumeIdempotentRequest(ctx context.Context, record *model.IdempotencyRecord, hash string, now time.Time) (*model.IdempotencyRecord, bool, error) {
+	leaseStart := now.Add(-idempotencyLease())
+	res, replay, err := checkIdempotencyReplay(record, hash, leaseStart)
+	if !errors.Is(err, errIdempotencyLeaseExpired) {
+		return res, replay, err
+	}
+	expireAt := now.Add(idempotencyKeyTTL())
+	ok, err := model.TakeOverIdempotencyRecord(ctx, record.Id, leaseStart, now, expireAt)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		//其他请求已经接管或第一次请求刚刚完成
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+	logs.Logger.Warnf("[BeginIdempotentRequest] key: %s registered at %v abandoned, taken over", record.IdemKey, record.CreateAt)
+	record.CreateAt = &now
+	record.ExpireAt = &expireAt
+	return record, false, nil
+}
+
+// checkIdempotencyReplay 检查已登记的记录, leaseStart 之前登记且还在处理中时返回 errIdempotencyLeaseExpired
+func checkIdempotencyReplay(record *model.IdempotencyRecord, hash string, leaseStart time.Time) (*model.IdempotencyRecord, bool, error) {
+	if record.RequestHash != hash {
+		return nil, false, ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == 0 {
+		if record.CreateAt != nil && record.CreateAt.Before(leaseStart) {
+			return nil, false, errIdempotencyLeaseExpired
+		}
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+	return record, true, nil
+}
+
+// FinishIdempotentRequest 保存成功请求的响应用于重放; 请求失败时删除记录, 客户端可以用相同的 key 重试
+func FinishIdempotentRequest(ctx context.Context, record *model.IdempotencyRecord, statusCode int, body []byte) {
+	var err error
+	if statusCode >= 200 && statusCode < 300 {
+		err = model.SaveIdempotencyResponse(ctx, record.Id, statusCode, idempotencyResourceId(body), string(body))
+	} else {
+		err = model.DeleteIdempotencyRecord(ctx, record.Id)
+	}
+	if err != nil {
+		logs.Logger.Errorf("[FinishIdempotentRequest] key: %s, error: %v", record.IdemKey, err)
+	}
+}
+
+// idempotencyResourceId 创建类接口在响应的 data 中返回资源或任务id
+func idempotencyResourceId(body []byte) string {
+	var resp struct {
+		Data jsoniter.RawMessage `json:"data"`
+	}
+	if err := jsoniter.Unmarshal(body, &resp); err != nil || len(resp.Data) == 0 {
+		return ""
+	}
+	//任务id超过 float64 的精度, 数字直接取原文
+	if resp.Data[0] >= '0' && resp.Data[0] <= '9' {
+		return string(resp.Data)
+	}
+	var id string
+	if err := jsoniter.Unmarshal(resp.Data, &id); err != nil {
+		return ""
+	}
+	return id
+}
+
+func idempotencyKeyTTL() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.IdempotencyCfg.KeyTTLSec > 0 {
+		return time.Duration(config.GlobalConfig.IdempotencyCfg.KeyTTLSec) * time.Second
+	}
+	return constants.DefaultIdempotencyKeyTTL
+}
+
+func idempotencyLease() time.Duration {
+	if config.GlobalConfig != nil && config.GlobalConfig.IdempotencyCfg.LeaseSec > 0 {
+		return time.Duration(config.GlobalConfig.IdempotencyCfg.LeaseSec) * time.Second
+	}
+	return constants.DefaultIdempotencyLease
+}
+
+// CleanExpiredIdempotencyRecords 删除超过保留期的 Idempotency-Key 记录
+func CleanExpiredIdempotencyRecords(ctx context.Context) (int64, error) {
+	return model.DeleteExpiredIdempotencyRecords(ctx, time.Now())
+}
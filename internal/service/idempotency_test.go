@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestIdempotencyRequestHash(t *testing.T) {
+	h := IdempotencyRequestHash("POST", "/api/v1/cluster/expand", []byte(`{"count":1}`))
+	if h != IdempotencyRequestHash("POST", "/api/v1/cluster/expand", []byte(`{"count":1}`)) {
+		t.Error("same request got different hash")
+	}
+	if h == IdempotencyRequestHash("POST", "/api/v1/cluster/expand", []byte(`{"count":2}`)) {
+		t.Error("different body got same hash")
+	}
+	if h == IdempotencyRequestHash("POST", "/api/v1/cluster/shrink", []byte(`{"count":1}`)) {
+		t.Error("different path got same hash")
+	}
+}
+
+func TestCheckIdempotencyReplay(t *testing.T) {
+	now := time.Now()
+	leaseStart := now.Add(-time.Minute)
+	record := &model.IdempotencyRecord{RequestHash: "h1", CreateAt: &now}
+	if _, _, err := checkIdempotencyReplay(record, "h2", leaseStart); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("different hash got %v", err)
+	}
+	if _, _, err := checkIdempotencyReplay(record, "h1", leaseStart); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("in progress got %v", err)
+	}
+	//超过租期仍在处理中的请求可以被接管
+	abandoned := now.Add(-2 * time.Minute)
+	record.CreateAt = &abandoned
+	if _, _, err := checkIdempotencyReplay(record, "h1", leaseStart); !errors.Is(err, errIdempotencyLeaseExpired) {
+		t.Errorf("abandoned got %v", err)
+	}
+	if _, _, err := checkIdempotencyReplay(record, "h2", leaseStart); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("abandoned with different hash got %v", err)
+	}
+	record.StatusCode = 200
+	if got, replay, err := checkIdempotencyReplay(record, "h1", leaseStart); err != nil || !replay || got != record {
+		t.Errorf("finished got %v %v %v", got, replay, err)
+	}
+}
+
+func TestIdempotencyResourceId(t *testing.T) {
+	cases := map[string]string{
+		`{"code":200,"msg":"success","data":1459474478530514944}`: "1459474478530514944",
+		`{"code":200,"msg":"success","data":"vpc-123"}`:           "vpc-123",
+		`{"code":200,"msg":"success","data":null}`:                "",
+		`{"code":200,"msg":"success","data":{"id":1}}`:            "",
+		`not json`: "",
+	}
+	for body, want := range cases {
+		if got := idempotencyResourceId([]byte(body)); got != want {
+			t.Errorf("%s got %q, want %q", body, got, want)
+		}
+	}
+}
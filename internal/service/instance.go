@@ -48,7 +48,7 @@ func GetInstanceTypeByName(ctx context.Context, instanceTypeName string) (*model
 func GetInstancesByTaskId(ctx context.Context, taskId string, taskAction string) ([]model.Instance, error) {
 	ret := make([]model.Instance, 0)
 	m := make(map[string]interface{}, 0)
-	if taskAction == constants.TaskActionExpand {
+	if taskAction == constants.TaskActionExpand || taskAction == constants.TaskActionRollingUpdate {
 		m["task_id"] = taskId
 	} else if taskAction == constants.TaskActionShrink {
 		m["shrink_task_id"] = taskId
@@ -159,7 +159,7 @@ type InstancesSearchCond struct {
 
 func GetInstancesByCond(ctx context.Context, cond InstancesSearchCond) (ret []model.Instance, total int64, err error) {
 	queryMap := map[string]interface{}{}
-	if cond.TaskAction == constants.TaskActionExpand {
+	if cond.TaskAction == constants.TaskActionExpand || cond.TaskAction == constants.TaskActionRollingUpdate {
 		queryMap["task_id"] = cond.TaskId
 	}
 	if cond.TaskAction == constants.TaskActionShrink {
@@ -330,11 +330,11 @@ func RefreshCache() error {
 		return err
 	}
 	if len(ins) == 0 {
-		// TODO: SELECT `provider`,`access_key` FROM ACCOUNT GROUP BY `provider`.
-		err = SyncInstanceTypes(ctx, cloud.AlibabaCloud)
-		if err != nil {
-			logs.Logger.Error("SyncInstanceTypes Error err:%v", err)
-			return err
+		for _, provider := range cloud.ProviderNames() {
+			err = SyncInstanceTypes(ctx, provider)
+			if err != nil {
+				logs.Logger.Errorf("SyncInstanceTypes Error provider:%s err:%v", provider, err)
+			}
 		}
 		ins, err = model.ScanInstanceType(ctx)
 		if err != nil {
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+// zoneInstanceTypes 各可用区按优先级排列的候选规格
+func zoneInstanceTypes(c *types.ClusterInfo, zones []types.ZoneConfig) map[string][]string {
+	derived := deriveInstanceTypes(c)
+	res := make(map[string][]string, len(zones))
+	for _, zone := range zones {
+		res[zone.ZoneId] = instanceTypeCandidates(c, derived, zone.ZoneId)
+	}
+	return res
+}
+
+// deriveInstanceTypes 查询与集群规格同规格族、同核数内存的其他规格, 未开启自动追加时返回空
+func deriveInstanceTypes(c *types.ClusterInfo) []model.InstanceType {
+	if c.InstanceTypeConfig == nil || !c.InstanceTypeConfig.AutoDerive {
+		return nil
+	}
+	ctx := context.Background()
+	spec, err := model.GetInstanceTypeByName(ctx, c.InstanceType)
+	if err != nil {
+		logs.Logger.Errorf("[deriveInstanceTypes] cluster name: %s, instance type: %s, error: %v", c.Name, c.InstanceType, err)
+		return nil
+	}
+	derived, err := model.GetInstanceTypesByCond(ctx, model.InstanceTypeCondition{
+		Provider: c.Provider,
+		RegionId: c.RegionId,
+		Family:   spec.Family,
+		Core:     spec.Core,
+		Memory:   spec.Memory,
+	})
+	if err != nil {
+		logs.Logger.Errorf("[deriveInstanceTypes] cluster name: %s, error: %v", c.Name, err)
+		return nil
+	}
+	return derived
+}
+
+// instanceTypeCandidates 集群规格优先, 其次是配置的备选规格, 最后是该可用区内自动追加的规格
+func instanceTypeCandidates(c *types.ClusterInfo, derived []model.InstanceType, zoneId string) []string {
+	candidates := make([]string, 0, 1+len(derived))
+	seen := make(map[string]bool)
+	add := func(instanceType string) {
+		if instanceType == "" || seen[instanceType] {
+			return
+		}
+		seen[instanceType] = true
+		candidates = append(candidates, instanceType)
+	}
+	add(c.InstanceType)
+	if c.InstanceTypeConfig != nil {
+		for _, instanceType := range c.InstanceTypeConfig.InstanceTypes {
+			add(instanceType)
+		}
+	}
+	for _, row := range derived {
+		if row.ZoneId == zoneId {
+			add(row.TypeName)
+		}
+	}
+	return candidates
+}
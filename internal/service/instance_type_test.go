@@ -0,0 +1,26 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+func TestInstanceTypeCandidates(t *testing.T) {
+	c := &types.ClusterInfo{InstanceType: "ecs.g6.large"}
+	if got := instanceTypeCandidates(c, nil, "a"); !reflect.DeepEqual(got, []string{"ecs.g6.large"}) {
+		t.Errorf("candidates got %v", got)
+	}
+	c.InstanceTypeConfig = &types.InstanceTypeConfig{InstanceTypes: []string{"ecs.g7.large", "ecs.g6.large"}, AutoDerive: true}
+	derived := []model.InstanceType{
+		{ZoneId: "a", TypeName: "ecs.g6a.large"},
+		{ZoneId: "b", TypeName: "ecs.g6e.large"},
+		{ZoneId: "a", TypeName: "ecs.g7.large"},
+	}
+	want := []string{"ecs.g6.large", "ecs.g7.large", "ecs.g6a.large"}
+	if got := instanceTypeCandidates(c, derived, "a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates got %v, want %v", got, want)
+	}
+}
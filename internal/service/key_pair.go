@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/errs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+type KeyPairRequest struct {
+	Provider    string
+	RegionId    string
+	Ak          string
+	KeyPairName string
+	PublicKey   string
+}
+
+// CreateKeyPair 私钥只在创建时返回, 不落库
+func CreateKeyPair(ctx context.Context, req KeyPairRequest) (cloud.CreateKeyPairResponse, error) {
+	p, err := getProvider(req.Provider, req.Ak, req.RegionId)
+	if err != nil {
+		return cloud.CreateKeyPairResponse{}, err
+	}
+	return p.CreateKeyPair(cloud.CreateKeyPairRequest{
+		RegionId:    req.RegionId,
+		KeyPairName: req.KeyPairName,
+	})
+}
+
+func ImportKeyPair(ctx context.Context, req KeyPairRequest) (cloud.ImportKeyPairResponse, error) {
+	p, err := getProvider(req.Provider, req.Ak, req.RegionId)
+	if err != nil {
+		return cloud.ImportKeyPairResponse{}, err
+	}
+	return p.ImportKeyPair(cloud.ImportKeyPairRequest{
+		RegionId:    req.RegionId,
+		KeyPairName: req.KeyPairName,
+		PublicKey:   req.PublicKey,
+	})
+}
+
+func ListKeyPairs(ctx context.Context, req KeyPairRequest) ([]cloud.KeyPair, error) {
+	p, err := getProvider(req.Provider, req.Ak, req.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.DescribeKeyPairs(cloud.DescribeKeyPairsRequest{RegionId: req.RegionId})
+	if err != nil {
+		return nil, err
+	}
+	return res.KeyPairs, nil
+}
+
+// DeleteKeyPair 仍被集群引用的密钥对不允许删除, 否则后续扩容会失败
+func DeleteKeyPair(ctx context.Context, req KeyPairRequest) error {
+	cnt, err := model.CountClustersByKeyPair(ctx, req.Ak, req.RegionId, req.KeyPairName)
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	if cnt > 0 {
+		return errs.ErrKeyPairInUse
+	}
+	p, err := getProvider(req.Provider, req.Ak, req.RegionId)
+	if err != nil {
+		return err
+	}
+	return p.DeleteKeyPair(cloud.DeleteKeyPairRequest{
+		RegionId:    req.RegionId,
+		KeyPairName: req.KeyPairName,
+	})
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+)
+
+// ErrLifecycleAbandoned 生命周期钩子超时且 default_result 为 ABANDON
+var ErrLifecycleAbandoned = errors.New("lifecycle hook timed out and abandoned")
+
+// LifecycleHookPayload 调用 webhook 时 POST 的内容
+type LifecycleHookPayload struct {
+	Hook        string                  `json:"hook"`
+	ClusterName string                  `json:"cluster_name"`
+	TaskId      string                  `json:"task_id"`
+	Instances   []LifecycleHookInstance `json:"instances"`
+}
+
+type LifecycleHookInstance struct {
+	InstanceId string `json:"instance_id"`
+	IpInner    string `json:"ip_inner"`
+}
+
+var lifecycleHTTPClient = &http.Client{Timeout: constants.LifecycleWebhookTimeout * time.Second}
+
+func preTerminateHook(c *types.ClusterInfo) *types.LifecycleHook {
+	if c.LifecycleConfig == nil {
+		return nil
+	}
+	return c.LifecycleConfig.PreTerminate
+}
+
+func postLaunchHook(c *types.ClusterInfo) *types.LifecycleHook {
+	if c.LifecycleConfig == nil {
+		return nil
+	}
+	return c.LifecycleConfig.PostLaunch
+}
+
+// launchStatus 拿到 IP 后的实例状态, 配置了 post_launch 钩子时就绪后才变为 RUNNING
+func launchStatus(c *types.ClusterInfo) constants.Status {
+	if postLaunchHook(c) != nil {
+		return constants.Starting
+	}
+	return constants.Running
+}
+
+// drainedInstances 摘除流量前实例的状态, 放弃释放时恢复为原来的状态
+type drainedInstances map[string]constants.Status
+
+// drainInstances 把待释放的实例标记为 DELETING 并重新发布 IP 列表, 配置了 pre_terminate 钩子时等待 webhook 确认.
+// 返回实例摘除前的状态; 返回错误时实例已恢复为原来的状态, 不能继续释放
+func drainInstances(ctx context.Context, c *types.ClusterInfo, instanceIds []string, taskId int64) (drainedInstances, error) {
+	drained, err := model.GetInstanceStatuses(ctx, instanceIds)
+	if err != nil {
+		return nil, err
+	}
+	err = model.BatchUpdateByInstanceIds(instanceIds, model.Instance{Status: constants.Deleting})
+	if err != nil {
+		return nil, err
+	}
+	if err = publishShrinkConfig(c.Name); err != nil {
+		restoreDrainingInstances(c, drained)
+		return nil, err
+	}
+	RecordTaskEvent(taskId, constants.TaskEventDraining, "%d instances marked DELETING and removed from working ips", len(instanceIds))
+	hook := preTerminateHook(c)
+	if hook == nil {
+		return drained, nil
+	}
+	instances, err := model.GetActiveInstancesByInstanceIds(ctx, instanceIds)
+	if err != nil {
+		restoreDrainingInstances(c, drained)
+		return nil, err
+	}
+	payload := lifecycleHookPayload(constants.LifecycleHookPreTerminate, c.Name, taskId, instances)
+	acked, err := waitLifecycleHook(ctx, hook, func() bool {
+		return callLifecycleWebhook(ctx, hook.WebhookUrl, payload) == nil
+	})
+	if err != nil {
+		restoreDrainingInstances(c, drained)
+		return nil, err
+	}
+	if acked {
+		RecordTaskEvent(taskId, constants.TaskEventLifecycleHook, "pre_terminate hook acked for %d instances", len(instanceIds))
+		return drained, nil
+	}
+	if lifecycleDefaultResult(hook) == constants.LifecycleResultAbandon {
+		RecordTaskEvent(taskId, constants.TaskEventLifecycleHook, "pre_terminate hook timed out, abandon releasing %d instances", len(instanceIds))
+		restoreDrainingInstances(c, drained)
+		return nil, fmt.Errorf("%w: pre_terminate hook of %d instances", ErrLifecycleAbandoned, len(instanceIds))
+	}
+	RecordTaskEvent(taskId, constants.TaskEventLifecycleHook, "pre_terminate hook timed out, continue releasing %d instances", len(instanceIds))
+	return drained, nil
+}
+
+// restoreDrainingInstances 放弃释放时把实例恢复为摘除前的状态并重新发布 IP 列表
+func restoreDrainingInstances(c *types.ClusterInfo, drained drainedInstances) {
+	for status, ids := range drained.idsByStatus() {
+		if err := model.BatchUpdateByInstanceIds(ids, model.Instance{Status: status}); err != nil {
+			logs.Logger.Errorf("[restoreDrainingInstances] cluster name: %s, error: %v", c.Name, err)
+		}
+	}
+	_ = publishShrinkConfig(c.Name)
+}
+
+// merge 合并另一批摘除的实例
+func (d drainedInstances) merge(other drainedInstances) drainedInstances {
+	if d == nil {
+		d = make(drainedInstances, len(other))
+	}
+	for id, status := range other {
+		d[id] = status
+	}
+	return d
+}
+
+// idsByStatus 按摘除前的状态分组, 每组按实例 ID 排序
+func (d drainedInstances) idsByStatus() map[constants.Status][]string {
+	res := make(map[constants.Status][]string)
+	for id, status := range d {
+		res[status] = append(res[status], id)
+	}
+	for _, ids := range res {
+		sort.Strings(ids)
+	}
+	return res
+}
+
+// waitInstancesReady 配置了 post_launch 钩子时等待有 IP 的实例就绪并标记为 RUNNING,
+// 超时且 default_result 为 ABANDON 时释放未就绪的实例, 返回保留的实例和被释放的实例 ID
+func waitInstancesReady(ctx context.Context, c *types.ClusterInfo, taskId int64, instances []cloud.Instance) ([]cloud.Instance, []string, error) {
+	hook := postLaunchHook(c)
+	if hook == nil {
+		return instances, nil, nil
+	}
+	kept := make([]cloud.Instance, 0, len(instances))
+	pending := make([]cloud.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if instance.IpInner == "" {
+			kept = append(kept, instance)
+		} else {
+			pending = append(pending, instance)
+		}
+	}
+	ready := make([]cloud.Instance, 0, len(pending))
+	_, err := waitLifecycleHook(ctx, hook, func() bool {
+		var newlyReady []cloud.Instance
+		newlyReady, pending = splitReadyInstances(ctx, hook, c.Name, taskId, pending)
+		ready = append(ready, newlyReady...)
+		return len(pending) == 0
+	})
+	if err != nil {
+		return instances, nil, err
+	}
+	if len(pending) > 0 && lifecycleDefaultResult(hook) != constants.LifecycleResultAbandon {
+		RecordTaskEvent(taskId, constants.TaskEventLifecycleHook, "post_launch hook timed out, continue with %d not ready instances", len(pending))
+		ready = append(ready, pending...)
+		pending = nil
+	}
+	if err = markInstancesRunning(ready); err != nil {
+		return instances, nil, err
+	}
+	RecordTaskEvent(taskId, constants.TaskEventLifecycleHook, "%d instances ready", len(ready))
+	kept = append(kept, ready...)
+	if len(pending) == 0 {
+		return kept, nil, nil
+	}
+	abandonedIds := make([]string, 0, len(pending))
+	for _, instance := range pending {
+		abandonedIds = append(abandonedIds, instance.Id)
+	}
+	if err = Shrink(c, abandonedIds); err != nil {
+		logs.Logger.Errorf("[waitInstancesReady] release not ready instances error. cluster name: %s, error: %v", c.Name, err)
+	}
+	now := time.Now()
+	err = model.BatchUpdateByInstanceIds(abandonedIds, model.Instance{Status: constants.Deleted, DeleteAt: &now})
+	if err != nil {
+		logs.Logger.Errorf("[waitInstancesReady] update not ready instances error. cluster name: %s, error: %v", c.Name, err)
+	}
+	RecordTaskEvent(taskId, constants.TaskEventReleased, "post_launch hook timed out, released %d not ready instances", len(abandonedIds))
+	return kept, abandonedIds, fmt.Errorf("%w: %d instances not ready", ErrLifecycleAbandoned, len(abandonedIds))
+}
+
+// splitReadyInstances 先检查 TCP 端口, 端口可以连接的实例再一起调用 webhook, webhook 返回 2xx 时都视为就绪
+func splitReadyInstances(ctx context.Context, hook *types.LifecycleHook, clusterName string, taskId int64, instances []cloud.Instance) (ready, pending []cloud.Instance) {
+	candidates := make([]cloud.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if hook.HealthCheckPort == 0 || tcpReachable(instance.IpInner, hook.HealthCheckPort, constants.Delay*time.Second) {
+			candidates = append(candidates, instance)
+		} else {
+			pending = append(pending, instance)
+		}
+	}
+	if len(candidates) == 0 || hook.WebhookUrl == "" {
+		return candidates, pending
+	}
+	hookInstances := make([]model.Instance, 0, len(candidates))
+	for _, instance := range candidates {
+		hookInstances = append(hookInstances, model.Instance{InstanceId: instance.Id, IpInner: instance.IpInner})
+	}
+	payload := lifecycleHookPayload(constants.LifecycleHookPostLaunch, clusterName, taskId, hookInstances)
+	if err := callLifecycleWebhook(ctx, hook.WebhookUrl, payload); err != nil {
+		return nil, append(pending, candidates...)
+	}
+	return candidates, pending
+}
+
+func markInstancesRunning(instances []cloud.Instance) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.Id)
+	}
+	now := time.Now()
+	return model.BatchUpdateByInstanceIds(ids, model.Instance{Status: constants.Running, RunningAt: &now})
+}
+
+// waitLifecycleHook 每隔 LifecycleHookInterval 秒调用一次 check 直到返回 true, 超时返回 false
+func waitLifecycleHook(ctx context.Context, hook *types.LifecycleHook, check func() bool) (bool, error) {
+	deadline := time.Now().Add(time.Duration(lifecycleHookTimeout(hook)) * time.Second)
+	for {
+		if check() {
+			return true, nil
+		}
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(constants.LifecycleHookInterval * time.Second):
+		}
+	}
+}
+
+func lifecycleHookTimeout(hook *types.LifecycleHook) int {
+	if hook.TimeoutSeconds == 0 {
+		return constants.DefaultLifecycleHookTimeout
+	}
+	return hook.TimeoutSeconds
+}
+
+// LifecycleHookDuration 扩缩容任务等待生命周期钩子最长需要的时间, 任务的最长执行时间需要加上这段时间.
+// 不是扩缩容任务或集群没有配置对应的钩子时返回 0
+func LifecycleHookDuration(task *model.Task) time.Duration {
+	var count int
+	switch task.TaskAction {
+	case constants.TaskActionExpand:
+		info := &model.ExpandTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return 0
+		}
+		count = info.Count
+	case constants.TaskActionShrink:
+		info := &model.ShrinkTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return 0
+		}
+		count = info.Count
+	default:
+		return 0
+	}
+	cluster, err := model.GetByClusterName(task.TaskFilter)
+	if err != nil {
+		return 0
+	}
+	c, err := ConvertToClusterInfo(cluster, nil)
+	if err != nil {
+		return 0
+	}
+	return lifecycleHookDuration(c, task.TaskAction, count)
+}
+
+// lifecycleHookDuration 扩容等待一次 post_launch 钩子, 缩容每批等待一次 pre_terminate 钩子.
+// 每次等待在超时前最后一次调用 webhook 时还可能多等待 LifecycleHookInterval + LifecycleWebhookTimeout 秒
+func lifecycleHookDuration(c *types.ClusterInfo, action string, count int) time.Duration {
+	hook, batches := postLaunchHook(c), 1
+	if action == constants.TaskActionShrink {
+		hook, batches = preTerminateHook(c), (count+constants.BatchMax-1)/constants.BatchMax
+	}
+	if hook == nil {
+		return 0
+	}
+	wait := lifecycleHookTimeout(hook) + constants.LifecycleHookInterval + constants.LifecycleWebhookTimeout
+	return time.Duration(batches*wait) * time.Second
+}
+
+func lifecycleDefaultResult(hook *types.LifecycleHook) string {
+	if hook.DefaultResult == "" {
+		return constants.LifecycleResultContinue
+	}
+	return hook.DefaultResult
+}
+
+func lifecycleHookPayload(hook, clusterName string, taskId int64, instances []model.Instance) []byte {
+	payload := LifecycleHookPayload{
+		Hook:        hook,
+		ClusterName: clusterName,
+		TaskId:      cast.ToString(taskId),
+		Instances:   make([]LifecycleHookInstance, 0, len(instances)),
+	}
+	for _, instance := range instances {
+		payload.Instances = append(payload.Instances, LifecycleHookInstance{InstanceId: instance.InstanceId, IpInner: instance.IpInner})
+	}
+	data, _ := jsoniter.Marshal(payload)
+	return data
+}
+
+// callLifecycleWebhook 返回 2xx 视为确认
+func callLifecycleWebhook(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := lifecycleHTTPClient.Do(req)
+	if err != nil {
+		logs.Logger.Warnf("[callLifecycleWebhook] url: %s, error: %v", url, err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func tcpReachable(ip string, port int, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestCallLifecycleWebhook(t *testing.T) {
+	var got LifecycleHookPayload
+	ready := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = jsoniter.NewDecoder(r.Body).Decode(&got)
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	payload := lifecycleHookPayload(constants.LifecycleHookPreTerminate, "c1", 12, []model.Instance{{InstanceId: "i-1", IpInner: "10.0.0.1"}})
+	if err := callLifecycleWebhook(context.Background(), server.URL, payload); err == nil {
+		t.Errorf("want error for status 503")
+	}
+	ready = true
+	if err := callLifecycleWebhook(context.Background(), server.URL, payload); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if got.Hook != constants.LifecycleHookPreTerminate || got.TaskId != "12" || len(got.Instances) != 1 || got.Instances[0].IpInner != "10.0.0.1" {
+		t.Errorf("unexpected payload %+v", got)
+	}
+}
+
+func TestSplitReadyInstances(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+	instances := []cloud.Instance{{Id: "i-1", IpInner: "127.0.0.1"}, {Id: "i-2", IpInner: "127.0.0.2"}}
+
+	hook := &types.LifecycleHook{HealthCheckPort: port}
+	ready, pending := splitReadyInstances(context.Background(), hook, "c1", 1, instances)
+	if len(ready) != 1 || ready[0].Id != "i-1" || len(pending) != 1 || pending[0].Id != "i-2" {
+		t.Errorf("tcp check got ready %v, pending %v", ready, pending)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	hook.WebhookUrl = server.URL
+	ready, pending = splitReadyInstances(context.Background(), hook, "c1", 1, instances)
+	if len(ready) != 0 || len(pending) != 2 {
+		t.Errorf("webhook not ready got ready %v, pending %v", ready, pending)
+	}
+}
+
+func TestLaunchStatus(t *testing.T) {
+	c := &types.ClusterInfo{}
+	if launchStatus(c) != constants.Running {
+		t.Errorf("want RUNNING without post_launch hook")
+	}
+	c.LifecycleConfig = &types.LifecycleConfig{PostLaunch: &types.LifecycleHook{HealthCheckPort: 80}}
+	if launchStatus(c) != constants.Starting {
+		t.Errorf("want STARTING with post_launch hook")
+	}
+	acked, err := waitLifecycleHook(context.Background(), c.LifecycleConfig.PostLaunch, func() bool { return true })
+	if !acked || err != nil {
+		t.Errorf("want acked, got %v %v", acked, err)
+	}
+}
+
+func TestDrainedInstances(t *testing.T) {
+	var drained drainedInstances
+	drained = drained.merge(drainedInstances{"i-2": constants.Running, "i-3": constants.Starting})
+	drained = drained.merge(drainedInstances{"i-1": constants.Running, "i-4": constants.Deleting})
+	want := map[constants.Status][]string{
+		constants.Running:  {"i-1", "i-2"},
+		constants.Starting: {"i-3"},
+		constants.Deleting: {"i-4"},
+	}
+	if got := drained.idsByStatus(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ids by status got %v, want %v", got, want)
+	}
+}
+
+func TestLifecycleHookDuration(t *testing.T) {
+	hook := &types.LifecycleHook{WebhookUrl: "http://127.0.0.1/hook", TimeoutSeconds: constants.MaxLifecycleHookTimeout}
+	c := &types.ClusterInfo{LifecycleConfig: &types.LifecycleConfig{PreTerminate: hook, PostLaunch: hook}}
+	wait := time.Duration(constants.MaxLifecycleHookTimeout+constants.LifecycleHookInterval+constants.LifecycleWebhookTimeout) * time.Second
+
+	if got := lifecycleHookDuration(c, constants.TaskActionExpand, 3*constants.BatchMax+1); got != wait {
+		t.Errorf("expand: got %v, want %v", got, wait)
+	}
+	if got := lifecycleHookDuration(c, constants.TaskActionShrink, 3*constants.BatchMax+1); got != 4*wait {
+		t.Errorf("shrink: got %v, want %v", got, 4*wait)
+	}
+	if got := lifecycleHookDuration(c, constants.TaskActionShrink, constants.BatchMax); got != wait {
+		t.Errorf("single batch shrink: got %v, want %v", got, wait)
+	}
+	if got := lifecycleHookDuration(&types.ClusterInfo{}, constants.TaskActionShrink, 1); got != 0 {
+		t.Errorf("no hook: got %v, want 0", got)
+	}
+	if got := lifecycleHookDuration(&types.ClusterInfo{LifecycleConfig: &types.LifecycleConfig{PostLaunch: hook}}, constants.TaskActionShrink, 1); got != 0 {
+		t.Errorf("shrink without pre_terminate: got %v, want 0", got)
+	}
+}
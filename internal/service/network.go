@@ -1007,7 +1007,7 @@ type GetRegionsRequest struct {
 
 func GetRegions(ctx context.Context, req GetRegionsRequest) ([]cloud.Region, error) {
 	ak := getFirstAk(req.Account, req.Provider)
-	p, err := getProvider(req.Provider, ak, DefaultRegion)
+	p, err := getProvider(req.Provider, ak, getDefaultRegion(req.Provider))
 	if err != nil {
 		return nil, err
 	}
@@ -1018,6 +1018,13 @@ func GetRegions(ctx context.Context, req GetRegionsRequest) ([]cloud.Region, err
 	return regions.Regions, nil
 }
 
+func getDefaultRegion(provider string) string {
+	if region := cloud.GetDefaultRegion(provider); region != "" {
+		return region
+	}
+	return DefaultRegion
+}
+
 type GetZonesRequest struct {
 	Provider string
 	RegionId string
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+// PlanCheck 扩缩容预检的一项检查, Name 为 constants.PlanCheck*, 与可用区无关的检查 ZoneId 为空
+type PlanCheck struct {
+	Name    string
+	ZoneId  string
+	Result  string
+	Message string
+}
+
+// ExpandZonePlan 扩容计划中分配到一个可用区的实例
+type ExpandZonePlan struct {
+	ZoneId       string
+	SubnetId     string
+	InstanceType string
+	// Candidates 库存或配额不足时依次换用的规格, 第一个为 InstanceType
+	Candidates []string
+	Count      int
+	// AvailableIpCount 子网当前的可用 IP 数量, 查询失败时为 -1
+	AvailableIpCount int
+}
+
+// ExpandPlan 扩容计划, 按与实际扩容相同的规则分配可用区和规格
+type ExpandPlan struct {
+	ClusterName string
+	Count       int
+	Zones       []ExpandZonePlan
+	Checks      []PlanCheck
+}
+
+// ShrinkPlan 缩容计划, Instances 为按集群终止策略从当前实例中挑选的待释放实例
+type ShrinkPlan struct {
+	ClusterName string
+	Count       int
+	// TerminationPolicies 按数量缩容时使用的终止策略
+	TerminationPolicies []string
+	Instances           []model.Instance
+	// NotFoundIps 指定 IP 缩容时不属于集群活跃实例的 IP
+	NotFoundIps []string
+	Checks      []PlanCheck
+}
+
+// PlanFeasible 所有检查都没有失败
+func PlanFeasible(checks []PlanCheck) bool {
+	for _, check := range checks {
+		if check.Result == constants.PlanCheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// PlanExpand 计算扩容 num 台实例在各可用区的分配, 并检查规格是否在可用区售卖、子网 IP 是否足够,
+// 云厂商支持预检时检查库存和配额. 不创建任务和实例
+func PlanExpand(ctx context.Context, clusterName string, num int) (*ExpandPlan, error) {
+	c, err := clusterInfoByName(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	params, err := generateParams(c, nil)
+	if err != nil {
+		return nil, err
+	}
+	zones := clusterZones(c)
+	candidates := zoneInstanceTypes(c, zones)
+	plan := planZones(zones, zoneInstanceCount(c), num)
+	res := &ExpandPlan{ClusterName: c.Name, Count: num, Zones: make([]ExpandZonePlan, 0), Checks: make([]PlanCheck, 0)}
+	for i, zone := range zones {
+		if plan[i] == 0 {
+			continue
+		}
+		zonePlan := ExpandZonePlan{
+			ZoneId:     zone.ZoneId,
+			SubnetId:   zone.SubnetId,
+			Candidates: candidates[zone.ZoneId],
+			Count:      plan[i],
+		}
+		if len(zonePlan.Candidates) > 0 {
+			zonePlan.InstanceType = zonePlan.Candidates[0]
+		}
+		ipCheck, available := checkSubnetIp(provider, zonePlan)
+		zonePlan.AvailableIpCount = available
+		res.Checks = append(res.Checks, checkInstanceTypeZone(ctx, c, zonePlan), ipCheck, checkCloudDryRun(provider, params, zonePlan))
+		res.Zones = append(res.Zones, zonePlan)
+	}
+	return res, nil
+}
+
+// checkInstanceTypeZone 按 instance_type 表检查规格是否在可用区售卖
+func checkInstanceTypeZone(ctx context.Context, c *types.ClusterInfo, zonePlan ExpandZonePlan) PlanCheck {
+	check := PlanCheck{Name: constants.PlanCheckInstanceType, ZoneId: zonePlan.ZoneId}
+	zones, err := model.GetInstanceTypeZones(ctx, c.Provider, c.RegionId, zonePlan.InstanceType)
+	if err != nil {
+		return failedCheck(check, "query instance type %s error: %v", zonePlan.InstanceType, err)
+	}
+	for _, zoneId := range zones {
+		if zoneId == zonePlan.ZoneId {
+			return passedCheck(check, "instance type %s is available in zone %s", zonePlan.InstanceType, zonePlan.ZoneId)
+		}
+	}
+	return failedCheck(check, "instance type %s is not available in zone %s", zonePlan.InstanceType, zonePlan.ZoneId)
+}
+
+// checkSubnetIp 检查子网剩余 IP 是否足够, 返回子网当前的可用 IP 数量
+func checkSubnetIp(provider cloud.Provider, zonePlan ExpandZonePlan) (PlanCheck, int) {
+	check := PlanCheck{Name: constants.PlanCheckSubnetIp, ZoneId: zonePlan.ZoneId}
+	resp, err := provider.GetSwitch(cloud.GetSwitchRequest{SwitchId: zonePlan.SubnetId})
+	if err != nil {
+		return failedCheck(check, "get subnet %s error: %v", zonePlan.SubnetId, err), -1
+	}
+	available := resp.Switch.AvailableIpAddressCount
+	if available < zonePlan.Count {
+		return failedCheck(check, "subnet %s has %d available ips, need %d", zonePlan.SubnetId, available, zonePlan.Count), available
+	}
+	return passedCheck(check, "subnet %s has %d available ips, need %d", zonePlan.SubnetId, available, zonePlan.Count), available
+}
+
+// checkCloudDryRun 调用云厂商的预检接口检查库存和配额, 单次预检数量与实际扩容一样不超过 constants.BatchMax
+func checkCloudDryRun(provider cloud.Provider, params cloud.Params, zonePlan ExpandZonePlan) PlanCheck {
+	check := PlanCheck{Name: constants.PlanCheckCloudDryRun, ZoneId: zonePlan.ZoneId}
+	dryRunner, ok := provider.(cloud.DryRunCreator)
+	if !ok {
+		return skippedCheck(check, "provider %s does not support dry run", provider.ProviderType())
+	}
+	network := *params.Network
+	network.SubnetId = zonePlan.SubnetId
+	params.Network = &network
+	params.Zone = zonePlan.ZoneId
+	params.InstanceType = zonePlan.InstanceType
+	num := zonePlan.Count
+	if num > constants.BatchMax {
+		num = constants.BatchMax
+	}
+	err := dryRunner.DryRunCreate(params, num)
+	switch {
+	case err == nil:
+		return passedCheck(check, "dry run creating %d %s passed", num, zonePlan.InstanceType)
+	case cloud.IsInsufficientStock(err):
+		return failedCheck(check, "insufficient stock of %s: %v", zonePlan.InstanceType, err)
+	case cloud.IsQuotaExceeded(err):
+		return failedCheck(check, "quota exceeded: %v", err)
+	default:
+		return failedCheck(check, "dry run error: %v", err)
+	}
+}
+
+// PlanShrink 按与实际缩容相同的规则挑选待释放的实例, ips 不为空时按 IP 缩容. 不创建任务和释放实例
+func PlanShrink(ctx context.Context, clusterName string, num int, ips []string) (*ShrinkPlan, error) {
+	c, err := clusterInfoByName(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	activeInstances, err := model.GetActiveInstancesByClusterName(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	res := &ShrinkPlan{ClusterName: c.Name, Count: num, NotFoundIps: make([]string, 0), Checks: make([]PlanCheck, 0)}
+	countCheck := PlanCheck{Name: constants.PlanCheckInstanceCount}
+	if len(ips) > 0 {
+		res.Instances, res.NotFoundIps = instancesByIps(activeInstances, ips)
+		if len(ips) != num {
+			countCheck = failedCheck(countCheck, "%d ips do not match count %d", len(ips), num)
+		} else {
+			countCheck = passedCheck(countCheck, "%d instances found, %d ips not found", len(res.Instances), len(res.NotFoundIps))
+		}
+	} else {
+		res.Instances, res.TerminationPolicies = selectShrinkVictims(c, unprotectedInstances(activeInstances), num)
+		if len(res.Instances) < num {
+			countCheck = failedCheck(countCheck, "cluster has only %d unprotected active instances, need %d", len(res.Instances), num)
+		} else {
+			countCheck = passedCheck(countCheck, "%d of %d active instances will be released", num, len(activeInstances))
+		}
+	}
+	res.Checks = append(res.Checks, countCheck, checkShrinkProtected(res.Instances), checkShrinkPrePaid(c, res.Instances))
+	return res, nil
+}
+
+// checkShrinkProtected 指定 IP 缩容时待释放的实例中有开启缩容保护的实例会被拒绝
+func checkShrinkProtected(instances []model.Instance) PlanCheck {
+	check := PlanCheck{Name: constants.PlanCheckProtected}
+	if err := checkProtectedInstances(instances); err != nil {
+		return failedCheck(check, "%v", err)
+	}
+	return passedCheck(check, "no protected instances")
+}
+
+// checkShrinkPrePaid 待释放的实例中有包年包月实例时整个缩容会被拒绝
+func checkShrinkPrePaid(c *types.ClusterInfo, instances []model.Instance) PlanCheck {
+	check := PlanCheck{Name: constants.PlanCheckPrePaid}
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	err := checkPrePaidInstances(c, ids)
+	if errors.Is(err, ErrPrePaidInstances) {
+		return failedCheck(check, "%v", err)
+	}
+	if err != nil {
+		return failedCheck(check, "get instances from cloud error: %v", err)
+	}
+	return passedCheck(check, "no prepaid instances")
+}
+
+func clusterInfoByName(ctx context.Context, clusterName string) (*types.ClusterInfo, error) {
+	cluster, err := model.GetByClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := GetClusterTagsByClusterName(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertToClusterInfo(cluster, tags)
+}
+
+func passedCheck(check PlanCheck, format string, args ...interface{}) PlanCheck {
+	check.Result = constants.PlanCheckPassed
+	check.Message = fmt.Sprintf(format, args...)
+	return check
+}
+
+func failedCheck(check PlanCheck, format string, args ...interface{}) PlanCheck {
+	check.Result = constants.PlanCheckFailed
+	check.Message = fmt.Sprintf(format, args...)
+	return check
+}
+
+func skippedCheck(check PlanCheck, format string, args ...interface{}) PlanCheck {
+	check.Result = constants.PlanCheckSkipped
+	check.Message = fmt.Sprintf(format, args...)
+	return check
+}
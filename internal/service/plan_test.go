@@ -0,0 +1,65 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/cloud/fake"
+)
+
+func TestInstancesByIps(t *testing.T) {
+	active := []model.Instance{{InstanceId: "i-1", IpInner: "10.0.0.1"}, {InstanceId: "i-2", IpInner: "10.0.0.2"}}
+	instances, notExist := instancesByIps(active, []string{"10.0.0.2", "10.0.0.9"})
+	if len(instances) != 1 || instances[0].InstanceId != "i-2" {
+		t.Errorf("instances got %v", instances)
+	}
+	if !reflect.DeepEqual(notExist, []string{"10.0.0.9"}) {
+		t.Errorf("not exist ips got %v", notExist)
+	}
+}
+
+func TestPlanFeasible(t *testing.T) {
+	checks := []PlanCheck{{Result: constants.PlanCheckPassed}, {Result: constants.PlanCheckSkipped}}
+	if !PlanFeasible(checks) {
+		t.Errorf("passed and skipped checks should be feasible")
+	}
+	if PlanFeasible(append(checks, PlanCheck{Result: constants.PlanCheckFailed})) {
+		t.Errorf("failed check should not be feasible")
+	}
+}
+
+func TestExpandPlanChecks(t *testing.T) {
+	t.Cleanup(fake.Reset)
+	p := fake.New("ak", "sk", fake.DefaultRegion)
+	vpcRes, _ := p.CreateVPC(cloud.CreateVpcRequest{RegionId: fake.DefaultRegion, CidrBlock: "10.0.0.0/16"})
+	swRes, err := p.CreateSwitch(cloud.CreateSwitchRequest{RegionId: fake.DefaultRegion, VpcId: vpcRes.VpcId, CidrBlock: "10.0.1.0/29"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zonePlan := ExpandZonePlan{ZoneId: "zone-a", SubnetId: swRes.SwitchId, InstanceType: "t1", Count: 3}
+	if check, available := checkSubnetIp(p, zonePlan); check.Result != constants.PlanCheckPassed || available < 3 {
+		t.Errorf("subnet check got %+v, %d", check, available)
+	}
+	zonePlan.Count = 100
+	if check, _ := checkSubnetIp(p, zonePlan); check.Result != constants.PlanCheckFailed {
+		t.Errorf("subnet check with too many instances got %+v", check)
+	}
+	if check, available := checkSubnetIp(p, ExpandZonePlan{SubnetId: "vsw-none"}); check.Result != constants.PlanCheckFailed || available != -1 {
+		t.Errorf("subnet check with unknown subnet got %+v, %d", check, available)
+	}
+
+	params := cloud.Params{Network: &cloud.Network{}, Region: fake.DefaultRegion}
+	if check := checkCloudDryRun(p, params, zonePlan); check.Result != constants.PlanCheckPassed {
+		t.Errorf("dry run got %+v", check)
+	}
+	p.SetSoldOut("zone-a", "t1", true)
+	if check := checkCloudDryRun(p, params, zonePlan); check.Result != constants.PlanCheckFailed {
+		t.Errorf("dry run in sold out zone got %+v", check)
+	}
+	if instances, _ := p.GetInstancesByTags(fake.DefaultRegion, nil); len(instances) != 0 {
+		t.Errorf("dry run created %d instances", len(instances))
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/galaxy-future/BridgX/config"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+var (
+	// ErrProtectedInstances 指定 IP 缩容时包含开启了缩容保护的实例
+	ErrProtectedInstances = errors.New("protected instances can not be released, unprotect them first")
+	// ErrProtectedShortfall 按数量缩容时开启了缩容保护的实例不释放, 可释放的实例不足
+	ErrProtectedShortfall = errors.New("not enough unprotected instances to release")
+	// ErrInstanceNotFound 实例不存在或已经释放
+	ErrInstanceNotFound = errors.New("instances not found or already deleted")
+)
+
+// SetInstancesProtected 开启或关闭实例的缩容保护, 有实例不存在时不做修改
+func SetInstancesProtected(ctx context.Context, instanceIds []string, protected bool) error {
+	instances, err := model.GetActiveInstancesByInstanceIds(ctx, instanceIds)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		found[instance.InstanceId] = true
+	}
+	missing := make([]string, 0)
+	for _, id := range instanceIds {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", ErrInstanceNotFound, missing)
+	}
+	return model.SetInstancesProtected(ctx, instanceIds, protected)
+}
+
+// unprotectedInstances 去掉开启了缩容保护的实例
+func unprotectedInstances(instances []model.Instance) []model.Instance {
+	res := make([]model.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if !instance.Protected {
+			res = append(res, instance)
+		}
+	}
+	return res
+}
+
+// checkShrinkShortfall 挑选出的实例少于 num 时返回 ErrProtectedShortfall, 说明跳过的受保护实例数量
+func checkShrinkShortfall(active []model.Instance, selected, num int) error {
+	if selected >= num {
+		return nil
+	}
+	return fmt.Errorf("%w: released %d of %d instances, %d protected instances skipped",
+		ErrProtectedShortfall, selected, num, len(active)-len(unprotectedInstances(active)))
+}
+
+// checkProtectedInstances 指定释放的实例中有开启缩容保护的实例时返回 ErrProtectedInstances
+func checkProtectedInstances(instances []model.Instance) error {
+	protectedIds := make([]string, 0)
+	for _, instance := range instances {
+		if instance.Protected {
+			protectedIds = append(protectedIds, instance.InstanceId)
+		}
+	}
+	if len(protectedIds) > 0 {
+		return fmt.Errorf("%w: %v", ErrProtectedInstances, protectedIds)
+	}
+	return nil
+}
+
+// protectionTag 云上实例的保护标签, 未配置时使用默认值
+func protectionTag() cloud.Tag {
+	tag := cloud.Tag{Key: constants.DefaultProtectionTagKey, Value: constants.DefaultProtectionTagValue}
+	if config.GlobalConfig == nil {
+		return tag
+	}
+	if key := config.GlobalConfig.ProtectionCfg.TagKey; key != "" {
+		tag.Key = key
+	}
+	if value := config.GlobalConfig.ProtectionCfg.TagValue; value != "" {
+		tag.Value = value
+	}
+	return tag
+}
+
+// cloudProtectedInstanceIds 集群在云上带有保护标签的实例
+func cloudProtectedInstanceIds(c *types.ClusterInfo) (map[string]bool, error) {
+	instances, err := GetInstanceByTag(c, []cloud.Tag{{Key: cloud.ClusterName, Value: c.Name}, protectionTag()})
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		res[instance.Id] = true
+	}
+	return res, nil
+}
+
+func excludeInstanceIds(instanceIds []string, excluded map[string]bool) []string {
+	res := make([]string, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		if !excluded[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
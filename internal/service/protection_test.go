@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestUnprotectedInstances(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1"},
+		{InstanceId: "i-2", Protected: true},
+		{InstanceId: "i-3"},
+	}
+	if ids := victimIds(unprotectedInstances(instances)); !reflect.DeepEqual(ids, []string{"i-1", "i-3"}) {
+		t.Errorf("unprotected instances got %v", ids)
+	}
+	if err := checkProtectedInstances(instances[:1]); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if err := checkProtectedInstances(instances); !errors.Is(err, ErrProtectedInstances) {
+		t.Errorf("want ErrProtectedInstances, got %v", err)
+	}
+}
+
+func TestExcludeInstanceIds(t *testing.T) {
+	ids := excludeInstanceIds([]string{"i-1", "i-2", "i-3"}, map[string]bool{"i-2": true})
+	if !reflect.DeepEqual(ids, []string{"i-1", "i-3"}) {
+		t.Errorf("exclude instance ids got %v", ids)
+	}
+}
+
+func TestCheckShrinkShortfall(t *testing.T) {
+	active := []model.Instance{
+		{InstanceId: "i-1"},
+		{InstanceId: "i-2", Protected: true},
+		{InstanceId: "i-3", Protected: true},
+	}
+	if err := checkShrinkShortfall(active, 1, 1); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	err := checkShrinkShortfall(active, 1, 3)
+	if !errors.Is(err, ErrProtectedShortfall) {
+		t.Fatalf("want ErrProtectedShortfall, got %v", err)
+	}
+	if want := "not enough unprotected instances to release: released 1 of 3 instances, 2 protected instances skipped"; err.Error() != want {
+		t.Errorf("message got %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+// OutdatedInstances 规格或镜像不是集群当前配置的运行中实例, 按创建顺序排列, 开启了缩容保护的实例单独返回, 不替换
+func OutdatedInstances(c *types.ClusterInfo) (outdated, protected []model.Instance, err error) {
+	instances, err := model.GetActiveInstancesByClusterName(c.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	images, err := queryInstanceImages(c, instances)
+	if err != nil {
+		return nil, nil, err
+	}
+	facts := terminationFacts{image: c.Image, instanceTypes: clusterInstanceTypes(c), images: images}
+	outdated, protected = outdatedInstances(instances, facts)
+	return outdated, protected, nil
+}
+
+func outdatedInstances(instances []model.Instance, facts terminationFacts) (outdated, protected []model.Instance) {
+	outdated = make([]model.Instance, 0)
+	protected = make([]model.Instance, 0)
+	for _, instance := range instances {
+		if instance.Status != constants.Running || !facts.deprecated(instance) {
+			continue
+		}
+		if instance.Protected {
+			protected = append(protected, instance)
+		} else {
+			outdated = append(outdated, instance)
+		}
+	}
+	sort.SliceStable(outdated, func(i, j int) bool {
+		return outdated[i].Id < outdated[j].Id
+	})
+	return outdated, protected
+}
+
+// rollingBatchSize 本批替换的实例数量, 不超过 batch_size 和 max_surge + max_unavailable
+func rollingBatchSize(info *model.RollingUpdateTaskInfo, remaining int) int {
+	size := info.BatchSize
+	if limit := info.MaxSurge + info.MaxUnavailable; size > limit {
+		size = limit
+	}
+	if size > remaining {
+		size = remaining
+	}
+	return size
+}
+
+// rollingUnavailable 扩容前先摘除流量的旧实例数量, 其余旧实例在新实例通过健康检查后再摘除,
+// 这样 working_ips 最多比原来多 max_surge 台, 最多少 max_unavailable 台
+func rollingUnavailable(size, maxSurge int) int {
+	if size <= maxSurge {
+		return 0
+	}
+	return size - maxSurge
+}
+
+// RollingUpdateCluster 分批替换 outdated 中的实例: 先扩容同样数量的新实例并等待通过健康检查, 再按 IP 释放旧实例.
+// 某一批失败或任务被取消时释放这一批的新实例并恢复旧实例, 之前已完成的批次不回滚. 任务被暂停时在批次之间等待
+func RollingUpdateCluster(ctx context.Context, c *types.ClusterInfo, info *model.RollingUpdateTaskInfo, outdated []model.Instance, taskId int64) (res model.RollingUpdateTaskRes, err error) {
+	res.ReplacedInstanceIdList = make([]string, 0, len(outdated))
+	res.NewInstanceIdList = make([]string, 0, len(outdated))
+	if err = checkPrePaidInstances(c, modelInstanceIds(outdated)); err != nil {
+		return res, err
+	}
+	for batchNo, start := 1, 0; start < len(outdated); batchNo++ {
+		if err = waitTaskResumed(ctx, taskId); err != nil {
+			return res, err
+		}
+		size := rollingBatchSize(info, len(outdated)-start)
+		batch, err := stillOutdated(ctx, outdated[start:start+size])
+		if err != nil {
+			return res, err
+		}
+		start += size
+		if len(batch) == 0 {
+			continue
+		}
+		newIds, err := replaceBatch(ctx, c, batch, rollingUnavailable(len(batch), info.MaxSurge), taskId, batchNo)
+		if err != nil {
+			return res, fmt.Errorf("batch %d rolled back: %w", batchNo, err)
+		}
+		res.ReplacedInstanceIdList = append(res.ReplacedInstanceIdList, modelInstanceIds(batch)...)
+		res.NewInstanceIdList = append(res.NewInstanceIdList, newIds...)
+	}
+	return res, nil
+}
+
+// stillOutdated 去掉任务执行期间已被释放或开启了缩容保护的实例
+func stillOutdated(ctx context.Context, instances []model.Instance) ([]model.Instance, error) {
+	active, err := model.GetActiveInstancesByInstanceIds(ctx, modelInstanceIds(instances))
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]model.Instance, len(active))
+	for _, instance := range active {
+		current[instance.InstanceId] = instance
+	}
+	res := make([]model.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if cur, ok := current[instance.InstanceId]; ok && cur.Status == constants.Running && !cur.Protected {
+			res = append(res, cur)
+		}
+	}
+	return res, nil
+}
+
+// replaceBatch 替换一批旧实例, 返回新实例 ID. 返回错误时这一批已经回滚, 之前批次创建的新实例保留
+func replaceBatch(ctx context.Context, c *types.ClusterInfo, batch []model.Instance, unavailable int, taskId int64, batchNo int) ([]string, error) {
+	oldIds := modelInstanceIds(batch)
+	RecordTaskEvent(taskId, constants.TaskEventRollingBatch, "batch %d replacing %d instances %v, %d removed from working ips before launching",
+		batchNo, len(oldIds), oldIds, unavailable)
+	var drained drainedInstances
+	if unavailable > 0 {
+		d, err := drainInstances(ctx, c, oldIds[:unavailable], taskId)
+		if err != nil {
+			return nil, err
+		}
+		drained = drained.merge(d)
+	}
+	instances, err := ExpandCluster(ctx, c, len(oldIds), taskId)
+	newIds := cloudInstanceIds(instances)
+	if err == nil && len(instances) < len(oldIds) {
+		err = fmt.Errorf("only %d of %d new instances launched", len(instances), len(oldIds))
+	}
+	if err == nil {
+		err = waitBatchHealthy(ctx, c, instances, taskId)
+	}
+	if err == nil && unavailable < len(oldIds) {
+		var d drainedInstances
+		if d, err = drainInstances(ctx, c, oldIds[unavailable:], taskId); err == nil {
+			drained = drained.merge(d)
+		}
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = Shrink(c, oldIds)
+	}
+	if err != nil {
+		rollbackBatch(c, taskId, batchNo, drained, newIds)
+		return nil, err
+	}
+	now := time.Now()
+	err = model.BatchUpdateByInstanceIds(oldIds, model.Instance{
+		ShrinkTaskId: taskId,
+		Status:       constants.Deleted,
+		DeleteAt:     &now,
+	})
+	if err != nil {
+		logs.Logger.Errorf("[replaceBatch] update released instances error. cluster name: %s, error: %v", c.Name, err)
+	}
+	_ = publishShrinkConfig(c.Name)
+	ips := make([]string, 0, len(batch))
+	for _, instance := range batch {
+		ips = append(ips, instance.IpInner)
+	}
+	RecordTaskEvent(taskId, constants.TaskEventReleased, "batch %d released %d old instances %v", batchNo, len(oldIds), ips)
+	return newIds, nil
+}
+
+// waitBatchHealthy 集群配置了健康检查时, 等待新实例在 grace_period_seconds 内都通过一次检查
+func waitBatchHealthy(ctx context.Context, c *types.ClusterInfo, instances []cloud.Instance, taskId int64) error {
+	if c.HealthCheckConfig == nil || len(instances) == 0 {
+		return nil
+	}
+	cfg := healthCheckDefaults(*c.HealthCheckConfig)
+	pending := make([]model.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if cfg.Type != constants.HealthCheckCloud && instance.IpInner == "" {
+			return fmt.Errorf("new instance %s has no inner ip", instance.Id)
+		}
+		pending = append(pending, model.Instance{InstanceId: instance.Id, IpInner: instance.IpInner})
+	}
+	deadline := time.Now().Add(time.Duration(cfg.GracePeriodSeconds) * time.Second)
+	message := ""
+	for {
+		probes, err := probeInstances(ctx, c, cfg, pending)
+		if err != nil {
+			message = err.Error()
+		} else {
+			pending, message = failedInstances(pending, probes)
+		}
+		if len(pending) == 0 {
+			RecordTaskEvent(taskId, constants.TaskEventHealthCheck, "%d new instances passed %s check", len(instances), cfg.Type)
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%d new instances failed %s check in %d seconds: %s", len(pending), cfg.Type, cfg.GracePeriodSeconds, message)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(constants.RollingUpdateHealthCheckInterval * time.Second):
+		}
+	}
+}
+
+// failedInstances 检查失败的实例和其中一个失败原因
+func failedInstances(instances []model.Instance, probes []healthProbe) ([]model.Instance, string) {
+	failed := make([]model.Instance, 0)
+	message := ""
+	for i, instance := range instances {
+		if probes[i].healthy {
+			continue
+		}
+		failed = append(failed, instance)
+		message = fmt.Sprintf("%s: %s", instance.InstanceId, probes[i].message)
+	}
+	return failed, message
+}
+
+// rollbackBatch 释放这一批创建的新实例并把已摘除的旧实例恢复为摘除前的状态, repairTaskInstances 再释放还没有记录到 DB 的新实例
+func rollbackBatch(c *types.ClusterInfo, taskId int64, batchNo int, drained drainedInstances, newIds []string) {
+	if len(newIds) > 0 {
+		if err := Shrink(c, newIds); err != nil {
+			logs.Logger.Errorf("[rollbackBatch] release new instances error. cluster name: %s, error: %v", c.Name, err)
+		}
+		now := time.Now()
+		err := model.BatchUpdateByInstanceIds(newIds, model.Instance{Status: constants.Deleted, DeleteAt: &now})
+		if err != nil {
+			logs.Logger.Errorf("[rollbackBatch] update new instances error. cluster name: %s, error: %v", c.Name, err)
+		}
+	}
+	_ = repairTaskInstances(c, taskId, newIds)
+	if len(drained) > 0 {
+		restoreDrainingInstances(c, drained)
+	} else {
+		_ = publishShrinkConfig(c.Name)
+	}
+	RecordTaskEvent(taskId, constants.TaskEventRolledBack, "batch %d rolled back, released %d new instances and restored %d old instances",
+		batchNo, len(newIds), len(drained))
+}
+
+// waitTaskResumed 任务被暂停时在批次之间等待, 直到继续执行; 任务被取消时等待 ctx 被取消
+func waitTaskResumed(ctx context.Context, taskId int64) error {
+	for {
+		task := &model.Task{}
+		if err := model.Get(taskId, task); err == nil {
+			switch task.Status {
+			case constants.TaskStatusRunning:
+				return ctx.Err()
+			case constants.TaskStatusPaused:
+			default:
+				<-ctx.Done()
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(constants.DefaultTaskCancelCheckInterval * time.Second):
+		}
+	}
+}
+
+// ErrNoOutdatedInstances 集群中没有需要替换的实例
+var ErrNoOutdatedInstances = errors.New("all running instances already use the current image and instance type")
+
+// CheckRollingUpdate 创建滚动更新任务前检查是否有需要替换的实例
+func CheckRollingUpdate(ctx context.Context, clusterName string) error {
+	c, err := clusterInfoByName(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	outdated, _, err := OutdatedInstances(c)
+	if err != nil {
+		return err
+	}
+	if len(outdated) == 0 {
+		return ErrNoOutdatedInstances
+	}
+	return nil
+}
+
+func modelInstanceIds(instances []model.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	return ids
+}
+
+func cloudInstanceIds(instances []cloud.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.Id)
+	}
+	return ids
+}
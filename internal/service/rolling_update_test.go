@@ -0,0 +1,70 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestRollingBatch(t *testing.T) {
+	cases := []struct {
+		info            model.RollingUpdateTaskInfo
+		remaining       int
+		wantSize        int
+		wantUnavailable int
+	}{
+		{model.RollingUpdateTaskInfo{BatchSize: 1, MaxSurge: 1}, 5, 1, 0},
+		{model.RollingUpdateTaskInfo{BatchSize: 4, MaxSurge: 4}, 3, 3, 0},
+		{model.RollingUpdateTaskInfo{BatchSize: 4, MaxSurge: 1, MaxUnavailable: 1}, 10, 2, 1},
+		{model.RollingUpdateTaskInfo{BatchSize: 3, MaxSurge: 0, MaxUnavailable: 5}, 10, 3, 3},
+		{model.RollingUpdateTaskInfo{BatchSize: 3, MaxSurge: 2, MaxUnavailable: 2}, 10, 3, 1},
+	}
+	for _, cs := range cases {
+		info := cs.info
+		size := rollingBatchSize(&info, cs.remaining)
+		unavailable := rollingUnavailable(size, info.MaxSurge)
+		if size != cs.wantSize || unavailable != cs.wantUnavailable {
+			t.Errorf("%+v remaining %d got size %d unavailable %d, want %d %d",
+				info, cs.remaining, size, unavailable, cs.wantSize, cs.wantUnavailable)
+		}
+	}
+}
+
+func TestOutdatedInstances(t *testing.T) {
+	instances := []model.Instance{
+		{Id: 5, InstanceId: "i-5", Status: constants.Running, InstanceType: "t0"},
+		{Id: 1, InstanceId: "i-1", Status: constants.Running, InstanceType: "t1"},
+		{Id: 2, InstanceId: "i-2", Status: constants.Running, InstanceType: "t1"},
+		{Id: 3, InstanceId: "i-3", Status: constants.Running, InstanceType: "t0", Protected: true},
+		{Id: 4, InstanceId: "i-4", Status: constants.Starting, InstanceType: "t0"},
+	}
+	facts := terminationFacts{
+		image:         "img-new",
+		instanceTypes: map[string]bool{"t1": true},
+		images:        map[string]string{"i-1": "img-old", "i-2": "img-new", "i-5": "img-new"},
+	}
+	outdated, protected := outdatedInstances(instances, facts)
+	if ids := modelInstanceIds(outdated); !reflect.DeepEqual(ids, []string{"i-1", "i-5"}) {
+		t.Errorf("outdated got %v", ids)
+	}
+	if ids := modelInstanceIds(protected); !reflect.DeepEqual(ids, []string{"i-3"}) {
+		t.Errorf("protected got %v", ids)
+	}
+}
+
+func TestFailedInstances(t *testing.T) {
+	instances := []model.Instance{{InstanceId: "i-1"}, {InstanceId: "i-2"}, {InstanceId: "i-3"}}
+	probes := []healthProbe{{healthy: true}, {message: "connection refused"}, {healthy: true}}
+	failed, message := failedInstances(instances, probes)
+	if ids := modelInstanceIds(failed); !reflect.DeepEqual(ids, []string{"i-2"}) {
+		t.Errorf("failed got %v", ids)
+	}
+	if message != "i-2: connection refused" {
+		t.Errorf("message got %q", message)
+	}
+	if failed, _ = failedInstances(instances[:1], probes[:1]); len(failed) != 0 {
+		t.Errorf("want no failed instance, got %v", modelInstanceIds(failed))
+	}
+}
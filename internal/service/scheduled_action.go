@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/tovenja/cron/v3"
+)
+
+// ErrScheduledActionNotFound 定时任务不存在或已删除
+var ErrScheduledActionNotFound = errors.New("scheduled action not found")
+
+// ScheduledActionSpec 带时区的 cron 表达式, 供调度器注册定时任务
+func ScheduledActionSpec(action *model.ScheduledAction) string {
+	return fmt.Sprintf("CRON_TZ=%s %s", action.Timezone, action.Cron)
+}
+
+// NextScheduledActionRun 定时任务在 now 之后的下一次触发时间, 表达式非法或已暂停时返回 nil
+func NextScheduledActionRun(action *model.ScheduledAction, now time.Time) *time.Time {
+	if action.Status != constants.ScheduledActionStatusEnable {
+		return nil
+	}
+	schedule, err := cron.ParseStandard(ScheduledActionSpec(action))
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(now)
+	return &next
+}
+
+func checkScheduledAction(action *model.ScheduledAction) error {
+	if action.ActionName == "" {
+		return errors.New("missing action name")
+	}
+	if action.ExpectCount < 0 {
+		return errors.New("expect count must not be negative")
+	}
+	if action.Timezone == "" {
+		action.Timezone = constants.DefaultScheduledActionTimezone
+	}
+	if _, err := time.LoadLocation(action.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %s", action.Timezone)
+	}
+	action.Cron = strings.TrimSpace(action.Cron)
+	if strings.HasPrefix(action.Cron, "TZ=") || strings.HasPrefix(action.Cron, "CRON_TZ=") {
+		return errors.New("use timezone instead of TZ in cron")
+	}
+	//同一分钟内的多次触发会被当作同一次执行
+	if strings.HasPrefix(action.Cron, "@every") {
+		return errors.New("@every is not supported in cron")
+	}
+	if _, err := cron.ParseStandard(ScheduledActionSpec(action)); err != nil {
+		return fmt.Errorf("invalid cron: %w", err)
+	}
+	return nil
+}
+
+func CreateScheduledAction(ctx context.Context, action *model.ScheduledAction) error {
+	if _, err := model.GetByClusterName(action.ClusterName); err != nil {
+		return err
+	}
+	if err := checkScheduledAction(action); err != nil {
+		return err
+	}
+	now := time.Now()
+	action.Status = constants.ScheduledActionStatusEnable
+	action.CreateAt = &now
+	action.UpdateAt = &now
+	return model.Create(action)
+}
+
+func ListScheduledActions(ctx context.Context, clusterName string) ([]model.ScheduledAction, error) {
+	return model.GetScheduledActions(ctx, clusterName)
+}
+
+// SetScheduledActionStatus 暂停或恢复定时任务. 调度器每轮加载时移除暂停的定时任务、注册恢复的定时任务,
+// 触发时也会检查状态, 暂停后不会再执行
+func SetScheduledActionStatus(ctx context.Context, id int64, status, updateBy string) error {
+	ok, err := model.UpdateScheduledActionStatus(ctx, id, status, updateBy, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrScheduledActionNotFound
+	}
+	return nil
+}
+
+// DeleteScheduledAction 删除定时任务, 调度器下一轮加载时移除
+func DeleteScheduledAction(ctx context.Context, id int64) error {
+	ok, err := model.DeleteScheduledAction(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrScheduledActionNotFound
+	}
+	return nil
+}
+
+// RunScheduledAction 执行一次定时任务, 同一触发时间只会被一个调度器执行
+func RunScheduledAction(id int64, fireAt time.Time) error {
+	ctx := context.Background()
+	var action model.ScheduledAction
+	if err := model.Get(id, &action); err != nil {
+		return err
+	}
+	if action.Status != constants.ScheduledActionStatusEnable {
+		return nil
+	}
+	claimed, err := model.ClaimScheduledActionRun(ctx, id, fireAt)
+	if err != nil || !claimed {
+		return err
+	}
+	logs.Logger.Infof("[RunScheduledAction] cluster: %s, action: %s, expect count: %d", action.ClusterName, action.ActionName, action.ExpectCount)
+	return SetExpectCount(ctx, action.ClusterName, action.ExpectCount, true)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestCheckScheduledAction(t *testing.T) {
+	action := &model.ScheduledAction{ActionName: "morning", Cron: " 0 8 * * 1-5 ", ExpectCount: 200}
+	if err := checkScheduledAction(action); err != nil {
+		t.Fatalf("valid action got %v", err)
+	}
+	if action.Timezone != constants.DefaultScheduledActionTimezone || action.Cron != "0 8 * * 1-5" {
+		t.Errorf("normalized action got %+v", action)
+	}
+	invalid := []model.ScheduledAction{
+		{ActionName: "a", Cron: "0 8 * *"},
+		{ActionName: "a", Cron: "0 8 * * *", Timezone: "Mars/Base"},
+		{ActionName: "a", Cron: "CRON_TZ=UTC 0 8 * * *"},
+		{ActionName: "a", Cron: "@every 1m"},
+		{ActionName: "a", Cron: "0 8 * * *", ExpectCount: -1},
+		{Cron: "0 8 * * *"},
+	}
+	for i := range invalid {
+		if err := checkScheduledAction(&invalid[i]); err == nil {
+			t.Errorf("want error for %+v", invalid[i])
+		}
+	}
+}
+
+func TestNextScheduledActionRun(t *testing.T) {
+	action := &model.ScheduledAction{Cron: "0 8 * * 1-5", Timezone: "Asia/Shanghai", Status: constants.ScheduledActionStatusEnable}
+	//2021-11-12 是周五, UTC 01:00 即上海 09:00
+	now := time.Date(2021, 11, 12, 1, 0, 0, 0, time.UTC)
+	next := NextScheduledActionRun(action, now)
+	if next == nil || !next.Equal(time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("next run got %v", next)
+	}
+	action.Status = constants.ScheduledActionStatusPaused
+	if next = NextScheduledActionRun(action, now); next != nil {
+		t.Errorf("paused action next run got %v", next)
+	}
+}
@@ -16,68 +16,463 @@ import (
 )
 
 func CreateExpandTask(ctx context.Context, clusterName string, count int, taskName string, uid int64) (int64, error) {
-	if hasUnfinishedTask(clusterName) {
-		return 0, errors.New(fmt.Sprintf("Cluster:%v has unfinished task", clusterName))
+	return createTask(ctx, constants.TaskActionExpand, clusterName, newExpandTaskInfo(clusterName, count, uid), taskName, 0)
+}
+
+func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips string, taskName string, uid int64) (int64, error) {
+	return createTask(ctx, constants.TaskActionShrink, clusterName, newShrinkTaskInfo(clusterName, count, ips, uid), taskName, 0)
+}
+
+//CreateExpandTaskWithExpectCount 手动扩容, 声明式集群同时增加期望实例数量, 否则调度器会把实例数量收敛回原来的期望值
+func CreateExpandTaskWithExpectCount(ctx context.Context, clusterName string, count int, taskName string, uid int64) (int64, error) {
+	return createTaskWithExpectDelta(ctx, constants.TaskActionExpand, clusterName, newExpandTaskInfo(clusterName, count, uid), taskName, count)
+}
+
+//CreateShrinkTaskWithExpectCount 手动缩容, 声明式集群同时减少期望实例数量
+func CreateShrinkTaskWithExpectCount(ctx context.Context, clusterName string, count int, ips string, taskName string, uid int64) (int64, error) {
+	return createTaskWithExpectDelta(ctx, constants.TaskActionShrink, clusterName, newShrinkTaskInfo(clusterName, count, ips, uid), taskName, -count)
+}
+
+func CreateRollingUpdateTask(ctx context.Context, info *model.RollingUpdateTaskInfo, taskName string) (int64, error) {
+	return createTask(ctx, constants.TaskActionRollingUpdate, info.ClusterName, newRollingUpdateTaskInfo(info, info.UserId), taskName, 0)
+}
+
+//createTask 创建排队中的任务, info 为任务参数, parentTaskId 不为 0 时为重试任务
+func createTask(ctx context.Context, action, filter string, info interface{}, taskName string, parentTaskId int64) (int64, error) {
+	task := newTask(action, filter, info, taskName, parentTaskId)
+	err := model.Create(task)
+	if err != nil {
+		return 0, err
 	}
-	info := &model.ExpandTaskInfo{
-		ClusterName:    clusterName,
-		Count:          count,
-		TaskSubmitHost: utils.PrivateIPv4(),
-		UserId:         uid,
+	RecordTaskEvent(task.Id, constants.TaskEventQueued, "task queued")
+	return task.Id, nil
+}
+
+//createTaskWithExpectDelta 创建排队中的任务, 在同一事务中调整声明式集群的期望实例数量
+func createTaskWithExpectDelta(ctx context.Context, action, clusterName string, info interface{}, taskName string, delta int) (int64, error) {
+	task := newTask(action, clusterName, info, taskName, 0)
+	err := model.CreateTaskWithExpectDelta(ctx, task, clusterName, delta)
+	if err != nil {
+		return 0, err
 	}
+	RecordTaskEvent(task.Id, constants.TaskEventQueued, "task queued")
+	return task.Id, nil
+}
+
+func newTask(action, filter string, info interface{}, taskName string, parentTaskId int64) *model.Task {
 	s, _ := jsoniter.MarshalToString(info)
 	taskId := id_generator.GetNextId()
 	task := &model.Task{
 		TaskName:      taskName,
-		TaskAction:    constants.TaskActionExpand,
-		Status:        constants.TaskStatusInit,
-		TaskFilter:    clusterName,
+		TaskAction:    action,
+		Status:        constants.TaskStatusQueued,
+		TaskFilter:    filter,
 		TaskInfo:      s,
-		SupportCancel: false,
+		SupportCancel: true,
+		ParentTaskId:  parentTaskId,
 	}
 	now := time.Now()
 	task.Id = int64(taskId)
 	task.CreateAt = &now
 	task.UpdateAt = &now
-	err := model.Create(task)
-	if err != nil {
-		return 0, err
-	}
-	return task.Id, nil
+	return task
 }
-func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips string, taskName string, uid int64) (int64, error) {
-	if hasUnfinishedTask(clusterName) {
-		return 0, errors.New(fmt.Sprintf("Cluster:%v has unfinished task", clusterName))
+
+func newExpandTaskInfo(clusterName string, count int, uid int64) *model.ExpandTaskInfo {
+	return &model.ExpandTaskInfo{
+		ClusterName:    clusterName,
+		Count:          count,
+		TaskSubmitHost: utils.PrivateIPv4(),
+		UserId:         uid,
 	}
-	info := &model.ShrinkTaskInfo{
+}
+
+func newShrinkTaskInfo(clusterName string, count int, ips string, uid int64) *model.ShrinkTaskInfo {
+	return &model.ShrinkTaskInfo{
 		ClusterName:    clusterName,
 		Count:          count,
 		IPs:            ips,
 		TaskSubmitHost: utils.PrivateIPv4(),
+		UserId:         uid,
 	}
-	s, _ := jsoniter.MarshalToString(info)
-	taskId := id_generator.GetNextId()
-	task := &model.Task{
-		TaskName:      taskName,
-		TaskAction:    constants.TaskActionShrink,
-		Status:        constants.TaskStatusInit,
-		TaskFilter:    clusterName,
-		TaskInfo:      s,
-		SupportCancel: false,
+}
+
+//newRollingUpdateTaskInfo 复制滚动更新参数, 重试时沿用原任务的参数
+func newRollingUpdateTaskInfo(info *model.RollingUpdateTaskInfo, uid int64) *model.RollingUpdateTaskInfo {
+	res := *info
+	res.UserId = uid
+	res.TaskSubmitHost = utils.PrivateIPv4()
+	res.TaskExecHost = ""
+	return &res
+}
+
+//ErrTaskNotCancellable 任务已结束、正在取消或不支持取消
+var ErrTaskNotCancellable = errors.New("task can not be cancelled")
+
+//CancelTask 取消任务, 排队中或还未执行的任务直接取消, 执行中的任务标记为取消中,
+//由执行任务的调度器在批次之间停止并释放已创建的实例, 返回取消后的任务状态
+func CancelTask(ctx context.Context, taskId int64) (string, error) {
+	task := &model.Task{}
+	if err := model.Get(taskId, task); err != nil {
+		return "", err
+	}
+	if !task.SupportCancel || cancelledStatus(task.Status) == "" {
+		return "", ErrTaskNotCancellable
 	}
 	now := time.Now()
-	task.Id = int64(taskId)
-	task.CreateAt = &now
-	task.UpdateAt = &now
-	err := model.Create(task)
+	for _, transition := range cancelTransitions {
+		updates := map[string]interface{}{
+			"status":    transition.to,
+			"update_at": now,
+		}
+		if transition.to == constants.TaskStatusCancelled {
+			updates["finish_time"] = now
+		}
+		ok, err := model.UpdateTaskIfStatus(ctx, taskId, transition.from, updates)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if transition.to == constants.TaskStatusCancelled {
+			RecordTaskEvent(taskId, constants.TaskEventFinished, "task cancelled before execution")
+		}
+		return transition.to, nil
+	}
+	return "", ErrTaskNotCancellable
+}
+
+//cancelTransitions 取消任务时依次尝试的状态转换, 还未执行的任务直接取消, 执行中的任务由调度器停止
+var cancelTransitions = []struct {
+	from []string
+	to   string
+}{
+	{[]string{constants.TaskStatusQueued, constants.TaskStatusInit}, constants.TaskStatusCancelled},
+	{[]string{constants.TaskStatusRunning, constants.TaskStatusPaused}, constants.TaskStatusCancelling},
+}
+
+//cancelledStatus 任务取消后的状态, 不能取消时返回空字符串
+func cancelledStatus(status string) string {
+	for _, transition := range cancelTransitions {
+		for _, from := range transition.from {
+			if from == status {
+				return transition.to
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	//ErrTaskNotPausable 只有执行中的滚动更新任务可以暂停
+	ErrTaskNotPausable = errors.New("only RUNNING rolling update task can be paused")
+	//ErrTaskNotPaused 只有暂停中的任务可以继续执行
+	ErrTaskNotPaused = errors.New("only PAUSED task can be resumed")
+)
+
+//PauseTask 暂停滚动更新任务, 正在替换的批次完成后才停止, 暂停期间仍然可以取消
+func PauseTask(ctx context.Context, taskId int64) error {
+	task := &model.Task{}
+	if err := model.Get(taskId, task); err != nil {
+		return err
+	}
+	if task.TaskAction != constants.TaskActionRollingUpdate {
+		return ErrTaskNotPausable
+	}
+	ok, err := model.UpdateTaskIfStatus(ctx, taskId, []string{constants.TaskStatusRunning}, map[string]interface{}{
+		"status":    constants.TaskStatusPaused,
+		"update_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTaskNotPausable
+	}
+	RecordTaskEvent(taskId, constants.TaskEventPaused, "task paused, it stops after the current batch")
+	return nil
+}
+
+//ResumeTask 继续执行暂停的任务
+func ResumeTask(ctx context.Context, taskId int64) error {
+	ok, err := model.UpdateTaskIfStatus(ctx, taskId, []string{constants.TaskStatusPaused}, map[string]interface{}{
+		"status":    constants.TaskStatusRunning,
+		"update_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTaskNotPaused
+	}
+	RecordTaskEvent(taskId, constants.TaskEventResumed, "task resumed")
+	return nil
+}
+
+//ErrTaskNotRetryable 只有失败或部分成功的任务可以重试
+var ErrTaskNotRetryable = errors.New("only FAILED or PARTIAL_SUCCESS task can be retried")
+
+//RetryTask 按原任务还缺少的数量创建关联的重试任务, 每个任务只能被重试一次, 返回重试任务id
+func RetryTask(ctx context.Context, taskId int64, uid int64) (int64, error) {
+	task := &model.Task{}
+	if err := model.Get(taskId, task); err != nil {
+		return 0, err
+	}
+	if task.Status != constants.TaskStatusFailed && task.Status != constants.TaskStatusPartialSuccess {
+		return 0, ErrTaskNotRetryable
+	}
+	if task.RetryTaskId != 0 {
+		return 0, fmt.Errorf("task %d has been retried by task %d", taskId, task.RetryTaskId)
+	}
+	instances, err := GetInstancesByTaskId(ctx, cast.ToString(taskId), task.TaskAction)
 	if err != nil {
 		return 0, err
 	}
-	return task.Id, nil
+	filter, info, err := retryTaskInfo(task, instances, uid)
+	if err != nil {
+		return 0, err
+	}
+	retry := newTask(task.TaskAction, filter, info, task.TaskName, taskId)
+	//并发重试时由数据库保证只有一个重试任务被创建
+	ok, err := model.CreateRetryTask(ctx, retry, []string{constants.TaskStatusFailed, constants.TaskStatusPartialSuccess})
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("task %d has been retried", taskId)
+	}
+	RecordTaskEvent(retry.Id, constants.TaskEventQueued, "task queued to retry task %d", taskId)
+	return retry.Id, nil
+}
+
+//retryTaskInfo 重试任务的 task_filter 和参数, instances 为原任务已创建或已释放的实例
+func retryTaskInfo(task *model.Task, instances []model.Instance, uid int64) (string, interface{}, error) {
+	switch task.TaskAction {
+	case constants.TaskActionExpand:
+		info := &model.ExpandTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return "", nil, err
+		}
+		count := expandShortfall(info.Count, instances)
+		if count <= 0 {
+			return "", nil, errors.New("no instance left to expand")
+		}
+		return info.ClusterName, newExpandTaskInfo(info.ClusterName, count, uid), nil
+	case constants.TaskActionShrink:
+		info := &model.ShrinkTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return "", nil, err
+		}
+		count, ips := shrinkShortfall(info, instances)
+		if count <= 0 {
+			return "", nil, errors.New("no instance left to shrink")
+		}
+		return info.ClusterName, newShrinkTaskInfo(info.ClusterName, count, ips, uid), nil
+	case constants.TaskActionRollingUpdate:
+		//滚动更新执行时重新挑选还没有替换的实例, 按原参数重新执行即可
+		info := &model.RollingUpdateTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return "", nil, err
+		}
+		return info.ClusterName, newRollingUpdateTaskInfo(info, uid), nil
+	}
+	return "", nil, fmt.Errorf("unknown task action, action : %v", task.TaskAction)
+}
+
+//expandShortfall 扩容任务还缺少的实例数量, 创建失败后被修复流程释放的实例不计入, 之后被缩容释放的实例计入
+func expandShortfall(count int, instances []model.Instance) int {
+	created := 0
+	for _, instance := range instances {
+		if instance.Status != constants.Deleted || instance.ShrinkTaskId != 0 {
+			created++
+		}
+	}
+	return count - created
+}
+
+//shrinkShortfall 缩容任务还需要释放的数量, 指定 IP 缩容时只重试还没有释放的 IP
+func shrinkShortfall(info *model.ShrinkTaskInfo, deleted []model.Instance) (int, string) {
+	if info.IPs == "" || info.IPs == constants.HasNoneIP {
+		return info.Count - len(deleted), ""
+	}
+	deletedIPs := make(map[string]bool, len(deleted))
+	for _, instance := range deleted {
+		deletedIPs[instance.IpInner] = true
+	}
+	remaining := make([]string, 0)
+	for _, ip := range strings.Split(info.IPs, ",") {
+		if !deletedIPs[ip] {
+			remaining = append(remaining, ip)
+		}
+	}
+	return len(remaining), strings.Join(remaining, ",")
+}
+
+//GetTaskRetryChain 获取任务所在的重试链, 从最初的任务到最新的重试任务, 任务没有被重试过时只包含任务本身
+func GetTaskRetryChain(ctx context.Context, task *model.Task) ([]model.Task, error) {
+	chain := []model.Task{*task}
+	for cur := task; cur.ParentTaskId != 0 && len(chain) < constants.MaxTaskRetryChainLength; {
+		parent := &model.Task{}
+		if err := model.Get(cur.ParentTaskId, parent); err != nil {
+			return nil, err
+		}
+		chain = append([]model.Task{*parent}, chain...)
+		cur = parent
+	}
+	for cur := task; len(chain) < constants.MaxTaskRetryChainLength; {
+		children, err := model.GetRetryTasks(ctx, cur.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) == 0 {
+			break
+		}
+		chain = append(chain, children[0])
+		cur = &children[0]
+	}
+	return chain, nil
+}
+
+//DispatchQueuedTask 集群没有已出队的未结束任务时, 合并队首方向相反的任务并让第一个任务出队(INIT), 由 TaskMonitor 执行
+func DispatchQueuedTask(ctx context.Context, clusterName string) error {
+	active, err := model.CountByTaskStatus(clusterName, constants.TaskStatusActive)
+	if err != nil || active > 0 {
+		return err
+	}
+	tasks, err := model.GetQueuedTasks(ctx, clusterName)
+	if err != nil || len(tasks) == 0 {
+		return err
+	}
+	keep, count, coalesced := planCoalesce(tasks)
+	now := time.Now()
+	var dispatchId int64
+	dispatchUpdates := map[string]interface{}{
+		"status":    constants.TaskStatusInit,
+		"update_at": now,
+	}
+	coalescedResult := "cancelled out by opposing queued tasks"
+	if keep >= 0 {
+		dispatchId = tasks[keep].Id
+		coalescedResult = fmt.Sprintf("coalesced into task %d", dispatchId)
+		if len(coalesced) > 0 {
+			dispatchUpdates["task_info"] = withTaskCount(&tasks[keep], count)
+		}
+	}
+	coalescedIds := make([]int64, 0, len(coalesced))
+	for _, i := range coalesced {
+		coalescedIds = append(coalescedIds, tasks[i].Id)
+	}
+	ok, err := model.DequeueTasks(ctx, dispatchId, dispatchUpdates, coalescedIds, map[string]interface{}{
+		"status":      constants.TaskStatusCoalesced,
+		"task_result": coalescedResult,
+		"finish_time": now,
+		"update_at":   now,
+	})
+	if err != nil || !ok {
+		return err
+	}
+	for _, id := range coalescedIds {
+		RecordTaskEvent(id, constants.TaskEventCoalesced, coalescedResult)
+	}
+	if len(coalesced) > 0 && keep >= 0 {
+		RecordTaskEvent(dispatchId, constants.TaskEventCoalesced, "coalesced %d opposing queued tasks %v, count changed to %d",
+			len(coalescedIds), coalescedIds, count)
+	}
+	return nil
+}
+
+//queuedTaskDelta 排队任务对实例数量的调整, 扩容为正数, 缩容为负数; 指定IP的缩容任务不能合并
+func queuedTaskDelta(task *model.Task) (int, bool) {
+	switch task.TaskAction {
+	case constants.TaskActionExpand:
+		info := model.ExpandTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, &info); err != nil {
+			return 0, false
+		}
+		return info.Count, true
+	case constants.TaskActionShrink:
+		info := model.ShrinkTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, &info); err != nil {
+			return 0, false
+		}
+		if info.IPs != "" && info.IPs != constants.HasNoneIP {
+			return 0, false
+		}
+		return -info.Count, true
+	}
+	return 0, false
+}
+
+//planCoalesce 从队首开始依次合并与当前合计方向相反的任务, 例如扩容10再缩容4合并为扩容6.
+//返回保留的任务下标(合计为0时为-1)、合并后的实例数量和被合并掉的任务下标
+func planCoalesce(tasks []model.Task) (keep int, count int, coalesced []int) {
+	net, ok := queuedTaskDelta(&tasks[0])
+	if !ok {
+		return 0, 0, nil
+	}
+	end := 1
+	for ; end < len(tasks); end++ {
+		delta, ok := queuedTaskDelta(&tasks[end])
+		if !ok || delta == 0 || (net != 0 && (net > 0) == (delta > 0)) {
+			break
+		}
+		net += delta
+	}
+	if end == 1 {
+		return 0, 0, nil
+	}
+	keep = -1
+	for i := 0; i < end; i++ {
+		delta, _ := queuedTaskDelta(&tasks[i])
+		if keep < 0 && net != 0 && (net > 0) == (delta > 0) {
+			keep = i
+			continue
+		}
+		coalesced = append(coalesced, i)
+	}
+	if net < 0 {
+		net = -net
+	}
+	return keep, net, coalesced
+}
+
+//withTaskCount 返回修改了实例数量的任务参数
+func withTaskCount(task *model.Task, count int) string {
+	var info interface{}
+	if task.TaskAction == constants.TaskActionExpand {
+		expandInfo := &model.ExpandTaskInfo{}
+		_ = jsoniter.UnmarshalFromString(task.TaskInfo, expandInfo)
+		expandInfo.Count = count
+		info = expandInfo
+	} else {
+		shrinkInfo := &model.ShrinkTaskInfo{}
+		_ = jsoniter.UnmarshalFromString(task.TaskInfo, shrinkInfo)
+		shrinkInfo.Count = count
+		info = shrinkInfo
+	}
+	s, _ := jsoniter.MarshalToString(info)
+	return s
+}
+
+//GetTaskQueuePosition 排队中的任务在集群队列中的位置, 从1开始, 不在排队中时返回0
+func GetTaskQueuePosition(ctx context.Context, task *model.Task) (int, error) {
+	if task.Status != constants.TaskStatusQueued {
+		return 0, nil
+	}
+	cnt, err := model.CountQueuedTasksBefore(ctx, task.TaskFilter, task.Id)
+	if err != nil {
+		return 0, err
+	}
+	return int(cnt) + 1, nil
+}
+
+func ListQueuedTasks(ctx context.Context, clusterName string) ([]model.Task, error) {
+	return model.GetQueuedTasks(ctx, clusterName)
 }
 
 func hasUnfinishedTask(clusterName string) bool {
-	cnt, err := model.CountByTaskStatus(clusterName, []string{constants.TaskStatusInit, constants.TaskStatusRunning})
+	cnt, err := model.CountByTaskStatus(clusterName, constants.TaskStatusUnfinished)
 	if err != nil {
 		return false
 	}
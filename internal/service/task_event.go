@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+// RecordTaskEvent 记录任务的步骤事件, 不是由任务触发的扩缩容(taskId 为 0)不记录.
+// 事件只用于展示进度, 记录失败不影响任务执行
+func RecordTaskEvent(taskId int64, eventType, format string, args ...interface{}) {
+	if taskId == 0 {
+		return
+	}
+	now := time.Now()
+	event := &model.TaskEvent{
+		TaskId:    taskId,
+		EventType: eventType,
+		Message:   fmt.Sprintf(format, args...),
+		CreateAt:  &now,
+	}
+	if err := model.Create(event); err != nil {
+		logs.Logger.Errorf("[RecordTaskEvent] task: %d, event: %s, error: %v", taskId, eventType, err)
+	}
+}
+
+// AddTaskProgress 累加任务的进度计数, column 为 constants.TaskProgress*
+func AddTaskProgress(taskId int64, column string, delta int) {
+	if taskId == 0 || delta == 0 {
+		return
+	}
+	_ = model.IncrTaskProgress(context.Background(), taskId, column, delta)
+}
+
+// GetTaskEvents 查询任务 id 大于 afterId 的事件, 用于增量拉取
+func GetTaskEvents(ctx context.Context, taskId, afterId int64) ([]model.TaskEvent, error) {
+	return model.GetTaskEvents(ctx, taskId, afterId, constants.MaxTaskEventsPerQuery)
+}
+
+// IsTaskFinished 任务已经结束, 不会再产生新的事件
+func IsTaskFinished(task *model.Task) bool {
+	for _, status := range constants.TaskStatusUnfinished {
+		if task.Status == status {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestExpandShortfall(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1", Status: constants.Running},
+		{InstanceId: "i-2", Status: constants.Deleted},
+		{InstanceId: "i-3", Status: constants.Deleted, ShrinkTaskId: 100},
+		{InstanceId: "i-4", Status: constants.Pending},
+	}
+	if got := expandShortfall(5, instances); got != 2 {
+		t.Errorf("expand shortfall got %d, want 2", got)
+	}
+	if got := expandShortfall(3, nil); got != 3 {
+		t.Errorf("expand shortfall without instances got %d, want 3", got)
+	}
+}
+
+func TestShrinkShortfall(t *testing.T) {
+	deleted := []model.Instance{{InstanceId: "i-1", IpInner: "10.0.0.1"}}
+	count, ips := shrinkShortfall(&model.ShrinkTaskInfo{Count: 3}, deleted)
+	if count != 2 || ips != "" {
+		t.Errorf("shrink shortfall got %d %q", count, ips)
+	}
+	count, ips = shrinkShortfall(&model.ShrinkTaskInfo{Count: 3, IPs: "10.0.0.1,10.0.0.2,10.0.0.3"}, deleted)
+	if count != 2 || ips != "10.0.0.2,10.0.0.3" {
+		t.Errorf("shrink shortfall with ips got %d %q", count, ips)
+	}
+}
+
+func TestIsTaskFinished(t *testing.T) {
+	for status, want := range map[string]bool{
+		constants.TaskStatusInit:           false,
+		constants.TaskStatusRunning:        false,
+		constants.TaskStatusCancelling:     false,
+		constants.TaskStatusSuccess:        true,
+		constants.TaskStatusPartialSuccess: true,
+		constants.TaskStatusFailed:         true,
+		constants.TaskStatusCancelled:      true,
+	} {
+		if got := IsTaskFinished(&model.Task{Status: status}); got != want {
+			t.Errorf("%s finished got %v, want %v", status, got, want)
+		}
+	}
+	//不是由任务触发的扩缩容不记录事件, 不会访问数据库
+	RecordTaskEvent(0, constants.TaskEventStarted, "not a task")
+	AddTaskProgress(0, constants.TaskProgressCreated, 1)
+}
+
+func TestPlanCoalesce(t *testing.T) {
+	expand := func(count int) model.Task {
+		return model.Task{TaskAction: constants.TaskActionExpand, TaskInfo: fmt.Sprintf(`{"count":%d}`, count)}
+	}
+	shrink := func(count int, ips string) model.Task {
+		return model.Task{TaskAction: constants.TaskActionShrink, TaskInfo: fmt.Sprintf(`{"count":%d,"ips":"%s"}`, count, ips)}
+	}
+	cases := []struct {
+		name      string
+		tasks     []model.Task
+		keep      int
+		count     int
+		coalesced []int
+	}{
+		{"single", []model.Task{expand(10)}, 0, 0, nil},
+		{"expand then shrink", []model.Task{expand(10), shrink(4, "")}, 0, 6, []int{1}},
+		{"shrink wins", []model.Task{expand(4), shrink(10, "")}, 1, 6, []int{0}},
+		{"cancel out", []model.Task{expand(4), shrink(4, "")}, -1, 0, []int{0, 1}},
+		{"same direction stops", []model.Task{expand(10), shrink(4, ""), expand(3)}, 0, 6, []int{1}},
+		{"continue after zero", []model.Task{expand(4), shrink(4, ""), expand(3)}, 0, 3, []int{1, 2}},
+		{"shrink by ips", []model.Task{expand(10), shrink(1, "10.0.0.1")}, 0, 0, nil},
+		{"head shrink by ips", []model.Task{shrink(1, "10.0.0.1"), expand(10)}, 0, 0, nil},
+	}
+	for _, c := range cases {
+		keep, count, coalesced := planCoalesce(c.tasks)
+		if keep != c.keep || count != c.count || !reflect.DeepEqual(coalesced, c.coalesced) {
+			t.Errorf("%s got %d %d %v, want %d %d %v", c.name, keep, count, coalesced, c.keep, c.count, c.coalesced)
+		}
+	}
+}
+
+func TestRetryTaskInfo(t *testing.T) {
+	instances := []model.Instance{{InstanceId: "i-1", IpInner: "10.0.0.1", Status: constants.Running}}
+	expand := &model.Task{TaskAction: constants.TaskActionExpand, TaskInfo: `{"cluster_name":"c1","count":3,"user_id":1}`}
+	filter, info, err := retryTaskInfo(expand, instances, 2)
+	if err != nil || filter != "c1" {
+		t.Fatalf("expand got %q, %v", filter, err)
+	}
+	if e := info.(*model.ExpandTaskInfo); e.Count != 2 || e.UserId != 2 {
+		t.Errorf("expand retry info got %+v", e)
+	}
+	shrink := &model.Task{TaskAction: constants.TaskActionShrink, TaskInfo: `{"cluster_name":"c1","count":2,"ips":"10.0.0.1,10.0.0.2"}`}
+	if _, info, err = retryTaskInfo(shrink, instances, 2); err != nil {
+		t.Fatalf("shrink got %v", err)
+	}
+	if s := info.(*model.ShrinkTaskInfo); s.Count != 1 || s.IPs != "10.0.0.2" {
+		t.Errorf("shrink retry info got %+v", s)
+	}
+	rolling := &model.Task{TaskAction: constants.TaskActionRollingUpdate, TaskInfo: `{"cluster_name":"c1","batch_size":2,"task_exec_host":"10.0.0.9"}`}
+	if _, info, err = retryTaskInfo(rolling, nil, 2); err != nil {
+		t.Fatalf("rolling update got %v", err)
+	}
+	if r := info.(*model.RollingUpdateTaskInfo); r.BatchSize != 2 || r.UserId != 2 || r.TaskExecHost != "" {
+		t.Errorf("rolling update retry info got %+v", r)
+	}
+	done := &model.Task{TaskAction: constants.TaskActionExpand, TaskInfo: `{"cluster_name":"c1","count":1}`}
+	if _, _, err = retryTaskInfo(done, instances, 2); err == nil {
+		t.Errorf("want error when nothing left to expand")
+	}
+}
+
+func TestCancelledStatus(t *testing.T) {
+	for status, want := range map[string]string{
+		constants.TaskStatusQueued:         constants.TaskStatusCancelled,
+		constants.TaskStatusInit:           constants.TaskStatusCancelled,
+		constants.TaskStatusRunning:        constants.TaskStatusCancelling,
+		constants.TaskStatusPaused:         constants.TaskStatusCancelling,
+		constants.TaskStatusCancelling:     "",
+		constants.TaskStatusCancelled:      "",
+		constants.TaskStatusSuccess:        "",
+		constants.TaskStatusFailed:         "",
+		constants.TaskStatusPartialSuccess: "",
+		constants.TaskStatusCoalesced:      "",
+	} {
+		if got := cancelledStatus(status); got != want {
+			t.Errorf("cancel %s got %q, want %q", status, got, want)
+		}
+	}
+}
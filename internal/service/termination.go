@@ -0,0 +1,180 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+// terminationFacts 比较实例时需要的集群当前配置和实例信息
+type terminationFacts struct {
+	now   time.Time
+	image string
+	// instanceTypes 集群当前使用的规格, 包括备选规格和自动追加的规格
+	instanceTypes map[string]bool
+	// images 实例使用的镜像, 只有配置了 DEPRECATED_FIRST 时才从云厂商查询
+	images map[string]string
+}
+
+// clusterTerminationPolicies 集群配置的终止策略, 未配置时使用默认策略
+func clusterTerminationPolicies(c *types.ClusterInfo) []string {
+	if c.TerminationConfig == nil || len(c.TerminationConfig.Policies) == 0 {
+		return constants.DefaultTerminationPolicies
+	}
+	return c.TerminationConfig.Policies
+}
+
+// selectShrinkVictims 按集群的终止策略从活跃实例中挑选 num 台待释放的实例, 同时返回使用的策略
+func selectShrinkVictims(c *types.ClusterInfo, instances []model.Instance, num int) ([]model.Instance, []string) {
+	policies := clusterTerminationPolicies(c)
+	facts := terminationFacts{now: time.Now(), image: c.Image}
+	if hasTerminationPolicy(policies, constants.TerminationDeprecatedFirst) {
+		facts.instanceTypes = clusterInstanceTypes(c)
+		facts.images = instanceImages(c, instances)
+	}
+	return orderShrinkVictims(c, instances, num, policies, facts), policies
+}
+
+// orderShrinkVictims 配置了 ZONE_BALANCED 时先按可用区挑选, 其余策略决定同一可用区内的释放顺序
+func orderShrinkVictims(c *types.ClusterInfo, instances []model.Instance, num int, policies []string, facts terminationFacts) []model.Instance {
+	less := terminationLess(policies, facts)
+	if hasTerminationPolicy(policies, constants.TerminationZoneBalanced) {
+		return pickShrinkVictims(c, instances, num, less)
+	}
+	victims := append([]model.Instance{}, instances...)
+	sortInstances(victims, less)
+	if len(victims) > num {
+		victims = victims[:num]
+	}
+	return victims
+}
+
+// terminationLess 依次按各策略比较, 都相同时先释放后创建的实例
+func terminationLess(policies []string, facts terminationFacts) func(a, b model.Instance) bool {
+	return func(a, b model.Instance) bool {
+		for _, policy := range policies {
+			if res := facts.compare(policy, a, b); res != 0 {
+				return res < 0
+			}
+		}
+		return a.Id > b.Id
+	}
+}
+
+// compare 返回负数表示 a 先于 b 释放, 正数表示 b 先释放, 0 表示该策略无法区分
+func (f terminationFacts) compare(policy string, a, b model.Instance) int {
+	switch policy {
+	case constants.TerminationOldestFirst:
+		return compareInt64(a.Id, b.Id)
+	case constants.TerminationNewestFirst:
+		return compareInt64(b.Id, a.Id)
+	case constants.TerminationClosestToBillingHour:
+		return compareInt64(int64(f.untilBillingHour(a)), int64(f.untilBillingHour(b)))
+	case constants.TerminationDeprecatedFirst:
+		return compareFirst(f.deprecated(a), f.deprecated(b))
+	case constants.TerminationPendingFirst:
+		return compareFirst(a.Status != constants.Running, b.Status != constants.Running)
+	}
+	return 0
+}
+
+// untilBillingHour 距离下一个整小时计费周期的时间, 没有创建时间的实例排在最后
+func (f terminationFacts) untilBillingHour(instance model.Instance) time.Duration {
+	if instance.CreateAt == nil {
+		return time.Hour
+	}
+	return time.Hour - f.now.Sub(*instance.CreateAt)%time.Hour
+}
+
+// deprecated 实例的规格或镜像已不是集群当前的配置, 多可用区上线前创建的实例没有记录规格, 不视为旧规格
+func (f terminationFacts) deprecated(instance model.Instance) bool {
+	if instance.InstanceType != "" && f.instanceTypes != nil && !f.instanceTypes[instance.InstanceType] {
+		return true
+	}
+	image := f.images[instance.InstanceId]
+	return image != "" && f.image != "" && image != f.image
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareFirst 满足条件的实例先释放
+func compareFirst(a, b bool) int {
+	switch {
+	case a && !b:
+		return -1
+	case !a && b:
+		return 1
+	}
+	return 0
+}
+
+func hasTerminationPolicy(policies []string, policy string) bool {
+	for _, p := range policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func sortInstances(instances []model.Instance, less func(a, b model.Instance) bool) {
+	sort.SliceStable(instances, func(i, j int) bool {
+		return less(instances[i], instances[j])
+	})
+}
+
+// clusterInstanceTypes 集群规格、备选规格和自动追加的规格
+func clusterInstanceTypes(c *types.ClusterInfo) map[string]bool {
+	res := map[string]bool{c.InstanceType: true}
+	if c.InstanceTypeConfig != nil {
+		for _, instanceType := range c.InstanceTypeConfig.InstanceTypes {
+			res[instanceType] = true
+		}
+	}
+	for _, row := range deriveInstanceTypes(c) {
+		res[row.TypeName] = true
+	}
+	return res
+}
+
+// instanceImages 从云厂商查询实例使用的镜像, 查询失败时只按规格判断是否为旧实例
+func instanceImages(c *types.ClusterInfo, instances []model.Instance) map[string]string {
+	res, err := queryInstanceImages(c, instances)
+	if err != nil {
+		logs.Logger.Errorf("[instanceImages] cluster name: %s, error: %v", c.Name, err)
+		return map[string]string{}
+	}
+	return res
+}
+
+// queryInstanceImages 从云厂商查询实例使用的镜像
+func queryInstanceImages(c *types.ClusterInfo, instances []model.Instance) (map[string]string, error) {
+	res := make(map[string]string, len(instances))
+	if len(instances) == 0 {
+		return res, nil
+	}
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	cloudInstances, err := GetInstances(c, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range cloudInstances {
+		res[instance.Id] = instance.ImageId
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+func victimIds(instances []model.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	return ids
+}
+
+func TestOrderShrinkVictims(t *testing.T) {
+	now := time.Date(2021, 11, 1, 10, 0, 0, 0, time.Local)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	c := &types.ClusterInfo{ZoneId: "a", Image: "img-new", InstanceType: "t1"}
+	instances := []model.Instance{
+		{Id: 1, InstanceId: "i-1", Status: constants.Running, InstanceType: "t1", CreateAt: at(3*time.Hour + 10*time.Minute)},
+		{Id: 2, InstanceId: "i-2", Status: constants.Running, InstanceType: "t0", CreateAt: at(2*time.Hour + 50*time.Minute)},
+		{Id: 3, InstanceId: "i-3", Status: constants.Running, InstanceType: "t1", CreateAt: at(time.Hour + 30*time.Minute)},
+		{Id: 4, InstanceId: "i-4", Status: constants.Pending, InstanceType: "t1", CreateAt: at(time.Minute)},
+	}
+	facts := terminationFacts{
+		now:           now,
+		image:         c.Image,
+		instanceTypes: map[string]bool{"t1": true},
+		images:        map[string]string{"i-1": "img-new", "i-2": "img-new", "i-3": "img-old", "i-4": "img-new"},
+	}
+	cases := []struct {
+		policies []string
+		want     []string
+	}{
+		{constants.DefaultTerminationPolicies, []string{"i-4", "i-3"}},
+		{[]string{constants.TerminationOldestFirst}, []string{"i-1", "i-2"}},
+		{[]string{constants.TerminationClosestToBillingHour}, []string{"i-2", "i-3"}},
+		{[]string{constants.TerminationDeprecatedFirst, constants.TerminationOldestFirst}, []string{"i-2", "i-3"}},
+		{[]string{constants.TerminationPendingFirst, constants.TerminationOldestFirst}, []string{"i-4", "i-1"}},
+	}
+	for _, cs := range cases {
+		victims := orderShrinkVictims(c, instances, 2, cs.policies, facts)
+		if ids := victimIds(victims); !reflect.DeepEqual(ids, cs.want) {
+			t.Errorf("policies %v got %v, want %v", cs.policies, ids, cs.want)
+		}
+	}
+	if victims := orderShrinkVictims(c, instances, 10, []string{constants.TerminationOldestFirst}, facts); len(victims) != len(instances) {
+		t.Errorf("want all instances, got %d", len(victims))
+	}
+}
+
+func TestOrderShrinkVictimsZoneBalanced(t *testing.T) {
+	c := &types.ClusterInfo{NetworkConfig: &types.NetworkConfig{Zones: []types.ZoneConfig{{ZoneId: "a"}, {ZoneId: "b"}}}}
+	instances := []model.Instance{
+		{Id: 1, InstanceId: "i-1", ZoneId: "a"},
+		{Id: 2, InstanceId: "i-2", ZoneId: "a"},
+		{Id: 3, InstanceId: "i-3", ZoneId: "a"},
+		{Id: 4, InstanceId: "i-4", ZoneId: "b"},
+	}
+	policies := []string{constants.TerminationOldestFirst, constants.TerminationZoneBalanced}
+	victims := orderShrinkVictims(c, instances, 2, policies, terminationFacts{})
+	if ids := victimIds(victims); !reflect.DeepEqual(ids, []string{"i-1", "i-2"}) {
+		t.Errorf("zone balanced oldest first got %v", ids)
+	}
+}
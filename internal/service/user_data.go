@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+// MaxUserDataSize 各云厂商对用户数据的限制不同, 取最小的 16KB
+const MaxUserDataSize = 16 * 1024
+
+const (
+	userDataBoundary      = "==BRIDGX_USER_DATA=="
+	defaultNASMountPoint  = "/mnt/nas"
+	userDataIndexVariable = ".InstanceIndex"
+)
+
+// UserDataVars 用户数据模板中可以引用的变量, 例如 {{.ClusterName}}
+type UserDataVars struct {
+	ClusterName string
+	TaskId      string
+	// InstanceIndex 实例在本次扩容任务中的序号, 从 0 开始
+	InstanceIndex int
+	MountPoint    string
+	NAS           string
+}
+
+// bootstrapScript 挂载数据盘和 NAS, 只处理没有分区和文件系统的磁盘, 重复执行不会格式化已挂载的盘
+var bootstrapScript = template.Must(template.New("bootstrap").Parse(`#!/bin/bash
+# generated by bridgx: mount data disks and nas
+MOUNT_POINT={{.MountPoint}}
+DATA_DISK_NUM={{.DataDiskNum}}
+NAS={{.NAS}}
+NAS_MOUNT_POINT={{.NASMountPoint}}
+
+if [ -n "$MOUNT_POINT" ] && [ "$DATA_DISK_NUM" -gt 0 ]; then
+  root_disk=$(lsblk -no PKNAME "$(findmnt -no SOURCE /)" | head -n 1)
+  index=0
+  for disk in $(lsblk -dpno NAME,TYPE | awk '$2=="disk"{print $1}' | sort); do
+    [ "$index" -ge "$DATA_DISK_NUM" ] && break
+    [ "$(basename "$disk")" = "$root_disk" ] && continue
+    [ "$(lsblk -no NAME "$disk" | wc -l)" -gt 1 ] && continue
+    [ -n "$(blkid -o value -s TYPE "$disk")" ] && continue
+    target=$MOUNT_POINT
+    [ "$index" -gt 0 ] && target=$MOUNT_POINT$index
+    mkfs.ext4 -q -F "$disk" || continue
+    mkdir -p "$target"
+    echo "UUID=$(blkid -o value -s UUID "$disk") $target ext4 defaults,nofail 0 2" >> /etc/fstab
+    index=$((index+1))
+  done
+fi
+
+if [ -n "$NAS" ]; then
+  command -v mount.nfs >/dev/null 2>&1 || yum install -y nfs-utils || apt-get install -y nfs-common
+  mkdir -p "$NAS_MOUNT_POINT"
+  grep -q " $NAS_MOUNT_POINT nfs " /etc/fstab || echo "$NAS $NAS_MOUNT_POINT nfs vers=3,nolock,proto=tcp,noresvport,_netdev 0 0" >> /etc/fstab
+fi
+
+mount -a
+`))
+
+// CheckUserDataTemplate 创建集群时校验模板语法和引用的变量
+func CheckUserDataTemplate(text string) error {
+	if len(text) > MaxUserDataSize {
+		return fmt.Errorf("user data exceeds %d bytes", MaxUserDataSize)
+	}
+	tpl, err := template.New("user_data").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	return tpl.Execute(&buf, UserDataVars{})
+}
+
+// userDataPerInstance 模板引用了实例序号时每台实例的用户数据都不同
+func userDataPerInstance(c *types.ClusterInfo) bool {
+	return strings.Contains(c.UserData, userDataIndexVariable)
+}
+
+// buildUserData 生成实例的用户数据: 需要挂盘时先执行挂载脚本, 再执行集群配置的模板, 多段内容使用 cloud-init 的 MIME 格式
+func buildUserData(c *types.ClusterInfo, tags []cloud.Tag, index int) (string, error) {
+	parts := make([]string, 0, 2)
+	bootstrap, err := renderBootstrap(c.StorageConfig)
+	if err != nil {
+		return "", err
+	}
+	if bootstrap != "" {
+		parts = append(parts, bootstrap)
+	}
+	if c.UserData != "" {
+		vars := UserDataVars{
+			ClusterName:   c.Name,
+			TaskId:        tagValue(tags, cloud.TaskId),
+			InstanceIndex: index,
+		}
+		if c.StorageConfig != nil {
+			vars.MountPoint = c.StorageConfig.MountPoint
+			vars.NAS = c.StorageConfig.NAS
+		}
+		tpl, err := template.New("user_data").Option("missingkey=error").Parse(c.UserData)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err = tpl.Execute(&buf, vars); err != nil {
+			return "", err
+		}
+		parts = append(parts, buf.String())
+	}
+	switch len(parts) {
+	case 0:
+		return "", nil
+	case 1:
+		return parts[0], nil
+	}
+	return multipartUserData(parts)
+}
+
+func renderBootstrap(sc *types.StorageConfig) (string, error) {
+	if sc == nil {
+		return "", nil
+	}
+	dataDiskNum := 0
+	if sc.Disks != nil {
+		dataDiskNum = len(sc.Disks.DataDisk)
+	}
+	if (sc.MountPoint == "" || dataDiskNum == 0) && sc.NAS == "" {
+		return "", nil
+	}
+	nasMountPoint := sc.NASMountPoint
+	if nasMountPoint == "" {
+		nasMountPoint = defaultNASMountPoint
+	}
+	var buf bytes.Buffer
+	err := bootstrapScript.Execute(&buf, map[string]string{
+		"MountPoint":    shellQuote(sc.MountPoint),
+		"DataDiskNum":   strconv.Itoa(dataDiskNum),
+		"NAS":           shellQuote(sc.NAS),
+		"NASMountPoint": shellQuote(nasMountPoint),
+	})
+	return buf.String(), err
+}
+
+func multipartUserData(parts []string) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("Content-Type: multipart/mixed; boundary=\"" + userDataBoundary + "\"\r\nMIME-Version: 1.0\r\n\r\n")
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(userDataBoundary); err != nil {
+		return "", err
+	}
+	for _, part := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {userDataContentType(part) + "; charset=\"utf-8\""},
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err = pw.Write([]byte(part)); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// userDataContentType 按 cloud-init 的约定根据首行判断内容类型
+func userDataContentType(part string) string {
+	switch {
+	case strings.HasPrefix(part, "#cloud-config"):
+		return "text/cloud-config"
+	case strings.HasPrefix(part, "#include"):
+		return "text/x-include-url"
+	case strings.HasPrefix(part, "#cloud-boothook"):
+		return "text/cloud-boothook"
+	}
+	return "text/x-shellscript"
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func tagValue(tags []cloud.Tag, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestBuildUserData(t *testing.T) {
+	tags := []cloud.Tag{{Key: cloud.TaskId, Value: "7"}}
+	c := &types.ClusterInfo{Name: "c1", StorageConfig: &types.StorageConfig{}}
+	if userData, err := buildUserData(c, tags, 0); err != nil || userData != "" {
+		t.Errorf("want empty user data, got %q, %v", userData, err)
+	}
+
+	c.UserData = "#!/bin/bash\necho {{.ClusterName}}-{{.TaskId}}-{{.InstanceIndex}}"
+	userData, err := buildUserData(c, tags, 3)
+	if err != nil || userData != "#!/bin/bash\necho c1-7-3" {
+		t.Errorf("render template got %q, %v", userData, err)
+	}
+
+	c.StorageConfig = &types.StorageConfig{
+		MountPoint: "/data",
+		NAS:        "nas.example.com:/",
+		Disks:      &cloud.Disks{DataDisk: []cloud.DiskConf{{Size: 100}}},
+	}
+	userData, err = buildUserData(c, tags, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`boundary="` + userDataBoundary + `"`,
+		"MOUNT_POINT='/data'",
+		"DATA_DISK_NUM=1",
+		"NAS_MOUNT_POINT='" + defaultNASMountPoint + "'",
+		"Content-Type: text/x-shellscript",
+		"echo c1-7-0",
+	} {
+		if !strings.Contains(userData, want) {
+			t.Errorf("user data missing %q:\n%s", want, userData)
+		}
+	}
+}
+
+func TestCheckUserDataTemplate(t *testing.T) {
+	if err := CheckUserDataTemplate("#cloud-config\nhostname: {{.ClusterName}}-{{.InstanceIndex}}"); err != nil {
+		t.Errorf("valid template got %v", err)
+	}
+	if err := CheckUserDataTemplate("{{.Unknown}}"); err == nil {
+		t.Error("want error for unknown variable")
+	}
+	if err := CheckUserDataTemplate("{{.ClusterName"); err == nil {
+		t.Error("want error for bad syntax")
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+// clusterZones 集群可用的可用区, 未配置多可用区时使用集群的 zone_id 和 subnet_id
+func clusterZones(c *types.ClusterInfo) []types.ZoneConfig {
+	if c.NetworkConfig != nil && len(c.NetworkConfig.Zones) > 0 {
+		zones := make([]types.ZoneConfig, 0, len(c.NetworkConfig.Zones))
+		for _, zone := range c.NetworkConfig.Zones {
+			if zone.Weight <= 0 {
+				zone.Weight = 1
+			}
+			zones = append(zones, zone)
+		}
+		return zones
+	}
+	zone := types.ZoneConfig{ZoneId: c.ZoneId, Weight: 1}
+	if c.NetworkConfig != nil {
+		zone.SubnetId = c.NetworkConfig.SubnetId
+	}
+	return []types.ZoneConfig{zone}
+}
+
+// instanceZone 多可用区上线前创建的实例没有记录可用区, 视为集群默认可用区
+func instanceZone(c *types.ClusterInfo, instance model.Instance) string {
+	if instance.ZoneId == "" {
+		return c.ZoneId
+	}
+	return instance.ZoneId
+}
+
+// zoneInstanceCount 各可用区当前的活跃实例数量
+func zoneInstanceCount(c *types.ClusterInfo) map[string]int {
+	counts := make(map[string]int)
+	instances, err := model.GetActiveInstancesByClusterName(c.Name)
+	if err != nil {
+		logs.Logger.Errorf("[zoneInstanceCount] cluster name: %s, error: %v", c.Name, err)
+		return counts
+	}
+	for _, instance := range instances {
+		counts[instanceZone(c, instance)]++
+	}
+	return counts
+}
+
+// planZones 把 num 台实例分配到各可用区, 每次放到 (已有数量+1)/权重 最小的可用区, 使各可用区实例数与权重成比例
+func planZones(zones []types.ZoneConfig, counts map[string]int, num int) []int {
+	plan := make([]int, len(zones))
+	if len(zones) == 0 {
+		return plan
+	}
+	current := make([]int, len(zones))
+	for i, zone := range zones {
+		current[i] = counts[zone.ZoneId]
+	}
+	for n := 0; n < num; n++ {
+		best := 0
+		for i := 1; i < len(zones); i++ {
+			if (current[i]+1)*zones[best].Weight < (current[best]+1)*zones[i].Weight {
+				best = i
+			}
+		}
+		current[best]++
+		plan[best]++
+	}
+	return plan
+}
+
+// pickShrinkVictims 缩容时优先释放不在可用区配置中的实例, 再从 实例数/权重 最大的可用区释放, 同一可用区按 less 排序后依次释放
+func pickShrinkVictims(c *types.ClusterInfo, instances []model.Instance, num int, less func(a, b model.Instance) bool) []model.Instance {
+	zones := clusterZones(c)
+	weights := make(map[string]int, len(zones))
+	for _, zone := range zones {
+		weights[zone.ZoneId] = zone.Weight
+	}
+	byZone := make(map[string][]model.Instance)
+	stale := make([]model.Instance, 0)
+	for _, instance := range instances {
+		zoneId := instanceZone(c, instance)
+		if _, ok := weights[zoneId]; !ok {
+			stale = append(stale, instance)
+			continue
+		}
+		byZone[zoneId] = append(byZone[zoneId], instance)
+	}
+	sortInstances(stale, less)
+	for _, list := range byZone {
+		sortInstances(list, less)
+	}
+	victims := make([]model.Instance, 0, num)
+	for _, instance := range stale {
+		if len(victims) == num {
+			return victims
+		}
+		victims = append(victims, instance)
+	}
+	for len(victims) < num {
+		worst := ""
+		for _, zone := range zones {
+			n := len(byZone[zone.ZoneId])
+			if n == 0 {
+				continue
+			}
+			if worst == "" || n*weights[worst] > len(byZone[worst])*zone.Weight {
+				worst = zone.ZoneId
+			}
+		}
+		if worst == "" {
+			break
+		}
+		victims = append(victims, byZone[worst][0])
+		byZone[worst] = byZone[worst][1:]
+	}
+	return victims
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+func TestPlanZones(t *testing.T) {
+	zones := []types.ZoneConfig{{ZoneId: "a", Weight: 1}, {ZoneId: "b", Weight: 2}}
+	if plan := planZones(zones, nil, 6); !reflect.DeepEqual(plan, []int{2, 4}) {
+		t.Errorf("plan got %v", plan)
+	}
+	if plan := planZones(zones, map[string]int{"b": 5}, 3); !reflect.DeepEqual(plan, []int{3, 0}) {
+		t.Errorf("plan with existing instances got %v", plan)
+	}
+	if plan := planZones(zones[:1], nil, 3); !reflect.DeepEqual(plan, []int{3}) {
+		t.Errorf("single zone plan got %v", plan)
+	}
+}
+
+func TestClusterZones(t *testing.T) {
+	c := &types.ClusterInfo{ZoneId: "a", NetworkConfig: &types.NetworkConfig{SubnetId: "s1"}}
+	if zones := clusterZones(c); !reflect.DeepEqual(zones, []types.ZoneConfig{{ZoneId: "a", SubnetId: "s1", Weight: 1}}) {
+		t.Errorf("default zones got %v", zones)
+	}
+	c.NetworkConfig.Zones = []types.ZoneConfig{{ZoneId: "b", SubnetId: "s2"}, {ZoneId: "c", SubnetId: "s3", Weight: 3}}
+	if zones := clusterZones(c); zones[0].Weight != 1 || zones[1].Weight != 3 {
+		t.Errorf("zones got %v", zones)
+	}
+}
+
+func TestPickShrinkVictims(t *testing.T) {
+	c := &types.ClusterInfo{ZoneId: "a", NetworkConfig: &types.NetworkConfig{Zones: []types.ZoneConfig{
+		{ZoneId: "a", SubnetId: "s1", Weight: 1},
+		{ZoneId: "b", SubnetId: "s2", Weight: 1},
+	}}}
+	instances := []model.Instance{
+		{Id: 1, InstanceId: "i-1"},
+		{Id: 2, InstanceId: "i-2", ZoneId: "a"},
+		{Id: 3, InstanceId: "i-3", ZoneId: "a"},
+		{Id: 4, InstanceId: "i-4", ZoneId: "b"},
+		{Id: 5, InstanceId: "i-5", ZoneId: "x"},
+	}
+	newestFirst := terminationLess(constants.DefaultTerminationPolicies, terminationFacts{})
+	victims := pickShrinkVictims(c, instances, 3, newestFirst)
+	ids := make([]string, 0, len(victims))
+	for _, victim := range victims {
+		ids = append(ids, victim.InstanceId)
+	}
+	if !reflect.DeepEqual(ids, []string{"i-5", "i-3", "i-2"}) {
+		t.Errorf("victims got %v", ids)
+	}
+	if victims = pickShrinkVictims(c, instances, 10, newestFirst); len(victims) != len(instances) {
+		t.Errorf("want all instances, got %d", len(victims))
+	}
+}
@@ -0,0 +1,19 @@
+package types
+
+// ScalingStep 指标落在 [lower_bound, upper_bound) 时调整 adjustment 台实例, 边界为空表示无穷
+type ScalingStep struct {
+	LowerBound *float64 `json:"lower_bound"`
+	UpperBound *float64 `json:"upper_bound"`
+	Adjustment int      `json:"adjustment"` //正数扩容, 负数缩容
+}
+
+// Contains 判断指标是否落在该区间内
+func (s ScalingStep) Contains(value float64) bool {
+	if s.LowerBound != nil && value < *s.LowerBound {
+		return false
+	}
+	if s.UpperBound != nil && value >= *s.UpperBound {
+		return false
+	}
+	return true
+}
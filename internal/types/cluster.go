@@ -16,12 +16,29 @@ type ClusterInfo struct {
 	Image        string `json:"image"`
 	Provider     string `json:"provider"`
 	Username     string `json:"username"`
-	Password     string `json:"password"`
-	AccountKey   string `json:"account_key"` //阿里云ak
+	Password     string `json:"password,omitempty"` //只用于创建和编辑, 查询时不返回
+	KeyPairName  string `json:"key_pair_name"`      //登录使用的密钥对, 与 password 二选一
+	AccountKey   string `json:"account_key"`        //阿里云ak
+
+	//ExpectCount 和 Declarative 只能通过 set_expect_count 接口修改, 创建和编辑集群时忽略
+	ExpectCount int  `json:"expect_count"`
+	Declarative bool `json:"declarative"` //为 true 时持续把活跃实例数量收敛到 expect_count
 
 	//Advanced Config
 	NetworkConfig *NetworkConfig `json:"network_config"`
 	StorageConfig *StorageConfig `json:"storage_config"`
+	ChargeConfig  *ChargeConfig  `json:"charge_config"`
+	SpotConfig    *SpotConfig    `json:"spot_config"`
+	//InstanceTypeConfig instance_type 库存或配额不足时依次尝试的备选规格
+	InstanceTypeConfig *InstanceTypeConfig `json:"instance_type_config"`
+	//TerminationConfig 缩容时挑选待释放实例的策略, 为空时使用 constants.DefaultTerminationPolicies
+	TerminationConfig *TerminationConfig `json:"termination_config"`
+	//LifecycleConfig 释放实例前等待摘除流量、扩容后等待实例就绪的钩子, 为空时不等待
+	LifecycleConfig *LifecycleConfig `json:"lifecycle_config"`
+	//HealthCheckConfig 实例健康检查, 为空时不检查
+	HealthCheckConfig *HealthCheckConfig `json:"health_check_config"`
+	//UserData 实例启动时执行的 cloud-init 模板, 可以引用 service.UserDataVars 中的变量
+	UserData string `json:"user_data"`
 
 	//Custom Config
 	Tags map[string]string `json:"tags"`
@@ -33,12 +50,73 @@ type NetworkConfig struct {
 	SecurityGroup           string `json:"security_group"`
 	InternetChargeType      string `json:"internet_charge_type"`
 	InternetMaxBandwidthOut int    `json:"internet_max_bandwidth_out"`
+	//Zones 多可用区部署, 为空时只使用集群的 zone_id 和 subnet_id
+	Zones []ZoneConfig `json:"zones"`
+}
+
+// ZoneConfig 可用区及其子网, 扩容时按权重分配实例, 某个可用区库存不足时由其他可用区补齐
+type ZoneConfig struct {
+	ZoneId   string `json:"zone_id"`
+	SubnetId string `json:"subnet_id"`
+	Weight   int    `json:"weight"` //默认为 1
 }
 
 type StorageConfig struct {
-	MountPoint string       `json:"mount_point"`
-	NAS        string       `json:"nas"`
-	Disks      *cloud.Disks `json:"disks"`
+	MountPoint    string       `json:"mount_point"`     //数据盘挂载目录, 多块数据盘依次挂载到 MountPoint, MountPoint1 ...
+	NAS           string       `json:"nas"`             //NFS 挂载地址, 例如 xxx.nas.aliyuncs.com:/
+	NASMountPoint string       `json:"nas_mount_point"` //NAS 挂载目录, 默认 /mnt/nas
+	Disks         *cloud.Disks `json:"disks"`
+}
+
+// ChargeConfig 包年包月购买配置, charge_type 为 PrePaid 时必填
+type ChargeConfig struct {
+	Period     int    `json:"period"`      //购买时长
+	PeriodUnit string `json:"period_unit"` //Week, Month, Year
+	AutoRenew  bool   `json:"auto_renew"`  //到期是否自动续费
+}
+
+// SpotConfig 抢占式实例配置, 为空表示按量实例
+type SpotConfig struct {
+	Strategy   string  `json:"strategy"`    //SpotOnly, SpotWithFallback
+	PriceLimit float64 `json:"price_limit"` //每台实例每小时最高出价, 0表示跟随市场价
+}
+
+// InstanceTypeConfig 备选实例规格, 扩容时先使用集群的 instance_type, 库存或配额不足时按顺序换用备选规格
+type InstanceTypeConfig struct {
+	InstanceTypes []string `json:"instance_types"` //按优先级排列的备选规格
+	AutoDerive    bool     `json:"auto_derive"`    //是否自动追加同规格族、同核数内存的其他规格
+}
+
+// TerminationConfig 终止策略按顺序比较实例, 前一个策略无法区分时使用下一个. ZONE_BALANCED 不论位置都先决定从哪个可用区释放
+type TerminationConfig struct {
+	Policies []string `json:"policies"` //constants.Termination*
+}
+
+// LifecycleConfig 生命周期钩子. 释放实例前总会先把实例标记为 DELETING 并发布去掉这些实例后的 IP 列表
+type LifecycleConfig struct {
+	PreTerminate *LifecycleHook `json:"pre_terminate"` //释放前调用 webhook, 返回 2xx 视为已摘除流量
+	PostLaunch   *LifecycleHook `json:"post_launch"`   //拿到 IP 后等待就绪再标记为 RUNNING 并发布 IP
+}
+
+// LifecycleHook 每隔 constants.LifecycleHookInterval 秒检查一次, 直到确认或超时
+type LifecycleHook struct {
+	WebhookUrl      string `json:"webhook_url"`
+	HealthCheckPort int    `json:"health_check_port"` //只用于 post_launch, 检查实例内网 IP 的 TCP 端口是否可以连接
+	TimeoutSeconds  int    `json:"timeout_seconds"`   //默认 constants.DefaultLifecycleHookTimeout
+	DefaultResult   string `json:"default_result"`    //超时后的处理, CONTINUE 或 ABANDON, 默认 CONTINUE
+}
+
+// HealthCheckConfig 调度器定时检查运行中的实例, 连续失败 unhealthy_threshold 次视为不健康, 开启 auto_heal 时按 IP 缩容并补齐
+type HealthCheckConfig struct {
+	Type                string `json:"type"`                  //TCP, HTTP, CLOUD
+	Port                int    `json:"port"`                  //TCP 和 HTTP 检查实例内网 IP 的端口
+	Path                string `json:"path"`                  //HTTP 检查的路径, 返回 2xx 视为健康
+	TimeoutSeconds      int    `json:"timeout_seconds"`       //默认 constants.DefaultHealthCheckTimeout
+	UnhealthyThreshold  int    `json:"unhealthy_threshold"`   //默认 constants.DefaultUnhealthyThreshold
+	GracePeriodSeconds  int    `json:"grace_period_seconds"`  //默认 constants.DefaultHealthCheckGracePeriod
+	AutoHeal            bool   `json:"auto_heal"`             //自动替换不健康的实例
+	MaxHealPerHour      int    `json:"max_heal_per_hour"`     //默认 constants.DefaultMaxHealPerHour
+	MaxUnhealthyPercent int    `json:"max_unhealthy_percent"` //默认 constants.DefaultMaxUnhealthyPercent
 }
 
 type OrgKeys struct {
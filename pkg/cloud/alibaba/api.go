@@ -1,6 +1,7 @@
 package alibaba
 
 import (
+	"encoding/base64"
 	"errors"
 	"math"
 	"strconv"
@@ -93,9 +94,22 @@ func generateInstances(cloudInstance []ecs.Instance) (instances []cloud.Instance
 }
 
 const (
-	CloudName = "AlibabaCloud"
+	CloudName     = "AlibabaCloud"
+	DefaultRegion = "cn-qingdao"
 )
 
+func init() {
+	cloud.RegisterProviderDriver(CloudName, newDriver, DefaultRegion)
+}
+
+func newDriver(ak, sk, region string) (cloud.Provider, error) {
+	client, err := New(ak, sk, region)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func New(AK, SK, region string) (*AlibabaCloud, error) {
 	client, err := ecs.NewClientWithAccessKey(region, AK, SK)
 	if err != nil {
@@ -123,6 +137,23 @@ func New(AK, SK, region string) (*AlibabaCloud, error) {
 
 // BatchCreate the maximum of 'num' is 100
 func (p *AlibabaCloud) BatchCreate(m cloud.Params, num int) (instanceIds []string, err error) {
+	request := runInstancesRequest(m, num)
+	response, err := p.client.RunInstances(request)
+	return response.InstanceIdSets.InstanceIdSet, err
+}
+
+// DryRunCreate 使用 RunInstances 的 DryRun 参数预检, 检查通过时接口返回 DryRunOperation
+func (p *AlibabaCloud) DryRunCreate(m cloud.Params, num int) error {
+	request := runInstancesRequest(m, num)
+	request.DryRun = requests.NewBoolean(true)
+	_, err := p.client.RunInstances(request)
+	if err != nil && strings.Contains(err.Error(), dryRunPassed) {
+		return nil
+	}
+	return err
+}
+
+func runInstancesRequest(m cloud.Params, num int) *ecs.RunInstancesRequest {
 	request := ecs.CreateRunInstancesRequest()
 	request.Scheme = "https"
 
@@ -136,6 +167,7 @@ func (p *AlibabaCloud) BatchCreate(m cloud.Params, num int) (instanceIds []strin
 		request.InternetMaxBandwidthOut = requests.NewInteger(m.Network.InternetMaxBandwidthOut)
 	}
 	request.Password = m.Password
+	request.KeyPairName = m.KeyPairName
 
 	request.SystemDiskCategory = m.Disks.SystemDisk.Category
 	request.SystemDiskSize = strconv.Itoa(m.Disks.SystemDisk.Size)
@@ -143,8 +175,33 @@ func (p *AlibabaCloud) BatchCreate(m cloud.Params, num int) (instanceIds []strin
 	for _, disk := range m.Disks.DataDisk {
 		dataDisks = append(dataDisks, ecs.RunInstancesDataDisk{Size: strconv.Itoa(disk.Size), Category: disk.Category, PerformanceLevel: disk.PerformanceLevel})
 	}
+	if len(dataDisks) > 0 {
+		request.DataDisk = &dataDisks
+	}
+	if m.UserData != "" {
+		request.UserData = base64.StdEncoding.EncodeToString([]byte(m.UserData))
+	}
 	request.Amount = requests.NewInteger(num)
 	request.MinAmount = requests.NewInteger(num)
+	request.InstanceChargeType = InstancePostPaid
+	if m.ChargeType == cloud.InstanceChargeTypePrePaid {
+		request.InstanceChargeType = InstancePrePaid
+		request.Period = requests.NewInteger(m.Period)
+		request.PeriodUnit = m.PeriodUnit
+		// 按年购买换算为月, 可选 12/24/36/48/60
+		if m.PeriodUnit == cloud.PeriodUnitYear {
+			request.Period = requests.NewInteger(m.Period * 12)
+			request.PeriodUnit = cloud.PeriodUnitMonth
+		}
+		request.AutoRenew = requests.NewBoolean(m.AutoRenew)
+	} else if m.Spot != nil {
+		request.SpotStrategy = SpotAsPriceGo
+		if m.Spot.PriceLimit > 0 {
+			request.SpotStrategy = SpotWithPriceLimit
+			request.SpotPriceLimit = requests.NewFloat(m.Spot.PriceLimit)
+		}
+		request.SpotInterruptionBehavior = SpotInterruptTerminate
+	}
 	if len(m.Tags) > 0 {
 		tags := make([]ecs.RunInstancesTag, 0)
 		for _, tag := range m.Tags {
@@ -156,8 +213,7 @@ func (p *AlibabaCloud) BatchCreate(m cloud.Params, num int) (instanceIds []strin
 		}
 		request.Tag = &tags
 	}
-	response, err := p.client.RunInstances(request)
-	return response.InstanceIdSets.InstanceIdSet, err
+	return request
 }
 
 func (p *AlibabaCloud) GetInstances(ids []string) (instances []cloud.Instance, err error) {
@@ -512,6 +568,89 @@ func (p *AlibabaCloud) DescribeSecurityGroups(req cloud.DescribeSecurityGroupsRe
 	return cloud.DescribeSecurityGroupsResponse{Groups: groups}, nil
 }
 
+func (p *AlibabaCloud) CreateKeyPair(req cloud.CreateKeyPairRequest) (cloud.CreateKeyPairResponse, error) {
+	response, err := p.ecsClient.CreateKeyPair(&ecsClient.CreateKeyPairRequest{
+		RegionId:    tea.String(req.RegionId),
+		KeyPairName: tea.String(req.KeyPairName),
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateKeyPair AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateKeyPairResponse{}, err
+	}
+	if response != nil && response.Body != nil {
+		return cloud.CreateKeyPairResponse{
+			KeyPairId:   tea.StringValue(response.Body.KeyPairId),
+			KeyPairName: tea.StringValue(response.Body.KeyPairName),
+			PrivateKey:  tea.StringValue(response.Body.PrivateKeyBody),
+		}, nil
+	}
+	return cloud.CreateKeyPairResponse{}, err
+}
+
+func (p *AlibabaCloud) ImportKeyPair(req cloud.ImportKeyPairRequest) (cloud.ImportKeyPairResponse, error) {
+	response, err := p.ecsClient.ImportKeyPair(&ecsClient.ImportKeyPairRequest{
+		RegionId:      tea.String(req.RegionId),
+		KeyPairName:   tea.String(req.KeyPairName),
+		PublicKeyBody: tea.String(req.PublicKey),
+	})
+	if err != nil {
+		logs.Logger.Errorf("ImportKeyPair AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.ImportKeyPairResponse{}, err
+	}
+	if response != nil && response.Body != nil {
+		// 阿里云密钥对没有单独的 ID, 以名称作为 ID
+		return cloud.ImportKeyPairResponse{
+			KeyPairId:   tea.StringValue(response.Body.KeyPairName),
+			KeyPairName: tea.StringValue(response.Body.KeyPairName),
+		}, nil
+	}
+	return cloud.ImportKeyPairResponse{}, err
+}
+
+func (p *AlibabaCloud) DescribeKeyPairs(req cloud.DescribeKeyPairsRequest) (cloud.DescribeKeyPairsResponse, error) {
+	var page int32 = 1
+	keyPairs := make([]cloud.KeyPair, 0, 16)
+	for {
+		response, err := p.ecsClient.DescribeKeyPairs(&ecsClient.DescribeKeyPairsRequest{
+			RegionId:   tea.String(req.RegionId),
+			PageSize:   tea.Int32(50),
+			PageNumber: tea.Int32(page),
+		})
+		if err != nil {
+			logs.Logger.Errorf("DescribeKeyPairs AlibabaCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeKeyPairsResponse{}, err
+		}
+		if response == nil || response.Body == nil || response.Body.KeyPairs == nil {
+			break
+		}
+		for _, keyPair := range response.Body.KeyPairs.KeyPair {
+			keyPairs = append(keyPairs, cloud.KeyPair{
+				KeyPairId:   tea.StringValue(keyPair.KeyPairName),
+				KeyPairName: tea.StringValue(keyPair.KeyPairName),
+				Fingerprint: tea.StringValue(keyPair.KeyPairFingerPrint),
+			})
+		}
+		if tea.Int32Value(response.Body.TotalCount) <= page*50 {
+			break
+		}
+		page++
+	}
+	return cloud.DescribeKeyPairsResponse{KeyPairs: keyPairs}, nil
+}
+
+func (p *AlibabaCloud) DeleteKeyPair(req cloud.DeleteKeyPairRequest) error {
+	names, _ := jsoniter.Marshal([]string{req.KeyPairName})
+	_, err := p.ecsClient.DeleteKeyPairs(&ecsClient.DeleteKeyPairsRequest{
+		RegionId:     tea.String(req.RegionId),
+		KeyPairNames: tea.String(string(names)),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DeleteKeyPair AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
 func (p *AlibabaCloud) GetRegions() (cloud.GetRegionsResponse, error) {
 	response, err := p.vpcClient.DescribeRegions(&vpcClient.DescribeRegionsRequest{
 		AcceptLanguage: tea.String(AcceptLanguage),
@@ -5,6 +5,18 @@ const (
 	PayAsYouGo = "PayAsYouGo"
 )
 
+// 实例计费方式及抢占式实例出价策略
+const (
+	InstancePrePaid        = "PrePaid"
+	InstancePostPaid       = "PostPaid"
+	SpotWithPriceLimit     = "SpotWithPriceLimit"
+	SpotAsPriceGo          = "SpotAsPriceGo"
+	SpotInterruptTerminate = "Terminate"
+)
+
+// dryRunPassed 预检请求通过时返回的错误码
+const dryRunPassed = "DryRunOperation"
+
 const (
 	Paid      = "Paid"
 	Unpaid    = "Unpaid"
@@ -0,0 +1,309 @@
+package aws
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+type AWSCloud struct {
+	ec2Client *ec2.EC2
+}
+
+func init() {
+	cloud.RegisterProviderDriver(CloudName, newDriver, DefaultRegion)
+}
+
+func newDriver(ak, sk, region string) (cloud.Provider, error) {
+	client, err := New(ak, sk, region)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func New(AK, SK, region string) (*AWSCloud, error) {
+	return NewWithEndpoint(AK, SK, region, "")
+}
+
+// NewWithEndpoint endpoint 不为空时请求发往该地址, 用于对接本地模拟服务
+func NewWithEndpoint(AK, SK, region, endpoint string) (*AWSCloud, error) {
+	config := &awssdk.Config{
+		Region:      awssdk.String(region),
+		Credentials: credentials.NewStaticCredentials(AK, SK, ""),
+	}
+	if endpoint != "" {
+		config.Endpoint = awssdk.String(endpoint)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSCloud{ec2Client: ec2.New(sess)}, nil
+}
+
+func (*AWSCloud) ProviderType() string {
+	return CloudName
+}
+
+// BatchCreate 创建数量不足 num 时整体失败, EC2 没有包年包月实例
+func (p *AWSCloud) BatchCreate(m cloud.Params, num int) (instanceIds []string, err error) {
+	if m.ChargeType == cloud.InstanceChargeTypePrePaid {
+		return nil, fmt.Errorf("%w: %s %s", cloud.ErrChargeTypeNotSupported, CloudName, m.ChargeType)
+	}
+	input := &ec2.RunInstancesInput{
+		ImageId:      awssdk.String(m.ImageId),
+		InstanceType: awssdk.String(m.InstanceType),
+		MinCount:     awssdk.Int64(int64(num)),
+		MaxCount:     awssdk.Int64(int64(num)),
+	}
+	if m.Spot != nil {
+		spot := &ec2.SpotMarketOptions{
+			SpotInstanceType:             awssdk.String(ec2.SpotInstanceTypeOneTime),
+			InstanceInterruptionBehavior: awssdk.String(ec2.InstanceInterruptionBehaviorTerminate),
+		}
+		// 不指定出价时最高价格为按需价格
+		if m.Spot.PriceLimit > 0 {
+			spot.MaxPrice = awssdk.String(strconv.FormatFloat(m.Spot.PriceLimit, 'f', -1, 64))
+		}
+		input.InstanceMarketOptions = &ec2.InstanceMarketOptionsRequest{
+			MarketType:  awssdk.String(ec2.MarketTypeSpot),
+			SpotOptions: spot,
+		}
+	}
+	if m.KeyPairName != "" {
+		input.KeyName = awssdk.String(m.KeyPairName)
+	}
+	if m.UserData != "" {
+		input.UserData = awssdk.String(base64.StdEncoding.EncodeToString([]byte(m.UserData)))
+	}
+	if m.Zone != "" {
+		input.Placement = &ec2.Placement{AvailabilityZone: awssdk.String(m.Zone)}
+	}
+	if m.Network != nil {
+		var groups []*string
+		if m.Network.SecurityGroup != "" {
+			groups = awssdk.StringSlice(strings.Split(m.Network.SecurityGroup, ","))
+		}
+		input.NetworkInterfaces = []*ec2.InstanceNetworkInterfaceSpecification{{
+			DeviceIndex:              awssdk.Int64(0),
+			SubnetId:                 awssdk.String(m.Network.SubnetId),
+			Groups:                   groups,
+			AssociatePublicIpAddress: awssdk.Bool(m.Network.InternetMaxBandwidthOut > 0),
+			DeleteOnTermination:      awssdk.Bool(true),
+		}}
+	}
+	if m.Disks != nil {
+		input.BlockDeviceMappings, err = p.blockDeviceMappings(m.ImageId, m.Disks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Tags) > 0 {
+		tags := make([]*ec2.Tag, 0, len(m.Tags)+1)
+		for _, tag := range m.Tags {
+			tags = append(tags, &ec2.Tag{Key: awssdk.String(tag.Key), Value: awssdk.String(tag.Value)})
+			if tag.Key == cloud.ClusterName {
+				tags = append(tags, &ec2.Tag{Key: awssdk.String(NameTag), Value: awssdk.String(tag.Value)})
+			}
+		}
+		input.TagSpecifications = []*ec2.TagSpecification{
+			{ResourceType: awssdk.String(ec2.ResourceTypeInstance), Tags: tags},
+			{ResourceType: awssdk.String(ec2.ResourceTypeVolume), Tags: tags},
+		}
+	}
+	output, err := p.ec2Client.RunInstances(input)
+	if err != nil {
+		logs.Logger.Errorf("BatchCreate AWS failed.err: [%v], req[%v]", err, m)
+		return nil, err
+	}
+	for _, instance := range output.Instances {
+		instanceIds = append(instanceIds, awssdk.StringValue(instance.InstanceId))
+	}
+	return instanceIds, nil
+}
+
+// blockDeviceMappings 系统盘设备名取决于镜像, 需要先查询镜像的根设备
+func (p *AWSCloud) blockDeviceMappings(imageId string, disks *cloud.Disks) ([]*ec2.BlockDeviceMapping, error) {
+	output, err := p.ec2Client.DescribeImages(&ec2.DescribeImagesInput{ImageIds: awssdk.StringSlice([]string{imageId})})
+	if err != nil {
+		logs.Logger.Errorf("DescribeImages AWS failed.err: [%v], imageId[%s]", err, imageId)
+		return nil, err
+	}
+	mappings := make([]*ec2.BlockDeviceMapping, 0, len(disks.DataDisk)+1)
+	if len(output.Images) > 0 && disks.SystemDisk.Size > 0 {
+		mappings = append(mappings, toBlockDevice(awssdk.StringValue(output.Images[0].RootDeviceName), disks.SystemDisk))
+	}
+	for i, disk := range disks.DataDisk {
+		mappings = append(mappings, toBlockDevice(DataDiskDevice+string(rune('b'+i)), disk))
+	}
+	return mappings, nil
+}
+
+func toBlockDevice(deviceName string, disk cloud.DiskConf) *ec2.BlockDeviceMapping {
+	ebs := &ec2.EbsBlockDevice{
+		VolumeSize:          awssdk.Int64(int64(disk.Size)),
+		DeleteOnTermination: awssdk.Bool(true),
+	}
+	if disk.Category != "" {
+		ebs.VolumeType = awssdk.String(disk.Category)
+	}
+	return &ec2.BlockDeviceMapping{DeviceName: awssdk.String(deviceName), Ebs: ebs}
+}
+
+// GetInstances 使用 instance-id 过滤而非 InstanceIds 参数, 避免已释放的实例导致整体报错
+func (p *AWSCloud) GetInstances(ids []string) (instances []cloud.Instance, err error) {
+	for _, onceIds := range utils.StringSliceSplit(ids, PageSize) {
+		res, err := p.describeInstances([]*ec2.Filter{{
+			Name:   awssdk.String("instance-id"),
+			Values: awssdk.StringSlice(onceIds),
+		}})
+		if err != nil {
+			logs.Logger.Errorf("GetInstances AWS failed.err: [%v], ids[%v]", err, onceIds)
+			return nil, err
+		}
+		instances = append(instances, res...)
+	}
+	return instances, nil
+}
+
+func (p *AWSCloud) GetInstancesByTags(region string, tags []cloud.Tag) (instances []cloud.Instance, err error) {
+	filters := make([]*ec2.Filter, 0, len(tags))
+	for _, tag := range tags {
+		filters = append(filters, &ec2.Filter{
+			Name:   awssdk.String("tag:" + tag.Key),
+			Values: awssdk.StringSlice([]string{tag.Value}),
+		})
+	}
+	instances, err = p.describeInstances(filters)
+	if err != nil {
+		logs.Logger.Errorf("GetInstancesByTags AWS failed.err: [%v], tags[%v]", err, tags)
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (p *AWSCloud) GetInstancesByCluster(regionId, clusterName string) (instances []cloud.Instance, err error) {
+	return p.GetInstancesByTags(regionId, []cloud.Tag{{
+		Key:   cloud.ClusterName,
+		Value: clusterName,
+	}})
+}
+
+// describeInstances 已终止的实例仍会在一段时间内可见, 查询时排除
+func (p *AWSCloud) describeInstances(filters []*ec2.Filter) (instances []cloud.Instance, err error) {
+	filters = append(filters, &ec2.Filter{
+		Name: awssdk.String("instance-state-name"),
+		Values: awssdk.StringSlice([]string{
+			InstancePending, InstanceRunning, InstanceShuttingDown, InstanceStopping, InstanceStopped,
+		}),
+	})
+	input := &ec2.DescribeInstancesInput{Filters: filters, MaxResults: awssdk.Int64(PageSize)}
+	err = p.ec2Client.DescribeInstancesPages(input, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range output.Reservations {
+			instances = append(instances, generateInstances(reservation.Instances)...)
+		}
+		return true
+	})
+	return instances, err
+}
+
+var instanceStatus = map[string]string{
+	InstancePending: cloud.Pending,
+	InstanceRunning: cloud.Running,
+	InstanceStopped: cloud.Stopped,
+}
+
+func generateInstances(cloudInstance []*ec2.Instance) (instances []cloud.Instance) {
+	for _, instance := range cloudInstance {
+		groupIds := make([]string, 0, len(instance.SecurityGroups))
+		for _, group := range instance.SecurityGroups {
+			groupIds = append(groupIds, awssdk.StringValue(group.GroupId))
+		}
+		costWay := cloud.InstanceChargeTypePostPaid
+		if awssdk.StringValue(instance.InstanceLifecycle) == LifecycleSpot {
+			costWay = cloud.InstanceChargeTypeSpotPaid
+		}
+		status := ""
+		if instance.State != nil {
+			status = awssdk.StringValue(instance.State.Name)
+		}
+		if s, ok := instanceStatus[status]; ok {
+			status = s
+		}
+		instances = append(instances, cloud.Instance{
+			Id:       awssdk.StringValue(instance.InstanceId),
+			CostWay:  costWay,
+			Provider: CloudName,
+			IpInner:  awssdk.StringValue(instance.PrivateIpAddress),
+			IpOuter:  awssdk.StringValue(instance.PublicIpAddress),
+			Network: &cloud.Network{
+				VpcId:         awssdk.StringValue(instance.VpcId),
+				SubnetId:      awssdk.StringValue(instance.SubnetId),
+				SecurityGroup: strings.Join(groupIds, ","),
+			},
+			ImageId: awssdk.StringValue(instance.ImageId),
+			Status:  status,
+		})
+	}
+	return
+}
+
+// BatchDelete 某一批释放失败时继续释放其余批次, 返回所有失败批次的实例 ID
+func (p *AWSCloud) BatchDelete(ids []string, regionId string) error {
+	deleteErr := &cloud.BatchDeleteError{}
+	for _, onceIds := range utils.StringSliceSplit(ids, PageSize) {
+		_, err := p.ec2Client.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: awssdk.StringSlice(onceIds)})
+		if err != nil {
+			logs.Logger.Errorf("BatchDelete AWS failed.err: [%v], ids[%v]", err, onceIds)
+			deleteErr.Add(onceIds, err)
+		}
+	}
+	return deleteErr.ErrOrNil()
+}
+
+func (p *AWSCloud) StartInstance(id string) error {
+	_, err := p.ec2Client.StartInstances(&ec2.StartInstancesInput{InstanceIds: awssdk.StringSlice([]string{id})})
+	if err != nil {
+		logs.Logger.Errorf("StartInstance AWS failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	return nil
+}
+
+func (p *AWSCloud) StopInstance(id string) error {
+	_, err := p.ec2Client.StopInstances(&ec2.StopInstancesInput{InstanceIds: awssdk.StringSlice([]string{id})})
+	if err != nil {
+		logs.Logger.Errorf("StopInstance AWS failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	return nil
+}
+
+func nameTag(tags []*ec2.Tag) string {
+	for _, tag := range tags {
+		if awssdk.StringValue(tag.Key) == NameTag {
+			return awssdk.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+func nameTagSpecification(resourceType, name string) []*ec2.TagSpecification {
+	if name == "" {
+		return nil
+	}
+	return []*ec2.TagSpecification{{
+		ResourceType: awssdk.String(resourceType),
+		Tags:         []*ec2.Tag{{Key: awssdk.String(NameTag), Value: awssdk.String(name)}},
+	}}
+}
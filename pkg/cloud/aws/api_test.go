@@ -0,0 +1,256 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+// ec2Mock 本地模拟 EC2 Query 接口, 按 Action 返回预置的 XML 并记录请求参数
+type ec2Mock struct {
+	mu        sync.Mutex
+	responses map[string]string
+	requests  map[string][]url.Values
+	// fail 请求参数满足条件时返回错误
+	fail map[string]func(params url.Values) bool
+}
+
+func newMock(t *testing.T, responses map[string]string) (*AWSCloud, *ec2Mock) {
+	s := &ec2Mock{responses: responses, requests: make(map[string][]url.Values)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	p, err := NewWithEndpoint("ak", "sk", DefaultRegion, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, s
+}
+
+func (s *ec2Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	action := r.PostForm.Get("Action")
+	s.mu.Lock()
+	s.requests[action] = append(s.requests[action], r.PostForm)
+	resp, ok := s.responses[action]
+	if fail, found := s.fail[action]; found && fail(r.PostForm) {
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<Response><Errors><Error><Code>InvalidAction</Code><Message>` + action +
+			`</Message></Error></Errors><RequestID>req-1</RequestID></Response>`))
+		return
+	}
+	_, _ = w.Write([]byte(`<` + action + `Response>` + resp + `</` + action + `Response>`))
+}
+
+func (s *ec2Mock) lastRequest(action string) url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := s.requests[action]
+	if len(reqs) == 0 {
+		return nil
+	}
+	return reqs[len(reqs)-1]
+}
+
+func TestBatchCreate(t *testing.T) {
+	p, s := newMock(t, map[string]string{
+		"DescribeImages": `<imagesSet><item><imageId>ami-1</imageId><rootDeviceName>/dev/xvda</rootDeviceName></item></imagesSet>`,
+		"RunInstances":   `<instancesSet><item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item></instancesSet>`,
+	})
+	ids, err := p.BatchCreate(cloud.Params{
+		InstanceType: "t3.medium",
+		ImageId:      "ami-1",
+		Zone:         "us-east-1a",
+		Network: &cloud.Network{
+			SubnetId:                "subnet-1",
+			SecurityGroup:           "sg-1,sg-2",
+			InternetMaxBandwidthOut: 1,
+		},
+		Disks: &cloud.Disks{
+			SystemDisk: cloud.DiskConf{Category: "gp3", Size: 40},
+			DataDisk:   []cloud.DiskConf{{Category: "gp3", Size: 100}},
+		},
+		Tags:        []cloud.Tag{{Key: cloud.ClusterName, Value: "c1"}},
+		UserData:    "#!/bin/bash",
+		KeyPairName: "ops",
+	}, 2)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("BatchCreate got %v, %v", ids, err)
+	}
+	req := s.lastRequest("RunInstances")
+	expected := map[string]string{
+		"MinCount":                                    "2",
+		"MaxCount":                                    "2",
+		"Placement.AvailabilityZone":                  "us-east-1a",
+		"NetworkInterface.1.SubnetId":                 "subnet-1",
+		"NetworkInterface.1.SecurityGroupId.2":        "sg-2",
+		"NetworkInterface.1.AssociatePublicIpAddress": "true",
+		"BlockDeviceMapping.1.DeviceName":             "/dev/xvda",
+		"BlockDeviceMapping.1.Ebs.VolumeSize":         "40",
+		"BlockDeviceMapping.2.DeviceName":             "/dev/sdb",
+		"TagSpecification.1.ResourceType":             "instance",
+		"TagSpecification.1.Tag.1.Key":                cloud.ClusterName,
+		"TagSpecification.1.Tag.2.Key":                NameTag,
+		"TagSpecification.1.Tag.2.Value":              "c1",
+		"UserData":                                    "IyEvYmluL2Jhc2g=",
+		"KeyName":                                     "ops",
+	}
+	for key, value := range expected {
+		if req.Get(key) != value {
+			t.Errorf("%s got %q, want %q", key, req.Get(key), value)
+		}
+	}
+}
+
+func TestBatchCreateSpot(t *testing.T) {
+	p, s := newMock(t, map[string]string{
+		"RunInstances": `<instancesSet><item><instanceId>i-1</instanceId></item></instancesSet>`,
+	})
+	_, err := p.BatchCreate(cloud.Params{ImageId: "ami-1", Spot: &cloud.SpotOption{}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := s.lastRequest("RunInstances")
+	if req.Get("InstanceMarketOptions.MarketType") != "spot" || req.Get("InstanceMarketOptions.SpotOptions.MaxPrice") != "" ||
+		req.Get("InstanceMarketOptions.SpotOptions.InstanceInterruptionBehavior") != "terminate" {
+		t.Errorf("unexpected request: %v", req)
+	}
+}
+
+func TestKeyPair(t *testing.T) {
+	p, s := newMock(t, map[string]string{
+		"ImportKeyPair":    `<keyPairId>key-1</keyPairId><keyName>ops</keyName>`,
+		"DescribeKeyPairs": `<keySet><item><keyPairId>key-1</keyPairId><keyName>ops</keyName><keyFingerprint>ab:cd</keyFingerprint></item></keySet>`,
+	})
+	res, err := p.ImportKeyPair(cloud.ImportKeyPairRequest{KeyPairName: "ops", PublicKey: "ssh-rsa AAAA"})
+	if err != nil || res.KeyPairId != "key-1" {
+		t.Fatalf("ImportKeyPair got %v, %v", res, err)
+	}
+	if req := s.lastRequest("ImportKeyPair"); req.Get("PublicKeyMaterial") != "c3NoLXJzYSBBQUFB" {
+		t.Errorf("PublicKeyMaterial got %q", req.Get("PublicKeyMaterial"))
+	}
+	keyPairs, err := p.DescribeKeyPairs(cloud.DescribeKeyPairsRequest{})
+	if err != nil || len(keyPairs.KeyPairs) != 1 || keyPairs.KeyPairs[0].Fingerprint != "ab:cd" {
+		t.Errorf("DescribeKeyPairs got %v, %v", keyPairs, err)
+	}
+}
+
+func TestGetInstancesByCluster(t *testing.T) {
+	p, s := newMock(t, map[string]string{
+		"DescribeInstances": `<reservationSet><item><instancesSet>
+			<item><instanceId>i-1</instanceId><imageId>ami-1</imageId><instanceState><name>running</name></instanceState>
+				<privateIpAddress>10.0.0.2</privateIpAddress><ipAddress>3.3.3.3</ipAddress><vpcId>vpc-1</vpcId><subnetId>subnet-1</subnetId>
+				<groupSet><item><groupId>sg-1</groupId></item></groupSet></item>
+			<item><instanceId>i-2</instanceId><instanceState><name>pending</name></instanceState><instanceLifecycle>spot</instanceLifecycle></item>
+		</instancesSet></item></reservationSet>`,
+	})
+	instances, err := p.GetInstancesByCluster(DefaultRegion, "c1")
+	if err != nil || len(instances) != 2 {
+		t.Fatalf("GetInstancesByCluster got %v, %v", instances, err)
+	}
+	req := s.lastRequest("DescribeInstances")
+	if req.Get("Filter.1.Name") != "tag:"+cloud.ClusterName || req.Get("Filter.2.Name") != "instance-state-name" {
+		t.Errorf("filters got %v", req)
+	}
+	if ins := instances[0]; ins.Status != cloud.Running || ins.IpInner != "10.0.0.2" || ins.IpOuter != "3.3.3.3" ||
+		ins.CostWay != "PostPaid" || ins.Network.SecurityGroup != "sg-1" {
+		t.Errorf("instance got %+v", ins)
+	}
+	if ins := instances[1]; ins.Status != cloud.Pending || ins.CostWay != "SpotPaid" {
+		t.Errorf("instance got %+v", ins)
+	}
+}
+
+func TestBatchDeleteError(t *testing.T) {
+	p, s := newMock(t, map[string]string{"TerminateInstances": `<instancesSet/>`})
+	s.fail = map[string]func(url.Values) bool{
+		"TerminateInstances": func(params url.Values) bool {
+			return params.Get(fmt.Sprintf("InstanceId.%d", PageSize)) != ""
+		},
+	}
+	ids := make([]string, 0, PageSize+2)
+	for i := 0; i < PageSize+2; i++ {
+		ids = append(ids, fmt.Sprintf("i-%d", i))
+	}
+	err := p.BatchDelete(ids, DefaultRegion)
+	deleteErr := &cloud.BatchDeleteError{}
+	if !errors.As(err, &deleteErr) {
+		t.Fatalf("want BatchDeleteError, got %v", err)
+	}
+	if !reflect.DeepEqual(deleteErr.FailedIds, ids[:PageSize]) {
+		t.Errorf("failed ids got %v", deleteErr.FailedIds)
+	}
+	if reqs := len(s.requests["TerminateInstances"]); reqs != 2 {
+		t.Errorf("want 2 TerminateInstances requests, got %d", reqs)
+	}
+	if err = p.BatchDelete(ids[PageSize:], DefaultRegion); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
+
+func TestSecurityGroupRule(t *testing.T) {
+	p, s := newMock(t, map[string]string{
+		"AuthorizeSecurityGroupIngress": `<return>true</return>`,
+		"DescribeSecurityGroups": `<securityGroupInfo><item><groupId>sg-1</groupId><vpcId>vpc-1</vpcId>
+			<ipPermissions><item><ipProtocol>tcp</ipProtocol><fromPort>22</fromPort><toPort>22</toPort>
+				<ipRanges><item><cidrIp>0.0.0.0/0</cidrIp></item><item><cidrIp>10.0.0.0/8</cidrIp></item></ipRanges></item></ipPermissions>
+			<ipPermissionsEgress><item><ipProtocol>-1</ipProtocol><ipRanges><item><cidrIp>0.0.0.0/0</cidrIp></item></ipRanges></item></ipPermissionsEgress>
+		</item></securityGroupInfo>`,
+	})
+	err := p.AddIngressSecurityGroupRule(cloud.AddSecurityGroupRuleRequest{
+		SecurityGroupId: "sg-1", IpProtocol: "TCP", PortRange: "8000/9000", GroupId: "sg-2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := s.lastRequest("AuthorizeSecurityGroupIngress")
+	if req.Get("IpPermissions.1.IpProtocol") != "tcp" || req.Get("IpPermissions.1.FromPort") != "8000" ||
+		req.Get("IpPermissions.1.ToPort") != "9000" || req.Get("IpPermissions.1.Groups.1.GroupId") != "sg-2" {
+		t.Errorf("permission got %v", req)
+	}
+	res, err := p.DescribeGroupRules(cloud.DescribeGroupRulesRequest{SecurityGroupId: "sg-1"})
+	if err != nil || len(res.Rules) != 3 {
+		t.Fatalf("DescribeGroupRules got %v, %v", res, err)
+	}
+	if rule := res.Rules[1]; rule.PortRange != "22/22" || rule.CidrIp != "10.0.0.0/8" || rule.Direction != DirectionIn {
+		t.Errorf("ingress rule got %+v", rule)
+	}
+	if rule := res.Rules[2]; rule.Protocol != "all" || rule.PortRange != "-1/-1" || rule.Direction != DirectionOut {
+		t.Errorf("egress rule got %+v", rule)
+	}
+}
+
+func TestDescribeAvailableResource(t *testing.T) {
+	p, _ := newMock(t, map[string]string{
+		"DescribeInstanceTypeOfferings": `<instanceTypeOfferingSet>
+			<item><instanceType>t3.medium</instanceType><locationType>availability-zone</locationType><location>us-east-1a</location></item>
+			<item><instanceType>t3.large</instanceType><locationType>availability-zone</locationType><location>us-east-1a</location></item>
+			<item><instanceType>t3.medium</instanceType><locationType>availability-zone</locationType><location>us-east-1b</location></item>
+		</instanceTypeOfferingSet>`,
+	})
+	res, err := p.DescribeAvailableResource(cloud.DescribeAvailableResourceRequest{RegionId: DefaultRegion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.InstanceTypes["us-east-1a"]) != 2 || len(res.InstanceTypes["us-east-1b"]) != 1 {
+		t.Errorf("instance types got %v", res.InstanceTypes)
+	}
+}
@@ -0,0 +1,38 @@
+package aws
+
+const (
+	CloudName     = "AWS"
+	DefaultRegion = "us-east-1"
+	PageSize      = 100
+	NameTag       = "Name"
+)
+
+// 实例状态
+const (
+	InstancePending      = "pending"
+	InstanceRunning      = "running"
+	InstanceShuttingDown = "shutting-down"
+	InstanceStopping     = "stopping"
+	InstanceStopped      = "stopped"
+	InstanceTerminated   = "terminated"
+)
+
+const (
+	LifecycleSpot  = "spot"
+	StateAvailable = "available"
+	ProtocolAll    = "-1"
+	DirectionIn    = "ingress"
+	DirectionOut   = "egress"
+	PlatformWin    = "windows"
+	// DataDiskDevice 数据盘依次挂载为 /dev/sdb, /dev/sdc ...
+	DataDiskDevice = "/dev/sd"
+)
+
+// ImageOwners 公共镜像只列出 Amazon 和 Canonical 发布的常用系统
+var ImageOwners = []string{"amazon", "099720109477"}
+
+var ImageNames = []string{
+	"amzn2-ami-hvm-*-x86_64-gp2",
+	"ubuntu/images/hvm-ssd/ubuntu-focal-20.04-amd64-server-*",
+	"Windows_Server-2019-English-Full-Base-*",
+}
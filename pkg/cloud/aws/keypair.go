@@ -0,0 +1,63 @@
+package aws
+
+import (
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func (p *AWSCloud) CreateKeyPair(req cloud.CreateKeyPairRequest) (cloud.CreateKeyPairResponse, error) {
+	output, err := p.ec2Client.CreateKeyPair(&ec2.CreateKeyPairInput{KeyName: awssdk.String(req.KeyPairName)})
+	if err != nil {
+		logs.Logger.Errorf("CreateKeyPair AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateKeyPairResponse{}, err
+	}
+	return cloud.CreateKeyPairResponse{
+		KeyPairId:   awssdk.StringValue(output.KeyPairId),
+		KeyPairName: awssdk.StringValue(output.KeyName),
+		PrivateKey:  awssdk.StringValue(output.KeyMaterial),
+	}, nil
+}
+
+func (p *AWSCloud) ImportKeyPair(req cloud.ImportKeyPairRequest) (cloud.ImportKeyPairResponse, error) {
+	output, err := p.ec2Client.ImportKeyPair(&ec2.ImportKeyPairInput{
+		KeyName:           awssdk.String(req.KeyPairName),
+		PublicKeyMaterial: []byte(req.PublicKey),
+	})
+	if err != nil {
+		logs.Logger.Errorf("ImportKeyPair AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.ImportKeyPairResponse{}, err
+	}
+	return cloud.ImportKeyPairResponse{
+		KeyPairId:   awssdk.StringValue(output.KeyPairId),
+		KeyPairName: awssdk.StringValue(output.KeyName),
+	}, nil
+}
+
+// DescribeKeyPairs EC2 一次返回地域内全部密钥对, 无需分页
+func (p *AWSCloud) DescribeKeyPairs(req cloud.DescribeKeyPairsRequest) (cloud.DescribeKeyPairsResponse, error) {
+	output, err := p.ec2Client.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		logs.Logger.Errorf("DescribeKeyPairs AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeKeyPairsResponse{}, err
+	}
+	keyPairs := make([]cloud.KeyPair, 0, len(output.KeyPairs))
+	for _, keyPair := range output.KeyPairs {
+		keyPairs = append(keyPairs, cloud.KeyPair{
+			KeyPairId:   awssdk.StringValue(keyPair.KeyPairId),
+			KeyPairName: awssdk.StringValue(keyPair.KeyName),
+			Fingerprint: awssdk.StringValue(keyPair.KeyFingerprint),
+		})
+	}
+	return cloud.DescribeKeyPairsResponse{KeyPairs: keyPairs}, nil
+}
+
+func (p *AWSCloud) DeleteKeyPair(req cloud.DeleteKeyPairRequest) error {
+	_, err := p.ec2Client.DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyName: awssdk.String(req.KeyPairName)})
+	if err != nil {
+		logs.Logger.Errorf("DeleteKeyPair AWS failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package aws
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func toVpcStatus(state string) string {
+	if state == StateAvailable {
+		return cloud.VPCStatusAvailable
+	}
+	return cloud.VPCStatusPending
+}
+
+func (p *AWSCloud) CreateVPC(req cloud.CreateVpcRequest) (cloud.CreateVpcResponse, error) {
+	output, err := p.ec2Client.CreateVpc(&ec2.CreateVpcInput{
+		CidrBlock:         awssdk.String(req.CidrBlock),
+		TagSpecifications: nameTagSpecification(ec2.ResourceTypeVpc, req.VpcName),
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateVPC AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcResponse{}, err
+	}
+	if output.Vpc == nil {
+		return cloud.CreateVpcResponse{}, nil
+	}
+	return cloud.CreateVpcResponse{VpcId: awssdk.StringValue(output.Vpc.VpcId)}, nil
+}
+
+func (p *AWSCloud) GetVPC(req cloud.GetVpcRequest) (cloud.GetVpcResponse, error) {
+	output, err := p.ec2Client.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: awssdk.StringSlice([]string{req.VpcId})})
+	if err != nil {
+		logs.Logger.Errorf("GetVPC AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.GetVpcResponse{}, err
+	}
+	if len(output.Vpcs) == 0 {
+		return cloud.GetVpcResponse{}, nil
+	}
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.GetVpcResponse{}, err
+	}
+	switchIds := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		switchIds = append(switchIds, awssdk.StringValue(subnet.SubnetId))
+	}
+	vpc := toVpc(output.Vpcs[0], req.RegionId)
+	vpc.SwitchIds = switchIds
+	return cloud.GetVpcResponse{Vpc: vpc}, nil
+}
+
+func toVpc(vpc *ec2.Vpc, regionId string) cloud.VPC {
+	return cloud.VPC{
+		VpcId:     awssdk.StringValue(vpc.VpcId),
+		VpcName:   nameTag(vpc.Tags),
+		CidrBlock: awssdk.StringValue(vpc.CidrBlock),
+		RegionId:  regionId,
+		Status:    toVpcStatus(awssdk.StringValue(vpc.State)),
+	}
+}
+
+func (p *AWSCloud) DescribeVpcs(req cloud.DescribeVpcsRequest) (cloud.DescribeVpcsResponse, error) {
+	vpcs := make([]cloud.VPC, 0, 128)
+	input := &ec2.DescribeVpcsInput{MaxResults: awssdk.Int64(PageSize)}
+	err := p.ec2Client.DescribeVpcsPages(input, func(output *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		for _, vpc := range output.Vpcs {
+			vpcs = append(vpcs, toVpc(vpc, req.RegionId))
+		}
+		return true
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeVpcs AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeVpcsResponse{}, err
+	}
+	return cloud.DescribeVpcsResponse{Vpcs: vpcs}, nil
+}
+
+func (p *AWSCloud) CreateSwitch(req cloud.CreateSwitchRequest) (cloud.CreateSwitchResponse, error) {
+	output, err := p.ec2Client.CreateSubnet(&ec2.CreateSubnetInput{
+		VpcId:             awssdk.String(req.VpcId),
+		CidrBlock:         awssdk.String(req.CidrBlock),
+		AvailabilityZone:  awssdk.String(req.ZoneId),
+		TagSpecifications: nameTagSpecification(ec2.ResourceTypeSubnet, req.VSwitchName),
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateSwitch AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSwitchResponse{}, err
+	}
+	if output.Subnet == nil {
+		return cloud.CreateSwitchResponse{}, nil
+	}
+	return cloud.CreateSwitchResponse{SwitchId: awssdk.StringValue(output.Subnet.SubnetId)}, nil
+}
+
+func (p *AWSCloud) GetSwitch(req cloud.GetSwitchRequest) (cloud.GetSwitchResponse, error) {
+	output, err := p.ec2Client.DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: awssdk.StringSlice([]string{req.SwitchId})})
+	if err != nil {
+		logs.Logger.Errorf("GetSwitch AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.GetSwitchResponse{}, err
+	}
+	if len(output.Subnets) == 0 {
+		return cloud.GetSwitchResponse{}, nil
+	}
+	return cloud.GetSwitchResponse{Switch: toSwitch(output.Subnets[0])}, nil
+}
+
+func (p *AWSCloud) DescribeSwitches(req cloud.DescribeSwitchesRequest) (cloud.DescribeSwitchesResponse, error) {
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.DescribeSwitchesResponse{}, err
+	}
+	switches := make([]cloud.Switch, 0, len(subnets))
+	for _, subnet := range subnets {
+		switches = append(switches, toSwitch(subnet))
+	}
+	return cloud.DescribeSwitchesResponse{Switches: switches}, nil
+}
+
+func (p *AWSCloud) listSubnets(vpcId string) ([]*ec2.Subnet, error) {
+	subnets := make([]*ec2.Subnet, 0, PageSize)
+	input := &ec2.DescribeSubnetsInput{
+		Filters:    []*ec2.Filter{{Name: awssdk.String("vpc-id"), Values: awssdk.StringSlice([]string{vpcId})}},
+		MaxResults: awssdk.Int64(PageSize),
+	}
+	err := p.ec2Client.DescribeSubnetsPages(input, func(output *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		subnets = append(subnets, output.Subnets...)
+		return true
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeSubnets AWS failed.err: [%v], vpcId[%s]", err, vpcId)
+		return nil, err
+	}
+	return subnets, nil
+}
+
+func toSwitch(subnet *ec2.Subnet) cloud.Switch {
+	isDefault := 0
+	if awssdk.BoolValue(subnet.DefaultForAz) {
+		isDefault = 1
+	}
+	return cloud.Switch{
+		VpcId:                   awssdk.StringValue(subnet.VpcId),
+		SwitchId:                awssdk.StringValue(subnet.SubnetId),
+		Name:                    nameTag(subnet.Tags),
+		IsDefault:               isDefault,
+		AvailableIpAddressCount: int(awssdk.Int64Value(subnet.AvailableIpAddressCount)),
+		VStatus:                 toVpcStatus(awssdk.StringValue(subnet.State)),
+		ZoneId:                  awssdk.StringValue(subnet.AvailabilityZone),
+		CidrBlock:               awssdk.StringValue(subnet.CidrBlock),
+	}
+}
+
+func (p *AWSCloud) CreateSecurityGroup(req cloud.CreateSecurityGroupRequest) (cloud.CreateSecurityGroupResponse, error) {
+	output, err := p.ec2Client.CreateSecurityGroup(&ec2.CreateSecurityGroupInput{
+		GroupName:   awssdk.String(req.SecurityGroupName),
+		Description: awssdk.String(req.SecurityGroupName),
+		VpcId:       awssdk.String(req.VpcId),
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateSecurityGroup AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSecurityGroupResponse{}, err
+	}
+	return cloud.CreateSecurityGroupResponse{SecurityGroupId: awssdk.StringValue(output.GroupId)}, nil
+}
+
+func (p *AWSCloud) AddIngressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	permission, err := toPermission(req)
+	if err != nil {
+		return err
+	}
+	_, err = p.ec2Client.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:       awssdk.String(req.SecurityGroupId),
+		IpPermissions: []*ec2.IpPermission{permission},
+	})
+	if err != nil {
+		logs.Logger.Errorf("AddIngressSecurityGroupRule AWS failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
+func (p *AWSCloud) AddEgressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	permission, err := toPermission(req)
+	if err != nil {
+		return err
+	}
+	_, err = p.ec2Client.AuthorizeSecurityGroupEgress(&ec2.AuthorizeSecurityGroupEgressInput{
+		GroupId:       awssdk.String(req.SecurityGroupId),
+		IpPermissions: []*ec2.IpPermission{permission},
+	})
+	if err != nil {
+		logs.Logger.Errorf("AddEgressSecurityGroupRule AWS failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
+func toPermission(req cloud.AddSecurityGroupRuleRequest) (*ec2.IpPermission, error) {
+	protocol := strings.ToLower(req.IpProtocol)
+	if protocol == "all" || protocol == "" {
+		protocol = ProtocolAll
+	}
+	permission := &ec2.IpPermission{IpProtocol: awssdk.String(protocol)}
+	if protocol != ProtocolAll {
+		from, to, err := parsePortRange(req.PortRange)
+		if err != nil {
+			return nil, err
+		}
+		permission.FromPort = awssdk.Int64(from)
+		permission.ToPort = awssdk.Int64(to)
+	}
+	switch {
+	case req.GroupId != "":
+		permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{{GroupId: awssdk.String(req.GroupId)}}
+	case req.PrefixListId != "":
+		permission.PrefixListIds = []*ec2.PrefixListId{{PrefixListId: awssdk.String(req.PrefixListId)}}
+	default:
+		permission.IpRanges = []*ec2.IpRange{{CidrIp: awssdk.String(req.CidrIp)}}
+	}
+	return permission, nil
+}
+
+func parsePortRange(portRange string) (int64, int64, error) {
+	if portRange == "" {
+		return -1, -1, nil
+	}
+	ports := strings.Split(portRange, "/")
+	if len(ports) != 2 {
+		return 0, 0, errors.New("invalid port range: " + portRange)
+	}
+	from, err := strconv.ParseInt(ports[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := strconv.ParseInt(ports[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+func formatPortRange(from, to *int64) string {
+	if from == nil || to == nil {
+		return "-1/-1"
+	}
+	return strconv.FormatInt(*from, 10) + "/" + strconv.FormatInt(*to, 10)
+}
+
+func (p *AWSCloud) DescribeSecurityGroups(req cloud.DescribeSecurityGroupsRequest) (cloud.DescribeSecurityGroupsResponse, error) {
+	input := &ec2.DescribeSecurityGroupsInput{MaxResults: awssdk.Int64(PageSize)}
+	if req.VpcId != "" {
+		input.Filters = []*ec2.Filter{{Name: awssdk.String("vpc-id"), Values: awssdk.StringSlice([]string{req.VpcId})}}
+	}
+	groups := make([]cloud.SecurityGroup, 0, 128)
+	err := p.ec2Client.DescribeSecurityGroupsPages(input, func(output *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+		for _, group := range output.SecurityGroups {
+			groups = append(groups, cloud.SecurityGroup{
+				SecurityGroupId:   awssdk.StringValue(group.GroupId),
+				SecurityGroupName: awssdk.StringValue(group.GroupName),
+				VpcId:             awssdk.StringValue(group.VpcId),
+				RegionId:          req.RegionId,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeSecurityGroups AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeSecurityGroupsResponse{}, err
+	}
+	return cloud.DescribeSecurityGroupsResponse{Groups: groups}, nil
+}
+
+func (p *AWSCloud) DescribeGroupRules(req cloud.DescribeGroupRulesRequest) (cloud.DescribeGroupRulesResponse, error) {
+	output, err := p.ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		GroupIds: awssdk.StringSlice([]string{req.SecurityGroupId}),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeGroupRules AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeGroupRulesResponse{}, err
+	}
+	rules := make([]cloud.SecurityGroupRule, 0)
+	for _, group := range output.SecurityGroups {
+		vpcId := awssdk.StringValue(group.VpcId)
+		rules = append(rules, toRules(vpcId, req.SecurityGroupId, DirectionIn, group.IpPermissions)...)
+		rules = append(rules, toRules(vpcId, req.SecurityGroupId, DirectionOut, group.IpPermissionsEgress)...)
+	}
+	return cloud.DescribeGroupRulesResponse{Rules: rules}, nil
+}
+
+// toRules 一条 IpPermission 可包含多个来源, 按来源拆分为多条规则
+func toRules(vpcId, groupId, direction string, permissions []*ec2.IpPermission) []cloud.SecurityGroupRule {
+	rules := make([]cloud.SecurityGroupRule, 0, len(permissions))
+	for _, permission := range permissions {
+		protocol := awssdk.StringValue(permission.IpProtocol)
+		if protocol == ProtocolAll {
+			protocol = "all"
+		}
+		rule := cloud.SecurityGroupRule{
+			VpcId:           vpcId,
+			SecurityGroupId: groupId,
+			PortRange:       formatPortRange(permission.FromPort, permission.ToPort),
+			Protocol:        protocol,
+			Direction:       direction,
+		}
+		for _, ipRange := range permission.IpRanges {
+			r := rule
+			r.CidrIp = awssdk.StringValue(ipRange.CidrIp)
+			rules = append(rules, r)
+		}
+		for _, pair := range permission.UserIdGroupPairs {
+			r := rule
+			r.GroupId = awssdk.StringValue(pair.GroupId)
+			rules = append(rules, r)
+		}
+		for _, prefixList := range permission.PrefixListIds {
+			r := rule
+			r.PrefixListId = awssdk.StringValue(prefixList.PrefixListId)
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
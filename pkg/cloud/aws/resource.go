@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+func (p *AWSCloud) GetRegions() (cloud.GetRegionsResponse, error) {
+	output, err := p.ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		logs.Logger.Errorf("GetRegions AWS failed.err: [%v]", err)
+		return cloud.GetRegionsResponse{}, err
+	}
+	regions := make([]cloud.Region, 0, len(output.Regions))
+	for _, region := range output.Regions {
+		regions = append(regions, cloud.Region{
+			RegionId:  awssdk.StringValue(region.RegionName),
+			LocalName: awssdk.StringValue(region.RegionName),
+		})
+	}
+	return cloud.GetRegionsResponse{Regions: regions}, nil
+}
+
+// GetZones 可用区随客户端所在地域返回
+func (p *AWSCloud) GetZones(req cloud.GetZonesRequest) (cloud.GetZonesResponse, error) {
+	output, err := p.ec2Client.DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{
+		Filters: []*ec2.Filter{{Name: awssdk.String("state"), Values: awssdk.StringSlice([]string{StateAvailable})}},
+	})
+	if err != nil {
+		logs.Logger.Errorf("GetZones AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.GetZonesResponse{}, err
+	}
+	zones := make([]cloud.Zone, 0, len(output.AvailabilityZones))
+	for _, zone := range output.AvailabilityZones {
+		zones = append(zones, cloud.Zone{
+			ZoneId:    awssdk.StringValue(zone.ZoneName),
+			LocalName: awssdk.StringValue(zone.ZoneName),
+		})
+	}
+	return cloud.GetZonesResponse{Zones: zones}, nil
+}
+
+// DescribeAvailableResource EC2 不返回库存信息, 可用区内提供的规格均视为有货
+func (p *AWSCloud) DescribeAvailableResource(req cloud.DescribeAvailableResourceRequest) (cloud.DescribeAvailableResourceResponse, error) {
+	input := &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: awssdk.String(ec2.LocationTypeAvailabilityZone),
+		MaxResults:   awssdk.Int64(1000),
+	}
+	if req.ZoneId != "" {
+		input.Filters = []*ec2.Filter{{Name: awssdk.String("location"), Values: awssdk.StringSlice([]string{req.ZoneId})}}
+	}
+	res := make(map[string][]cloud.InstanceType)
+	err := p.ec2Client.DescribeInstanceTypeOfferingsPages(input, func(output *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+		for _, offering := range output.InstanceTypeOfferings {
+			zoneId := awssdk.StringValue(offering.Location)
+			res[zoneId] = append(res[zoneId], cloud.InstanceType{
+				Status:         "Available",
+				StatusCategory: "WithStock",
+				Value:          awssdk.StringValue(offering.InstanceType),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeAvailableResource AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeAvailableResourceResponse{}, err
+	}
+	return cloud.DescribeAvailableResourceResponse{InstanceTypes: res}, nil
+}
+
+func (p *AWSCloud) DescribeInstanceTypes(req cloud.DescribeInstanceTypesRequest) (cloud.DescribeInstanceTypesResponse, error) {
+	infos := make([]cloud.InstanceInfo, 0, len(req.TypeName))
+	for _, names := range utils.StringSliceSplit(req.TypeName, PageSize) {
+		output, err := p.ec2Client.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+			InstanceTypes: awssdk.StringSlice(names),
+		})
+		if err != nil {
+			logs.Logger.Errorf("DescribeInstanceTypes AWS failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeInstanceTypesResponse{}, err
+		}
+		for _, info := range output.InstanceTypes {
+			typeName := awssdk.StringValue(info.InstanceType)
+			insInfo := cloud.InstanceInfo{
+				Family:      strings.SplitN(typeName, ".", 2)[0],
+				InsTypeName: typeName,
+			}
+			if info.VCpuInfo != nil {
+				insInfo.Core = int(awssdk.Int64Value(info.VCpuInfo.DefaultVCpus))
+			}
+			if info.MemoryInfo != nil {
+				insInfo.Memory = int(awssdk.Int64Value(info.MemoryInfo.SizeInMiB) / 1024)
+			}
+			infos = append(infos, insInfo)
+		}
+	}
+	return cloud.DescribeInstanceTypesResponse{Infos: infos}, nil
+}
+
+func (p *AWSCloud) DescribeImages(req cloud.DescribeImagesRequest) (cloud.DescribeImagesResponse, error) {
+	output, err := p.ec2Client.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: awssdk.StringSlice(ImageOwners),
+		Filters: []*ec2.Filter{
+			{Name: awssdk.String("name"), Values: awssdk.StringSlice(ImageNames)},
+			{Name: awssdk.String("state"), Values: awssdk.StringSlice([]string{StateAvailable})},
+			{Name: awssdk.String("architecture"), Values: awssdk.StringSlice([]string{ec2.ArchitectureValuesX8664})},
+		},
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeImages AWS failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeImagesResponse{}, err
+	}
+	images := make([]cloud.Image, 0, len(output.Images))
+	for _, img := range output.Images {
+		osType := "linux"
+		if awssdk.StringValue(img.Platform) == PlatformWin {
+			osType = "windows"
+		}
+		images = append(images, cloud.Image{
+			OsType:  osType,
+			OsName:  awssdk.StringValue(img.Name),
+			ImageId: awssdk.StringValue(img.ImageId),
+		})
+	}
+	return cloud.DescribeImagesResponse{Images: images}, nil
+}
+
+// GetOrders EC2 按需实例不产生订单, 费用数据后续通过 Cost Explorer 接入
+func (p *AWSCloud) GetOrders(req cloud.GetOrdersRequest) (cloud.GetOrdersResponse, error) {
+	return cloud.GetOrdersResponse{}, nil
+}
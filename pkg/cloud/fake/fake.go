@@ -0,0 +1,155 @@
+package fake
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const (
+	CloudName     = "Fake"
+	DefaultRegion = "fake-region-1"
+	// DefaultPendingDuration 实例创建后保持 Pending 的时长
+	DefaultPendingDuration = 2 * time.Second
+)
+
+var (
+	ErrInjected      = errors.New("fake cloud: injected failure")
+	ErrPartialCreate = errors.New("fake cloud: partial create")
+	ErrNotFound      = errors.New("fake cloud: resource not found")
+	ErrPrePaidDelete = errors.New("fake cloud: prepaid instance can not be deleted")
+	ErrKeyPairExists = errors.New("fake cloud: key pair already exists")
+)
+
+func init() {
+	cloud.RegisterProviderDriver(CloudName, newDriver, DefaultRegion)
+}
+
+func newDriver(ak, sk, region string) (cloud.Provider, error) {
+	return New(ak, sk, region), nil
+}
+
+// FakeCloud 进程内的云厂商实现, 同一个 ak 下的所有客户端共享一份数据
+type FakeCloud struct {
+	region string
+	s      *store
+}
+
+var (
+	stores   = map[string]*store{}
+	storesMu sync.Mutex
+)
+
+func New(AK, SK, regionId string) *FakeCloud {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	s, ok := stores[AK]
+	if !ok {
+		s = newStore()
+		stores[AK] = s
+	}
+	return &FakeCloud{region: regionId, s: s}
+}
+
+// Reset 清空所有账号下的数据和故障配置
+func Reset() {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	stores = map[string]*store{}
+}
+
+func (*FakeCloud) ProviderType() string {
+	return CloudName
+}
+
+// SetLatency 每次调用前的固定延迟
+func (p *FakeCloud) SetLatency(d time.Duration) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.s.latency = d
+}
+
+// SetPendingDuration 实例从 Pending 变为 Running 所需时长
+func (p *FakeCloud) SetPendingDuration(d time.Duration) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.s.pendingDuration = d
+}
+
+// InjectError 接下来 times 次调用 method 返回 err, times < 0 表示一直失败, err 为空时使用 ErrInjected
+func (p *FakeCloud) InjectError(method string, err error, times int) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if err == nil {
+		err = ErrInjected
+	}
+	p.s.faults[method] = &fault{err: err, times: times}
+}
+
+// ClearErrors 清除所有注入的错误
+func (p *FakeCloud) ClearErrors() {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.s.faults = map[string]*fault{}
+}
+
+// SetCreateLimit 单次 BatchCreate 最多创建 n 台, 不足时返回已创建的 id 和 ErrPartialCreate, n <= 0 表示不限制
+func (p *FakeCloud) SetCreateLimit(n int) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.s.createLimit = n
+}
+
+// SetDropCreatedIds 为 true 时 BatchCreate 实际创建了实例但不返回 id, 模拟请求超时
+func (p *FakeCloud) SetDropCreatedIds(drop bool) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.s.dropCreatedIds = drop
+}
+
+// SetSoldOut 设置可用区内某个规格售罄, BatchCreate 返回 cloud.ErrInsufficientStock, zone 或 instanceType 为空表示全部
+func (p *FakeCloud) SetSoldOut(zone, instanceType string, soldOut bool) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	key := soldOutKey(zone, instanceType)
+	if soldOut {
+		p.s.soldOut[key] = true
+	} else {
+		delete(p.s.soldOut, key)
+	}
+}
+
+func soldOutKey(zone, instanceType string) string {
+	return zone + "/" + instanceType
+}
+
+// isSoldOut 调用方需持有锁
+func (s *store) isSoldOut(zone, instanceType string) bool {
+	return s.soldOut[soldOutKey(zone, instanceType)] || s.soldOut[soldOutKey(zone, "")] ||
+		s.soldOut[soldOutKey("", instanceType)] || s.soldOut[soldOutKey("", "")]
+}
+
+type fault struct {
+	err   error
+	times int
+}
+
+// call 模拟一次 API 调用: 先等待延迟, 再检查是否有注入的错误
+func (p *FakeCloud) call(method string) error {
+	p.s.mu.Lock()
+	latency := p.s.latency
+	var err error
+	if f, ok := p.s.faults[method]; ok && f.times != 0 {
+		err = f.err
+		if f.times > 0 {
+			f.times--
+		}
+	}
+	p.s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+package fake
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func newTestCloud(t *testing.T) *FakeCloud {
+	t.Cleanup(Reset)
+	p := New("ak", "sk", DefaultRegion)
+	p.SetPendingDuration(0)
+	return p
+}
+
+func createNetwork(t *testing.T, p *FakeCloud, cidr string) *cloud.Network {
+	vpcRes, err := p.CreateVPC(cloud.CreateVpcRequest{RegionId: DefaultRegion, VpcName: "vpc", CidrBlock: "10.0.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	swRes, err := p.CreateSwitch(cloud.CreateSwitchRequest{RegionId: DefaultRegion, VpcId: vpcRes.VpcId, CidrBlock: cidr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sgRes, err := p.CreateSecurityGroup(cloud.CreateSecurityGroupRequest{RegionId: DefaultRegion, VpcId: vpcRes.VpcId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cloud.Network{VpcId: vpcRes.VpcId, SubnetId: swRes.SwitchId, SecurityGroup: sgRes.SecurityGroupId}
+}
+
+func TestBatchCreateAndQuery(t *testing.T) {
+	p := newTestCloud(t)
+	network := createNetwork(t, p, "10.0.1.0/24")
+	tags := []cloud.Tag{{Key: cloud.ClusterName, Value: "c1"}, {Key: cloud.TaskId, Value: "1"}}
+	ids, err := p.BatchCreate(cloud.Params{Network: network, Region: DefaultRegion, Tags: tags}, 3)
+	if err != nil || len(ids) != 3 {
+		t.Fatalf("BatchCreate got %v, %v", ids, err)
+	}
+	instances, _ := p.GetInstances(ids)
+	for _, ins := range instances {
+		if ins.Status != cloud.Running || ins.IpInner == "" {
+			t.Errorf("instance %s not running: %+v", ins.Id, ins)
+		}
+	}
+	byCluster, _ := p.GetInstancesByCluster(DefaultRegion, "c1")
+	if len(byCluster) != 3 {
+		t.Errorf("GetInstancesByCluster got %d, want 3", len(byCluster))
+	}
+	byTag, _ := p.GetInstancesByTags(DefaultRegion, []cloud.Tag{{Key: cloud.TaskId, Value: "2"}})
+	if len(byTag) != 0 {
+		t.Errorf("GetInstancesByTags got %d, want 0", len(byTag))
+	}
+	sw, _ := p.GetSwitch(cloud.GetSwitchRequest{SwitchId: network.SubnetId})
+	if sw.Switch.AvailableIpAddressCount != 253-3 {
+		t.Errorf("AvailableIpAddressCount got %d", sw.Switch.AvailableIpAddressCount)
+	}
+	if err = p.BatchDelete(ids[:2], DefaultRegion); err != nil {
+		t.Fatal(err)
+	}
+	byCluster, _ = p.GetInstancesByCluster(DefaultRegion, "c1")
+	if len(byCluster) != 1 {
+		t.Errorf("after delete got %d, want 1", len(byCluster))
+	}
+}
+
+func TestPendingToRunning(t *testing.T) {
+	p := newTestCloud(t)
+	p.SetPendingDuration(50 * time.Millisecond)
+	ids, _ := p.BatchCreate(cloud.Params{}, 1)
+	instances, _ := p.GetInstances(ids)
+	if instances[0].Status != cloud.Pending || instances[0].IpInner != "" {
+		t.Errorf("want pending without ip, got %+v", instances[0])
+	}
+	time.Sleep(60 * time.Millisecond)
+	instances, _ = p.GetInstances(ids)
+	if instances[0].Status != cloud.Running || instances[0].IpInner == "" {
+		t.Errorf("want running with ip, got %+v", instances[0])
+	}
+}
+
+func TestReclaimSpotInstances(t *testing.T) {
+	p := newTestCloud(t)
+	onDemand, _ := p.BatchCreate(cloud.Params{}, 1)
+	spot, _ := p.BatchCreate(cloud.Params{Spot: &cloud.SpotOption{PriceLimit: 0.5}}, 2)
+	if instances, _ := p.GetInstances(spot); instances[0].CostWay != "SpotPaid" {
+		t.Errorf("spot instance got %+v", instances[0])
+	}
+	reclaimed := p.ReclaimSpotInstances(DefaultRegion)
+	if len(reclaimed) != 2 || reclaimed[0] != spot[0] {
+		t.Errorf("reclaimed got %v, want %v", reclaimed, spot)
+	}
+	all, _ := p.GetInstancesByTags("", nil)
+	if len(all) != 1 || all[0].Id != onDemand[0] {
+		t.Errorf("after reclaim got %v", all)
+	}
+}
+
+func TestUserData(t *testing.T) {
+	p := newTestCloud(t)
+	ids, _ := p.BatchCreate(cloud.Params{UserData: "#!/bin/bash\necho ok"}, 1)
+	if userData, err := p.UserData(ids[0]); err != nil || userData != "#!/bin/bash\necho ok" {
+		t.Errorf("user data got %q, %v", userData, err)
+	}
+	if _, err := p.UserData("i-none"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want not found, got %v", err)
+	}
+}
+
+func TestPrePaidDelete(t *testing.T) {
+	p := newTestCloud(t)
+	postPaid, _ := p.BatchCreate(cloud.Params{}, 1)
+	prePaid, _ := p.BatchCreate(cloud.Params{ChargeType: cloud.InstanceChargeTypePrePaid, Period: 1, PeriodUnit: cloud.PeriodUnitMonth}, 1)
+	if err := p.BatchDelete(append(postPaid, prePaid...), DefaultRegion); !errors.Is(err, ErrPrePaidDelete) {
+		t.Errorf("want prepaid delete error, got %v", err)
+	}
+	if all, _ := p.GetInstancesByTags("", nil); len(all) != 2 {
+		t.Errorf("failed batch should delete nothing, got %v", all)
+	}
+}
+
+func TestKeyPair(t *testing.T) {
+	p := newTestCloud(t)
+	if _, err := p.BatchCreate(cloud.Params{KeyPairName: "ops"}, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("BatchCreate with missing key pair got %v", err)
+	}
+	created, err := p.CreateKeyPair(cloud.CreateKeyPairRequest{KeyPairName: "ops"})
+	if err != nil || created.PrivateKey == "" {
+		t.Fatalf("CreateKeyPair got %v, %v", created, err)
+	}
+	if _, err = p.ImportKeyPair(cloud.ImportKeyPairRequest{KeyPairName: "ops", PublicKey: "ssh-rsa AAAA"}); !errors.Is(err, ErrKeyPairExists) {
+		t.Errorf("ImportKeyPair duplicate got %v", err)
+	}
+	if _, err = p.BatchCreate(cloud.Params{KeyPairName: "ops"}, 1); err != nil {
+		t.Errorf("BatchCreate with key pair got %v", err)
+	}
+	if err = p.DeleteKeyPair(cloud.DeleteKeyPairRequest{KeyPairName: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := p.DescribeKeyPairs(cloud.DescribeKeyPairsRequest{RegionId: DefaultRegion})
+	if len(res.KeyPairs) != 0 {
+		t.Errorf("DescribeKeyPairs got %v", res.KeyPairs)
+	}
+}
+
+func TestSoldOut(t *testing.T) {
+	p := newTestCloud(t)
+	p.SetSoldOut("zone-a", "", true)
+	_, err := p.BatchCreate(cloud.Params{Zone: "zone-a", InstanceType: "t1"}, 1)
+	if !cloud.IsInsufficientStock(err) {
+		t.Fatalf("BatchCreate in sold out zone got %v", err)
+	}
+	if ids, err := p.BatchCreate(cloud.Params{Zone: "zone-b", InstanceType: "t1"}, 1); err != nil || len(ids) != 1 {
+		t.Errorf("BatchCreate in other zone got %v, %v", ids, err)
+	}
+	p.SetSoldOut("zone-a", "", false)
+	if _, err = p.BatchCreate(cloud.Params{Zone: "zone-a", InstanceType: "t1"}, 1); err != nil {
+		t.Errorf("BatchCreate after restock got %v", err)
+	}
+}
+
+func TestDryRunCreate(t *testing.T) {
+	p := newTestCloud(t)
+	var _ cloud.DryRunCreator = p
+	p.SetSoldOut("zone-a", "t1", true)
+	if err := p.DryRunCreate(cloud.Params{Zone: "zone-a", InstanceType: "t1"}, 1); !cloud.IsInsufficientStock(err) {
+		t.Errorf("DryRunCreate in sold out zone got %v", err)
+	}
+	if err := p.DryRunCreate(cloud.Params{Zone: "zone-a", InstanceType: "t2"}, 1); err != nil {
+		t.Errorf("DryRunCreate with other type got %v", err)
+	}
+	if instances, _ := p.GetInstancesByTags(DefaultRegion, nil); len(instances) != 0 {
+		t.Errorf("DryRunCreate created %d instances", len(instances))
+	}
+}
+
+func TestFaults(t *testing.T) {
+	p := newTestCloud(t)
+
+	p.SetCreateLimit(2)
+	ids, err := p.BatchCreate(cloud.Params{}, 5)
+	if !errors.Is(err, ErrPartialCreate) || len(ids) != 2 {
+		t.Errorf("partial create got %v, %v", ids, err)
+	}
+	p.SetCreateLimit(0)
+
+	p.SetDropCreatedIds(true)
+	ids, err = p.BatchCreate(cloud.Params{}, 1)
+	if err == nil || len(ids) != 0 {
+		t.Errorf("drop ids got %v, %v", ids, err)
+	}
+	p.SetDropCreatedIds(false)
+	all, _ := p.GetInstancesByTags("", nil)
+	if len(all) != 3 {
+		t.Errorf("instances in cloud got %d, want 3", len(all))
+	}
+
+	p.InjectError("GetInstances", nil, 1)
+	if _, err = p.GetInstances([]string{"x"}); !errors.Is(err, ErrInjected) {
+		t.Errorf("want injected error, got %v", err)
+	}
+	if _, err = p.GetInstances([]string{"x"}); err != nil {
+		t.Errorf("want recovered, got %v", err)
+	}
+}
+
+func TestSharedStoreByAccount(t *testing.T) {
+	p := newTestCloud(t)
+	ids, _ := p.BatchCreate(cloud.Params{}, 1)
+	other := New("ak", "sk", "fake-region-2")
+	if instances, _ := other.GetInstances(ids); len(instances) != 1 {
+		t.Errorf("same account should share instances")
+	}
+	if instances, _ := New("ak2", "sk", DefaultRegion).GetInstances(ids); len(instances) != 0 {
+		t.Errorf("different account should not share instances")
+	}
+}
+
+func TestIpReleasedOnDelete(t *testing.T) {
+	p := newTestCloud(t)
+	network := createNetwork(t, p, "10.0.1.0/28")
+	network.InternetMaxBandwidthOut = 1
+	for i := 0; i < 300; i++ {
+		ids, err := p.BatchCreate(cloud.Params{Network: network, Region: DefaultRegion}, 5)
+		if err != nil || len(ids) != 5 {
+			t.Fatalf("cycle %d BatchCreate got %v, %v", i, ids, err)
+		}
+		instances, _ := p.GetInstances(ids)
+		seen := make(map[string]bool, len(instances))
+		for _, ins := range instances {
+			if ins.IpInner == "" || ins.IpOuter == "" || seen[ins.IpInner] {
+				t.Fatalf("cycle %d got instance %+v", i, ins)
+			}
+			seen[ins.IpInner] = true
+		}
+		if err = p.BatchDelete(ids, DefaultRegion); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sw, _ := p.GetSwitch(cloud.GetSwitchRequest{SwitchId: network.SubnetId})
+	if sw.Switch.AvailableIpAddressCount != 13 {
+		t.Errorf("AvailableIpAddressCount got %d, want 13", sw.Switch.AvailableIpAddressCount)
+	}
+	ids, err := p.BatchCreate(cloud.Params{Network: network, Region: DefaultRegion}, 14)
+	if !errors.Is(err, ErrPartialCreate) || len(ids) != 13 {
+		t.Errorf("want 13 instances and ErrPartialCreate, got %d, %v", len(ids), err)
+	}
+}
@@ -0,0 +1,230 @@
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+// BatchCreate 受 SetCreateLimit/SetDropCreatedIds 控制, 可以模拟部分成功和丢失返回值
+func (p *FakeCloud) BatchCreate(m cloud.Params, num int) (instanceIds []string, err error) {
+	if err = p.call("BatchCreate"); err != nil {
+		return nil, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+
+	created := num
+	if p.s.createLimit > 0 && created > p.s.createLimit {
+		created = p.s.createLimit
+	}
+	network := cloud.Network{}
+	if m.Network != nil {
+		network = *m.Network
+	}
+	cidr := defaultCidr
+	if sw, ok := p.s.switches[network.SubnetId]; ok {
+		cidr = sw.CidrBlock
+	}
+	region := m.Region
+	if region == "" {
+		region = p.region
+	}
+	if m.KeyPairName != "" && p.s.keyPairs[keyPairKey(region, m.KeyPairName)] == nil {
+		return nil, ErrNotFound
+	}
+	if p.s.isSoldOut(m.Zone, m.InstanceType) {
+		return nil, fmt.Errorf("fake cloud: %s %s: %w", m.Zone, m.InstanceType, cloud.ErrInsufficientStock)
+	}
+	costWay := cloud.InstanceChargeTypePostPaid
+	if m.ChargeType == cloud.InstanceChargeTypePrePaid {
+		costWay = cloud.InstanceChargeTypePrePaid
+	} else if m.Spot != nil {
+		costWay = cloud.InstanceChargeTypeSpotPaid
+	}
+	now := time.Now()
+	instanceIds = make([]string, 0, created)
+	for i := 0; i < created; i++ {
+		ipInner := p.s.allocIp(cidr)
+		if ipInner == "" {
+			err = ErrPartialCreate
+			break
+		}
+		ipOuter := ""
+		if network.InternetMaxBandwidthOut > 0 {
+			ipOuter = p.s.allocIp(publicCidr)
+		}
+		id := p.s.nextId("i")
+		ins := &instance{
+			Instance: cloud.Instance{
+				Id:       id,
+				CostWay:  costWay,
+				Provider: CloudName,
+				IpInner:  ipInner,
+				IpOuter:  ipOuter,
+				ImageId:  m.ImageId,
+				Network:  &network,
+			},
+			cidr:     cidr,
+			region:   region,
+			zone:     m.Zone,
+			tags:     append([]cloud.Tag{}, m.Tags...),
+			createAt: now,
+			userData: m.UserData,
+		}
+		p.s.instances[id] = ins
+		instanceIds = append(instanceIds, id)
+	}
+	if len(instanceIds) < num {
+		err = ErrPartialCreate
+	}
+	if p.s.dropCreatedIds {
+		return nil, ErrInjected
+	}
+	return instanceIds, err
+}
+
+// DryRunCreate 只检查密钥对和售罄设置, 不创建实例
+func (p *FakeCloud) DryRunCreate(m cloud.Params, num int) error {
+	if err := p.call("DryRunCreate"); err != nil {
+		return err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	region := m.Region
+	if region == "" {
+		region = p.region
+	}
+	if m.KeyPairName != "" && p.s.keyPairs[keyPairKey(region, m.KeyPairName)] == nil {
+		return ErrNotFound
+	}
+	if p.s.isSoldOut(m.Zone, m.InstanceType) {
+		return fmt.Errorf("fake cloud: %s %s: %w", m.Zone, m.InstanceType, cloud.ErrInsufficientStock)
+	}
+	return nil
+}
+
+func (p *FakeCloud) GetInstances(ids []string) (instances []cloud.Instance, err error) {
+	if err = p.call("GetInstances"); err != nil {
+		return nil, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	for _, id := range ids {
+		if ins, ok := p.s.instances[id]; ok {
+			instances = append(instances, p.s.view(ins))
+		}
+	}
+	return instances, nil
+}
+
+func (p *FakeCloud) GetInstancesByTags(region string, tags []cloud.Tag) (instances []cloud.Instance, err error) {
+	if err = p.call("GetInstancesByTags"); err != nil {
+		return nil, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	for _, ins := range p.s.instances {
+		if region != "" && ins.region != region {
+			continue
+		}
+		if matchTags(ins.tags, tags) {
+			instances = append(instances, p.s.view(ins))
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Id < instances[j].Id
+	})
+	return instances, nil
+}
+
+func (p *FakeCloud) GetInstancesByCluster(regionId, clusterName string) (instances []cloud.Instance, err error) {
+	return p.GetInstancesByTags(regionId, []cloud.Tag{{
+		Key:   cloud.ClusterName,
+		Value: clusterName,
+	}})
+}
+
+func matchTags(have, want []cloud.Tag) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h.Key == w.Key && h.Value == w.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// BatchDelete 不存在的实例直接忽略, 与云厂商强制删除的行为一致; 包含包年包月实例时整批失败
+func (p *FakeCloud) BatchDelete(ids []string, regionId string) error {
+	if err := p.call("BatchDelete"); err != nil {
+		return err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	for _, id := range ids {
+		if ins, ok := p.s.instances[id]; ok && ins.CostWay == cloud.InstanceChargeTypePrePaid {
+			return ErrPrePaidDelete
+		}
+	}
+	for _, id := range ids {
+		p.s.removeInstance(id)
+	}
+	return nil
+}
+
+// UserData 返回创建实例时传入的用户数据
+func (p *FakeCloud) UserData(id string) (string, error) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	ins, ok := p.s.instances[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return ins.userData, nil
+}
+
+// ReclaimSpotInstances 释放 region 下所有抢占式实例, 模拟云厂商回收, 返回被回收的实例 id
+func (p *FakeCloud) ReclaimSpotInstances(region string) []string {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	ids := make([]string, 0)
+	for id, ins := range p.s.instances {
+		if ins.CostWay == cloud.InstanceChargeTypeSpotPaid && (region == "" || ins.region == region) {
+			p.s.removeInstance(id)
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (p *FakeCloud) StartInstance(id string) error {
+	return p.setStopped("StartInstance", id, false)
+}
+
+func (p *FakeCloud) StopInstance(id string) error {
+	return p.setStopped("StopInstance", id, true)
+}
+
+func (p *FakeCloud) setStopped(method, id string, stopped bool) error {
+	if err := p.call(method); err != nil {
+		return err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	ins, ok := p.s.instances[id]
+	if !ok {
+		return ErrNotFound
+	}
+	ins.stopped = stopped
+	return nil
+}
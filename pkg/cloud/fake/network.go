@@ -0,0 +1,237 @@
+package fake
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const timeLayout = "2006-01-02T15:04:05Z"
+
+func (p *FakeCloud) CreateVPC(req cloud.CreateVpcRequest) (cloud.CreateVpcResponse, error) {
+	if err := p.call("CreateVPC"); err != nil {
+		return cloud.CreateVpcResponse{}, err
+	}
+	if _, _, err := net.ParseCIDR(req.CidrBlock); err != nil {
+		return cloud.CreateVpcResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	id := p.s.nextId("vpc")
+	p.s.vpcs[id] = &vpc{VPC: cloud.VPC{
+		VpcId:     id,
+		VpcName:   req.VpcName,
+		CidrBlock: req.CidrBlock,
+		RegionId:  req.RegionId,
+		Status:    cloud.VPCStatusAvailable,
+		CreateAt:  time.Now().UTC().Format(timeLayout),
+	}}
+	return cloud.CreateVpcResponse{VpcId: id, RequestId: p.s.nextId("req")}, nil
+}
+
+func (p *FakeCloud) GetVPC(req cloud.GetVpcRequest) (cloud.GetVpcResponse, error) {
+	if err := p.call("GetVPC"); err != nil {
+		return cloud.GetVpcResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	v, ok := p.s.vpcs[req.VpcId]
+	if !ok {
+		return cloud.GetVpcResponse{}, ErrNotFound
+	}
+	return cloud.GetVpcResponse{Vpc: p.s.vpcView(v)}, nil
+}
+
+// vpcView 调用方需持有锁
+func (s *store) vpcView(v *vpc) cloud.VPC {
+	res := v.VPC
+	res.SwitchIds = make([]string, 0)
+	for id, sw := range s.switches {
+		if sw.VpcId == v.VpcId {
+			res.SwitchIds = append(res.SwitchIds, id)
+		}
+	}
+	sort.Strings(res.SwitchIds)
+	return res
+}
+
+func (p *FakeCloud) DescribeVpcs(req cloud.DescribeVpcsRequest) (cloud.DescribeVpcsResponse, error) {
+	if err := p.call("DescribeVpcs"); err != nil {
+		return cloud.DescribeVpcsResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	vpcs := make([]cloud.VPC, 0, len(p.s.vpcs))
+	for _, v := range p.s.vpcs {
+		if req.RegionId != "" && v.RegionId != req.RegionId {
+			continue
+		}
+		vpcs = append(vpcs, p.s.vpcView(v))
+	}
+	sort.Slice(vpcs, func(i, j int) bool {
+		return vpcs[i].VpcId < vpcs[j].VpcId
+	})
+	return cloud.DescribeVpcsResponse{Vpcs: vpcs}, nil
+}
+
+func (p *FakeCloud) CreateSwitch(req cloud.CreateSwitchRequest) (cloud.CreateSwitchResponse, error) {
+	if err := p.call("CreateSwitch"); err != nil {
+		return cloud.CreateSwitchResponse{}, err
+	}
+	if _, _, err := net.ParseCIDR(req.CidrBlock); err != nil {
+		return cloud.CreateSwitchResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if _, ok := p.s.vpcs[req.VpcId]; !ok {
+		return cloud.CreateSwitchResponse{}, ErrNotFound
+	}
+	id := p.s.nextId("vsw")
+	p.s.switches[id] = &vswitch{
+		Switch: cloud.Switch{
+			VpcId:     req.VpcId,
+			SwitchId:  id,
+			Name:      req.VSwitchName,
+			VStatus:   cloud.VPCStatusAvailable,
+			CreateAt:  time.Now().UTC().Format(timeLayout),
+			ZoneId:    req.ZoneId,
+			CidrBlock: req.CidrBlock,
+		},
+		regionId: req.RegionId,
+	}
+	return cloud.CreateSwitchResponse{SwitchId: id, RequestId: p.s.nextId("req")}, nil
+}
+
+func (p *FakeCloud) GetSwitch(req cloud.GetSwitchRequest) (cloud.GetSwitchResponse, error) {
+	if err := p.call("GetSwitch"); err != nil {
+		return cloud.GetSwitchResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	sw, ok := p.s.switches[req.SwitchId]
+	if !ok {
+		return cloud.GetSwitchResponse{}, ErrNotFound
+	}
+	return cloud.GetSwitchResponse{Switch: p.s.switchView(sw)}, nil
+}
+
+// switchView 可用 IP 数为网段容量减去子网内的实例数, 调用方需持有锁
+func (s *store) switchView(sw *vswitch) cloud.Switch {
+	res := sw.Switch
+	used := 0
+	for _, ins := range s.instances {
+		if ins.Network.SubnetId == sw.SwitchId {
+			used++
+		}
+	}
+	res.AvailableIpAddressCount = hostCount(sw.CidrBlock) - used
+	return res
+}
+
+func (p *FakeCloud) DescribeSwitches(req cloud.DescribeSwitchesRequest) (cloud.DescribeSwitchesResponse, error) {
+	if err := p.call("DescribeSwitches"); err != nil {
+		return cloud.DescribeSwitchesResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	switches := make([]cloud.Switch, 0)
+	for _, sw := range p.s.switches {
+		if sw.VpcId == req.VpcId {
+			switches = append(switches, p.s.switchView(sw))
+		}
+	}
+	sort.Slice(switches, func(i, j int) bool {
+		return switches[i].SwitchId < switches[j].SwitchId
+	})
+	return cloud.DescribeSwitchesResponse{Switches: switches}, nil
+}
+
+func (p *FakeCloud) CreateSecurityGroup(req cloud.CreateSecurityGroupRequest) (cloud.CreateSecurityGroupResponse, error) {
+	if err := p.call("CreateSecurityGroup"); err != nil {
+		return cloud.CreateSecurityGroupResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if _, ok := p.s.vpcs[req.VpcId]; !ok {
+		return cloud.CreateSecurityGroupResponse{}, ErrNotFound
+	}
+	id := p.s.nextId("sg")
+	p.s.groups[id] = &securityGroup{SecurityGroup: cloud.SecurityGroup{
+		SecurityGroupId:   id,
+		SecurityGroupType: req.SecurityGroupType,
+		SecurityGroupName: req.SecurityGroupName,
+		CreateAt:          time.Now().UTC().Format(timeLayout),
+		VpcId:             req.VpcId,
+		RegionId:          req.RegionId,
+	}}
+	return cloud.CreateSecurityGroupResponse{SecurityGroupId: id, RequestId: p.s.nextId("req")}, nil
+}
+
+func (p *FakeCloud) AddIngressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	return p.addRule("AddIngressSecurityGroupRule", req, "ingress")
+}
+
+func (p *FakeCloud) AddEgressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	return p.addRule("AddEgressSecurityGroupRule", req, "egress")
+}
+
+func (p *FakeCloud) addRule(method string, req cloud.AddSecurityGroupRuleRequest, direction string) error {
+	if err := p.call(method); err != nil {
+		return err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	group, ok := p.s.groups[req.SecurityGroupId]
+	if !ok {
+		return ErrNotFound
+	}
+	group.rules = append(group.rules, cloud.SecurityGroupRule{
+		VpcId:           group.VpcId,
+		SecurityGroupId: group.SecurityGroupId,
+		PortRange:       req.PortRange,
+		Protocol:        req.IpProtocol,
+		Direction:       direction,
+		GroupId:         req.GroupId,
+		CidrIp:          req.CidrIp,
+		PrefixListId:    req.PrefixListId,
+		CreateAt:        time.Now().UTC().Format(timeLayout),
+	})
+	return nil
+}
+
+func (p *FakeCloud) DescribeSecurityGroups(req cloud.DescribeSecurityGroupsRequest) (cloud.DescribeSecurityGroupsResponse, error) {
+	if err := p.call("DescribeSecurityGroups"); err != nil {
+		return cloud.DescribeSecurityGroupsResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	groups := make([]cloud.SecurityGroup, 0)
+	for _, group := range p.s.groups {
+		if req.VpcId != "" && group.VpcId != req.VpcId {
+			continue
+		}
+		if req.RegionId != "" && group.RegionId != req.RegionId {
+			continue
+		}
+		groups = append(groups, group.SecurityGroup)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].SecurityGroupId < groups[j].SecurityGroupId
+	})
+	return cloud.DescribeSecurityGroupsResponse{Groups: groups}, nil
+}
+
+func (p *FakeCloud) DescribeGroupRules(req cloud.DescribeGroupRulesRequest) (cloud.DescribeGroupRulesResponse, error) {
+	if err := p.call("DescribeGroupRules"); err != nil {
+		return cloud.DescribeGroupRulesResponse{}, err
+	}
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	group, ok := p.s.groups[req.SecurityGroupId]
+	if !ok {
+		return cloud.DescribeGroupRulesResponse{}, ErrNotFound
+	}
+	return cloud.DescribeGroupRulesResponse{Rules: append([]cloud.SecurityGroupRule{}, group.rules...)}, nil
+}
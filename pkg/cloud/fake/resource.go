@@ -0,0 +1,101 @@
+package fake
+
+import (
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+var regions = []cloud.Region{
+	{RegionId: "fake-region-1", LocalName: "模拟地域一"},
+	{RegionId: "fake-region-2", LocalName: "模拟地域二"},
+}
+
+var zoneSuffixes = []string{"a", "b"}
+
+var instanceTypes = []cloud.InstanceInfo{
+	{Core: 1, Memory: 2, Family: "fake.general", InsTypeName: "fake.small"},
+	{Core: 2, Memory: 4, Family: "fake.general", InsTypeName: "fake.medium"},
+	{Core: 4, Memory: 8, Family: "fake.general", InsTypeName: "fake.large"},
+	{Core: 8, Memory: 32, Family: "fake.memory", InsTypeName: "fake.xlarge"},
+}
+
+var images = []cloud.Image{
+	{OsType: "linux", OsName: "CentOS 7.9 64位", ImageId: "fake-centos-7"},
+	{OsType: "linux", OsName: "Ubuntu 20.04 64位", ImageId: "fake-ubuntu-2004"},
+}
+
+func (p *FakeCloud) GetRegions() (cloud.GetRegionsResponse, error) {
+	if err := p.call("GetRegions"); err != nil {
+		return cloud.GetRegionsResponse{}, err
+	}
+	return cloud.GetRegionsResponse{Regions: append([]cloud.Region{}, regions...)}, nil
+}
+
+func (p *FakeCloud) GetZones(req cloud.GetZonesRequest) (cloud.GetZonesResponse, error) {
+	if err := p.call("GetZones"); err != nil {
+		return cloud.GetZonesResponse{}, err
+	}
+	return cloud.GetZonesResponse{Zones: zones(req.RegionId)}, nil
+}
+
+func zones(regionId string) []cloud.Zone {
+	res := make([]cloud.Zone, 0, len(zoneSuffixes))
+	for _, suffix := range zoneSuffixes {
+		res = append(res, cloud.Zone{
+			ZoneId:    regionId + "-" + suffix,
+			LocalName: regionId + "-" + suffix,
+		})
+	}
+	return res
+}
+
+func (p *FakeCloud) DescribeAvailableResource(req cloud.DescribeAvailableResourceRequest) (cloud.DescribeAvailableResourceResponse, error) {
+	if err := p.call("DescribeAvailableResource"); err != nil {
+		return cloud.DescribeAvailableResourceResponse{}, err
+	}
+	res := make(map[string][]cloud.InstanceType)
+	for _, zone := range zones(req.RegionId) {
+		if req.ZoneId != "" && zone.ZoneId != req.ZoneId {
+			continue
+		}
+		types := make([]cloud.InstanceType, 0, len(instanceTypes))
+		for _, info := range instanceTypes {
+			types = append(types, cloud.InstanceType{
+				Status:         "Available",
+				StatusCategory: "WithStock",
+				Value:          info.InsTypeName,
+			})
+		}
+		res[zone.ZoneId] = types
+	}
+	return cloud.DescribeAvailableResourceResponse{InstanceTypes: res}, nil
+}
+
+func (p *FakeCloud) DescribeInstanceTypes(req cloud.DescribeInstanceTypesRequest) (cloud.DescribeInstanceTypesResponse, error) {
+	if err := p.call("DescribeInstanceTypes"); err != nil {
+		return cloud.DescribeInstanceTypesResponse{}, err
+	}
+	infos := make([]cloud.InstanceInfo, 0, len(req.TypeName))
+	for _, name := range req.TypeName {
+		for _, info := range instanceTypes {
+			if info.InsTypeName == name {
+				infos = append(infos, info)
+			}
+		}
+	}
+	return cloud.DescribeInstanceTypesResponse{Infos: infos}, nil
+}
+
+func (p *FakeCloud) DescribeImages(req cloud.DescribeImagesRequest) (cloud.DescribeImagesResponse, error) {
+	if err := p.call("DescribeImages"); err != nil {
+		return cloud.DescribeImagesResponse{}, err
+	}
+	return cloud.DescribeImagesResponse{Images: append([]cloud.Image{}, images...)}, nil
+}
+
+// GetOrders 模拟云厂商只有按量付费实例, 不产生订单
+func (p *FakeCloud) GetOrders(req cloud.GetOrdersRequest) (cloud.GetOrdersResponse, error) {
+	if err := p.call("GetOrders"); err != nil {
+		return cloud.GetOrdersResponse{}, err
+	}
+	return cloud.GetOrdersResponse{}, nil
+}
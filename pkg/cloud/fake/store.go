@@ -0,0 +1,200 @@
+package fake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const (
+	// defaultCidr 子网不存在时分配内网 IP 的网段
+	defaultCidr = "172.16.0.0/12"
+	// publicCidr 公网 IP 网段
+	publicCidr = "100.64.0.0/10"
+)
+
+type instance struct {
+	cloud.Instance
+	// cidr 分配内网 IP 的网段, 释放实例时归还
+	cidr     string
+	region   string
+	zone     string
+	tags     []cloud.Tag
+	createAt time.Time
+	stopped  bool
+	userData string
+}
+
+type vpc struct {
+	cloud.VPC
+}
+
+type vswitch struct {
+	cloud.Switch
+	regionId string
+}
+
+type securityGroup struct {
+	cloud.SecurityGroup
+	rules []cloud.SecurityGroupRule
+}
+
+type keyPair struct {
+	cloud.KeyPair
+	regionId string
+}
+
+type store struct {
+	mu sync.Mutex
+
+	seq       int64
+	instances map[string]*instance
+	vpcs      map[string]*vpc
+	switches  map[string]*vswitch
+	groups    map[string]*securityGroup
+	keyPairs  map[string]*keyPair
+	ipPools   map[string]*ipPool
+
+	latency         time.Duration
+	pendingDuration time.Duration
+	faults          map[string]*fault
+	createLimit     int
+	dropCreatedIds  bool
+	soldOut         map[string]bool
+}
+
+func newStore() *store {
+	return &store{
+		instances:       map[string]*instance{},
+		vpcs:            map[string]*vpc{},
+		switches:        map[string]*vswitch{},
+		groups:          map[string]*securityGroup{},
+		keyPairs:        map[string]*keyPair{},
+		ipPools:         map[string]*ipPool{},
+		pendingDuration: DefaultPendingDuration,
+		faults:          map[string]*fault{},
+		soldOut:         map[string]bool{},
+	}
+}
+
+// nextId 调用方需持有锁
+func (s *store) nextId(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-fake%08d", prefix, s.seq)
+}
+
+// allocIp 调用方需持有锁
+func (s *store) allocIp(cidr string) string {
+	pool, ok := s.ipPools[cidr]
+	if !ok {
+		var err error
+		pool, err = newIpPool(cidr)
+		if err != nil {
+			return ""
+		}
+		s.ipPools[cidr] = pool
+	}
+	return pool.next()
+}
+
+// removeInstance 删除实例并把 IP 归还到所在网段, 调用方需持有锁
+func (s *store) removeInstance(id string) {
+	ins, ok := s.instances[id]
+	if !ok {
+		return
+	}
+	delete(s.instances, id)
+	if pool, ok := s.ipPools[ins.cidr]; ok {
+		pool.release(ins.IpInner)
+	}
+	if pool, ok := s.ipPools[publicCidr]; ok {
+		pool.release(ins.IpOuter)
+	}
+}
+
+// view 按当前时间计算实例状态, 调用方需持有锁
+func (s *store) view(ins *instance) cloud.Instance {
+	res := ins.Instance
+	network := *ins.Network
+	res.Network = &network
+	switch {
+	case ins.stopped:
+		res.Status = cloud.Stopped
+	case time.Since(ins.createAt) < s.pendingDuration:
+		res.Status = cloud.Pending
+		res.IpInner = ""
+		res.IpOuter = ""
+	default:
+		res.Status = cloud.Running
+	}
+	return res
+}
+
+type ipPool struct {
+	network *net.IPNet
+	offset  uint32
+	size    uint32
+	// free 已归还的地址偏移, 按归还顺序复用
+	free []uint32
+}
+
+func newIpPool(cidr string) (*ipPool, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits != 32 {
+		return nil, fmt.Errorf("only ipv4 is supported: %s", cidr)
+	}
+	return &ipPool{network: ipNet, size: 1 << uint(bits-ones)}, nil
+}
+
+// next 跳过网络地址和网关, 优先复用已归还的地址, 用完返回空字符串
+func (p *ipPool) next() string {
+	if len(p.free) > 0 {
+		offset := p.free[0]
+		p.free = p.free[1:]
+		return p.ip(offset)
+	}
+	if p.offset < 1 {
+		p.offset = 1
+	}
+	if p.offset+1 >= p.size-1 {
+		return ""
+	}
+	p.offset++
+	return p.ip(p.offset)
+}
+
+// release 归还 next 分配的地址, 不属于该网段的地址忽略
+func (p *ipPool) release(addr string) {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil || !p.network.Contains(ip) {
+		return
+	}
+	offset := binary.BigEndian.Uint32(ip) - binary.BigEndian.Uint32(p.network.IP.To4())
+	if offset < 2 || offset > p.offset {
+		return
+	}
+	p.free = append(p.free, offset)
+}
+
+func (p *ipPool) ip(offset uint32) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(p.network.IP.To4())+offset)
+	return ip.String()
+}
+
+// hostCount 网段内可分配的地址数, 去掉网络地址, 网关和广播地址
+func hostCount(cidr string) int {
+	pool, err := newIpPool(cidr)
+	if err != nil || pool.size < 4 {
+		return 0
+	}
+	return int(pool.size) - 3
+}
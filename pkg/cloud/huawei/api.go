@@ -1,40 +1,495 @@
 package huawei
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth/basic"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth/global"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/region"
+	bss "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/bss/v2"
+	bssRegion "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/bss/v2/region"
 	ecs "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
-	region "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/region"
+	ecsRegion "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/region"
+	ims "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ims/v2"
+	imsRegion "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ims/v2/region"
+	vpc "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2"
+	vpcRegion "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/region"
 )
 
 type HuaweiCloud struct {
 	ecsClient *ecs.EcsClient
+	vpcClient *vpc.VpcClient
+	imsClient *ims.ImsClient
+	bssClient *bss.BssClient
 }
 
-func New(AK, SK, regionId string) *HuaweiCloud {
+func init() {
+	cloud.RegisterProviderDriver(CloudName, newDriver, DefaultRegion)
+}
+
+func newDriver(ak, sk, region string) (cloud.Provider, error) {
+	client, err := New(ak, sk, region)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func New(AK, SK, regionId string) (*HuaweiCloud, error) {
+	return NewWithEndpoint(AK, SK, regionId, "")
+}
+
+// NewWithEndpoint endpoint 不为空时所有请求发往该地址, 用于对接本地模拟服务.
+// SDK 在 region 不存在或获取 project id 失败时会 panic, 这里统一转成 error
+func NewWithEndpoint(AK, SK, regionId, endpoint string) (h *HuaweiCloud, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("new HuaweiCloud client failed: %v", e)
+		}
+	}()
 	auth := basic.NewCredentialsBuilder().
 		WithAk(AK).
 		WithSk(SK).
 		Build()
+	globalAuth := global.NewCredentialsBuilder().
+		WithAk(AK).
+		WithSk(SK).
+		Build()
+	build := func(builder *core.HcHttpClientBuilder, r *region.Region) *core.HcHttpClient {
+		if endpoint != "" {
+			return builder.WithEndpoint(endpoint).Build()
+		}
+		return builder.WithRegion(r).Build()
+	}
+
+	ecsClt := ecs.NewEcsClient(build(ecs.EcsClientBuilder().WithCredential(auth), ecsRegion.ValueOf(regionId)))
+	vpcClt := vpc.NewVpcClient(build(vpc.VpcClientBuilder().WithCredential(auth), vpcRegion.ValueOf(regionId)))
+	imsClt := ims.NewImsClient(build(ims.ImsClientBuilder().WithCredential(auth), imsRegion.ValueOf(regionId)))
+	bssClt := bss.NewBssClient(build(bss.BssClientBuilder().WithCredential(globalAuth), bssRegion.ValueOf(BssRegion)))
+	return &HuaweiCloud{ecsClient: ecsClt, vpcClient: vpcClt, imsClient: imsClt, bssClient: bssClt}, nil
+}
+
+func (*HuaweiCloud) ProviderType() string {
+	return CloudName
+}
+
+// BatchCreate 实例名称取集群名, 数量大于 1 时由华为云自动追加序号
+func (p *HuaweiCloud) BatchCreate(m cloud.Params, num int) (instanceIds []string, err error) {
+	count := int32(num)
+	server := &model.PostPaidServer{
+		AvailabilityZone: &m.Zone,
+		Count:            &count,
+		FlavorRef:        m.InstanceType,
+		ImageRef:         m.ImageId,
+		Name:             serverName(m.Tags),
+		Nics:             []model.PostPaidServerNic{{SubnetId: m.Network.SubnetId}},
+		Vpcid:            m.Network.VpcId,
+	}
+	if num > 1 {
+		autoRename := true
+		server.IsAutoRename = &autoRename
+	}
+	if m.KeyPairName != "" {
+		server.KeyName = &m.KeyPairName
+	} else if m.Password != "" {
+		server.AdminPass = &m.Password
+	}
+	if m.UserData != "" {
+		userData := base64.StdEncoding.EncodeToString([]byte(m.UserData))
+		server.UserData = &userData
+	}
+	if m.Spot != nil {
+		marketType := MarketSpot
+		server.Extendparam = &model.PostPaidServerExtendParam{MarketType: &marketType}
+		// 不指定出价时以按需价格作为竞价
+		if m.Spot.PriceLimit > 0 {
+			price := strconv.FormatFloat(m.Spot.PriceLimit, 'f', -1, 64)
+			server.Extendparam.SpotPrice = &price
+		}
+	}
+	if m.Network.SecurityGroup != "" {
+		groups := make([]model.PostPaidServerSecurityGroup, 0)
+		for _, id := range strings.Split(m.Network.SecurityGroup, ",") {
+			groupId := id
+			groups = append(groups, model.PostPaidServerSecurityGroup{Id: &groupId})
+		}
+		server.SecurityGroups = &groups
+	}
+	if m.Network.InternetMaxBandwidthOut != 0 {
+		server.Publicip, err = publicIp(m.Network)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.Disks != nil {
+		server.RootVolume, server.DataVolumes, err = volumes(m.Disks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Tags) > 0 {
+		tags := make([]model.PostPaidServerTag, 0, len(m.Tags))
+		for _, tag := range m.Tags {
+			tags = append(tags, model.PostPaidServerTag{Key: tag.Key, Value: tag.Value})
+		}
+		server.ServerTags = &tags
+	}
+	if m.ChargeType == cloud.InstanceChargeTypePrePaid {
+		return p.createPrePaidServers(server, m)
+	}
 
-	client := ecs.NewEcsClient(
-		ecs.EcsClientBuilder().
-			WithRegion(region.ValueOf(regionId)).
-			WithCredential(auth).
-			Build())
-	return &HuaweiCloud{ecsClient: client}
+	request := &model.CreatePostPaidServersRequest{
+		Body: &model.CreatePostPaidServersRequestBody{Server: server},
+	}
+	response, err := p.ecsClient.CreatePostPaidServers(request)
+	if err != nil {
+		logs.Logger.Errorf("BatchCreate HuaweiCloud failed.err: [%v], req[%v]", err, m)
+		return nil, err
+	}
+	if response.ServerIds != nil {
+		instanceIds = *response.ServerIds
+	}
+	return instanceIds, nil
+}
+
+// createPrePaidServers 包年包月与按需实例的请求体字段相同, 转换后补充订购参数, 订单自动支付
+func (p *HuaweiCloud) createPrePaidServers(server *model.PostPaidServer, m cloud.Params) (instanceIds []string, err error) {
+	periodType := ""
+	switch m.PeriodUnit {
+	case cloud.PeriodUnitMonth:
+		periodType = PeriodMonth
+	case cloud.PeriodUnitYear:
+		periodType = PeriodYear
+	default:
+		return nil, fmt.Errorf("%w: %s", cloud.ErrPeriodUnitNotSupported, m.PeriodUnit)
+	}
+	body, err := json.Marshal(server)
+	if err != nil {
+		return nil, err
+	}
+	prePaidServer := &model.PrePaidServer{}
+	if err = json.Unmarshal(body, prePaidServer); err != nil {
+		return nil, err
+	}
+	extendParam := fmt.Sprintf(`{"chargingMode":%s,"periodType":%s,"periodNum":%d,"isAutoRenew":"%t","isAutoPay":"true"}`,
+		quote(ChargingModePrePaid), quote(periodType), m.Period, m.AutoRenew)
+	prePaidServer.Extendparam = &model.PrePaidServerExtendParam{}
+	if err = json.Unmarshal([]byte(extendParam), prePaidServer.Extendparam); err != nil {
+		return nil, err
+	}
+	request := &model.CreateServersRequest{
+		Body: &model.CreateServersRequestBody{Server: prePaidServer},
+	}
+	response, err := p.ecsClient.CreateServers(request)
+	if err != nil {
+		logs.Logger.Errorf("BatchCreate HuaweiCloud failed.err: [%v], req[%v]", err, m)
+		return nil, err
+	}
+	if response.ServerIds != nil {
+		instanceIds = *response.ServerIds
+	}
+	return instanceIds, nil
+}
+
+func serverName(tags []cloud.Tag) string {
+	for _, tag := range tags {
+		if tag.Key == cloud.ClusterName {
+			return tag.Value
+		}
+	}
+	return "bridgx"
+}
+
+func publicIp(network *cloud.Network) (*model.PostPaidServerPublicip, error) {
+	var shareType model.PostPaidServerEipBandwidthSharetype
+	if err := shareType.UnmarshalJSON([]byte(quote(BandwidthPer))); err != nil {
+		return nil, err
+	}
+	size := int32(network.InternetMaxBandwidthOut)
+	bandwidth := &model.PostPaidServerEipBandwidth{
+		Size:      &size,
+		Sharetype: shareType,
+	}
+	if network.InternetChargeType == PayByTraffic {
+		chargeMode := ChargeByTraffic
+		bandwidth.Chargemode = &chargeMode
+	}
+	return &model.PostPaidServerPublicip{
+		Eip: &model.PostPaidServerEip{
+			Iptype:    EipType,
+			Bandwidth: bandwidth,
+		},
+	}, nil
+}
+
+func volumes(disks *cloud.Disks) (*model.PostPaidServerRootVolume, *[]model.PostPaidServerDataVolume, error) {
+	var rootType model.PostPaidServerRootVolumeVolumetype
+	if err := rootType.UnmarshalJSON([]byte(quote(disks.SystemDisk.Category))); err != nil {
+		return nil, nil, err
+	}
+	rootSize := int32(disks.SystemDisk.Size)
+	root := &model.PostPaidServerRootVolume{Volumetype: rootType}
+	if rootSize > 0 {
+		root.Size = &rootSize
+	}
+	if len(disks.DataDisk) == 0 {
+		return root, nil, nil
+	}
+	dataVolumes := make([]model.PostPaidServerDataVolume, 0, len(disks.DataDisk))
+	for _, disk := range disks.DataDisk {
+		var dataType model.PostPaidServerDataVolumeVolumetype
+		if err := dataType.UnmarshalJSON([]byte(quote(disk.Category))); err != nil {
+			return nil, nil, err
+		}
+		dataVolumes = append(dataVolumes, model.PostPaidServerDataVolume{
+			Volumetype: dataType,
+			Size:       int32(disk.Size),
+		})
+	}
+	return root, &dataVolumes, nil
 }
 
 func (p *HuaweiCloud) GetInstances(ids []string) (instances []cloud.Instance, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	servers, err := p.listServers(&model.ListServersDetailsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	idSet := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		idSet[id] = struct{}{}
+	}
+	matched := make([]model.ServerDetail, 0, len(ids))
+	for _, server := range servers {
+		if _, ok := idSet[server.Id]; ok {
+			matched = append(matched, server)
+		}
+	}
+	return generateInstances(matched), nil
+}
+
+// GetInstancesByTags 服务端只按第一个标签过滤, 其余标签在本地过滤
+func (p *HuaweiCloud) GetInstancesByTags(region string, tags []cloud.Tag) (instances []cloud.Instance, err error) {
 	request := &model.ListServersDetailsRequest{}
-	response, err := p.ecsClient.ListServersDetails(request)
-	if err == nil {
-		fmt.Printf("%+v\n", response)
-	} else {
-		fmt.Println(err)
+	if len(tags) > 0 {
+		tag := tags[0].Key + "=" + tags[0].Value
+		request.Tags = &tag
+	}
+	servers, err := p.listServers(request)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]model.ServerDetail, 0, len(servers))
+	for _, server := range servers {
+		if hasTags(server, tags) {
+			matched = append(matched, server)
+		}
 	}
-	return nil, err
+	return generateInstances(matched), nil
+}
+
+func (p *HuaweiCloud) GetInstancesByCluster(regionId, clusterName string) (instances []cloud.Instance, err error) {
+	return p.GetInstancesByTags(regionId, []cloud.Tag{{
+		Key:   cloud.ClusterName,
+		Value: clusterName,
+	}})
+}
+
+func (p *HuaweiCloud) listServers(request *model.ListServersDetailsRequest) ([]model.ServerDetail, error) {
+	var page int32 = 1
+	limit := int32(PageSize)
+	servers := make([]model.ServerDetail, 0)
+	request.Limit = &limit
+	for {
+		offset := page
+		request.Offset = &offset
+		response, err := p.ecsClient.ListServersDetails(request)
+		if err != nil {
+			logs.Logger.Errorf("ListServersDetails HuaweiCloud failed.err: [%v], req[%v]", err, request)
+			return nil, err
+		}
+		if response.Servers == nil {
+			break
+		}
+		servers = append(servers, *response.Servers...)
+		if len(*response.Servers) < PageSize || response.Count == nil || *response.Count <= page*limit {
+			break
+		}
+		page++
+	}
+	return servers, nil
+}
+
+func hasTags(server model.ServerDetail, tags []cloud.Tag) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if server.Tags == nil {
+		return false
+	}
+	serverTags := make(map[string]struct{}, len(*server.Tags))
+	for _, tag := range *server.Tags {
+		serverTags[tag] = struct{}{}
+	}
+	for _, tag := range tags {
+		if _, ok := serverTags[tag.Key+"="+tag.Value]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var serverStatus = map[string]string{
+	ServerBuild:   cloud.Pending,
+	ServerActive:  cloud.Running,
+	ServerShutoff: cloud.Stopped,
+}
+
+var chargeType = map[string]string{
+	ChargingPostPaid: "PostPaid",
+	ChargingPrePaid:  "PrePaid",
+	ChargingSpot:     "SpotPaid",
+}
+
+func generateInstances(servers []model.ServerDetail) (instances []cloud.Instance) {
+	for _, server := range servers {
+		var vpcId, ipOuter string
+		ipInner := make([]string, 0, 1)
+		for id, addresses := range server.Addresses {
+			vpcId = id
+			for _, address := range addresses {
+				if address.OSEXTIPStype == nil {
+					continue
+				}
+				switch enumValue(address.OSEXTIPStype) {
+				case AddressFixed:
+					ipInner = append(ipInner, address.Addr)
+				case AddressFloating:
+					if ipOuter == "" {
+						ipOuter = address.Addr
+					}
+				}
+			}
+		}
+		groups := make([]string, 0, len(server.SecurityGroups))
+		for _, group := range server.SecurityGroups {
+			groups = append(groups, group.Id)
+		}
+		status, ok := serverStatus[server.Status]
+		if !ok {
+			status = server.Status
+		}
+		imageId := ""
+		if server.Image != nil {
+			imageId = server.Image.Id
+		}
+		instances = append(instances, cloud.Instance{
+			Id:       server.Id,
+			CostWay:  chargeType[server.Metadata["charging_mode"]],
+			Provider: CloudName,
+			IpInner:  strings.Join(ipInner, ","),
+			IpOuter:  ipOuter,
+			ImageId:  imageId,
+			Network: &cloud.Network{
+				VpcId:         vpcId,
+				SecurityGroup: strings.Join(groups, ","),
+			},
+			Status: status,
+		})
+	}
+	return
+}
+
+// BatchDelete 某一批释放失败时继续释放其余批次, 返回所有失败批次的实例 ID
+func (p *HuaweiCloud) BatchDelete(ids []string, regionId string) error {
+	deleteAll := true
+	deleteErr := &cloud.BatchDeleteError{}
+	for _, onceIds := range utils.StringSliceSplit(ids, PageSize) {
+		request := &model.DeleteServersRequest{
+			Body: &model.DeleteServersRequestBody{
+				DeletePublicip: &deleteAll,
+				DeleteVolume:   &deleteAll,
+				Servers:        serverIds(onceIds),
+			},
+		}
+		response, err := p.ecsClient.DeleteServers(request)
+		if err != nil {
+			logs.Logger.Errorf("BatchDelete HuaweiCloud failed.err: [%v], ids[%v]", err, onceIds)
+			deleteErr.Add(onceIds, err)
+			continue
+		}
+		if response.JobId != nil {
+			logs.Logger.Infof("[BatchDelete] jobId: %s", *response.JobId)
+		}
+	}
+	return deleteErr.ErrOrNil()
+}
+
+func (p *HuaweiCloud) StartInstance(id string) error {
+	request := &model.BatchStartServersRequest{
+		Body: &model.BatchStartServersRequestBody{
+			OsStart: &model.BatchStartServersOption{Servers: serverIds([]string{id})},
+		},
+	}
+	response, err := p.ecsClient.BatchStartServers(request)
+	if err != nil {
+		logs.Logger.Errorf("StartInstance HuaweiCloud failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	if response.JobId != nil {
+		logs.Logger.Infof("[StartInstance] jobId: %s", *response.JobId)
+	}
+	return nil
+}
+
+func (p *HuaweiCloud) StopInstance(id string) error {
+	request := &model.BatchStopServersRequest{
+		Body: &model.BatchStopServersRequestBody{
+			OsStop: &model.BatchStopServersOption{Servers: serverIds([]string{id})},
+		},
+	}
+	response, err := p.ecsClient.BatchStopServers(request)
+	if err != nil {
+		logs.Logger.Errorf("StopInstance HuaweiCloud failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	if response.JobId != nil {
+		logs.Logger.Infof("[StopInstance] jobId: %s", *response.JobId)
+	}
+	return nil
+}
+
+func serverIds(ids []string) []model.ServerId {
+	servers := make([]model.ServerId, 0, len(ids))
+	for _, id := range ids {
+		servers = append(servers, model.ServerId{Id: id})
+	}
+	return servers
+}
+
+// enumValue SDK 的枚举类型不导出取值, 通过 json 序列化拿到原始字符串
+func enumValue(e json.Marshaler) string {
+	b, err := e.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	var s string
+	_ = json.Unmarshal(b, &s)
+	return s
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
 }
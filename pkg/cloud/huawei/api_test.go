@@ -0,0 +1,173 @@
+package huawei
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+// apiStandIn 本地模拟 ECS/BSS 接口, 按请求路径的后缀返回预置数据并记录请求体
+type apiStandIn struct {
+	mu        sync.Mutex
+	responses map[string]func(body map[string]interface{}) (int, string)
+	requests  map[string][]map[string]interface{}
+}
+
+func newStandIn(t *testing.T, responses map[string]func(body map[string]interface{}) (int, string)) (*HuaweiCloud, *apiStandIn) {
+	s := &apiStandIn{responses: responses, requests: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	p, err := NewWithEndpoint("ak", "sk", DefaultRegion, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, s
+}
+
+func (s *apiStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	params := make(map[string]interface{})
+	_ = json.Unmarshal(body, &params)
+	for suffix, respond := range s.responses {
+		if !strings.HasSuffix(r.URL.Path, suffix) {
+			continue
+		}
+		s.mu.Lock()
+		s.requests[suffix] = append(s.requests[suffix], params)
+		s.mu.Unlock()
+		code, resp := respond(params)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(resp))
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"error_code":"APIGW.0101","error_msg":"unknown path ` + r.URL.Path + `"}`))
+}
+
+func (s *apiStandIn) count(suffix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests[suffix])
+}
+
+func fixed(resp string) func(map[string]interface{}) (int, string) {
+	return func(map[string]interface{}) (int, string) {
+		return http.StatusOK, resp
+	}
+}
+
+func TestOrderChargeType(t *testing.T) {
+	period := func(v int32) *int32 { return &v }
+	cases := []struct {
+		periodType *int32
+		want       string
+	}{
+		{nil, constants.PostPaid},
+		{period(2), constants.PostPaid},
+		{period(3), constants.PostPaid},
+		{period(5), constants.PostPaid},
+		{period(PeriodTypeHour), constants.PayAsYouGo},
+		{period(PeriodTypeOnDemand), constants.PayAsYouGo},
+	}
+	for _, cs := range cases {
+		if got := orderChargeType(cs.periodType); got != cs.want {
+			t.Errorf("period type %v got %s, want %s", cs.periodType, got, cs.want)
+		}
+	}
+}
+
+func TestGetOrders(t *testing.T) {
+	p, _ := newStandIn(t, map[string]func(map[string]interface{}) (int, string){
+		"/customer-orders": fixed(`{"total_count":1,"order_infos":[{"order_id":"CS1","status":5,"order_type":1,"create_time":"2021-11-01T08:00:00Z"}]}`),
+		"/customer-orders/details/CS1": fixed(`{"total_count":2,"order_line_items":[
+			{"order_line_item_id":"CS1-1","service_type_code":"hws.service.type.ec2","period_type":2,"subscription_num":2,"amount_after_discount":120.5,"currency":"CNY"},
+			{"order_line_item_id":"CS1-2","service_type_code":"hws.service.type.ebs","period_type":4,"subscription_num":1,"amount_after_discount":3,"currency":"CNY"}]}`),
+	})
+	res, err := p.GetOrders(cloud.GetOrdersRequest{PageNum: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Orders) != 2 {
+		t.Fatalf("got %d orders, want 2", len(res.Orders))
+	}
+	first, second := res.Orders[0], res.Orders[1]
+	if first.OrderId != "CS1-1" || first.ChargeType != constants.PostPaid || first.Quantity != 2 || first.PayStatus != constants.Paid {
+		t.Errorf("first order got %+v", first)
+	}
+	if second.ChargeType != constants.PayAsYouGo || second.Extend["main_order_id"] != "CS1" {
+		t.Errorf("second order got %+v", second)
+	}
+}
+
+func TestGetInstancesByTags(t *testing.T) {
+	p, _ := newStandIn(t, map[string]func(map[string]interface{}) (int, string){
+		"/cloudservers/detail": fixed(`{"count":2,"servers":[
+			{"id":"s-1","status":"ACTIVE","tags":["cluster_name=c1","env=prod"],"metadata":{"charging_mode":"2"},"image":{"id":"img-1"},
+			 "security_groups":[{"id":"sg-1"}],
+			 "addresses":{"vpc-1":[{"addr":"192.168.0.2","OS-EXT-IPS:type":"fixed"},{"addr":"1.1.1.1","OS-EXT-IPS:type":"floating"}]}},
+			{"id":"s-2","status":"BUILD","tags":["cluster_name=c1"],"metadata":{"charging_mode":"0"},
+			 "addresses":{"vpc-1":[{"addr":"192.168.0.3","OS-EXT-IPS:type":"fixed"}]}}]}`),
+	})
+	instances, err := p.GetInstancesByTags(DefaultRegion, []cloud.Tag{{Key: "cluster_name", Value: "c1"}, {Key: "env", Value: "prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("got %d instances, want 1", len(instances))
+	}
+	got := instances[0]
+	if got.Id != "s-1" || got.Status != cloud.Running || got.CostWay != cloud.InstanceChargeTypeSpotPaid ||
+		got.IpInner != "192.168.0.2" || got.IpOuter != "1.1.1.1" || got.ImageId != "img-1" ||
+		got.Network.VpcId != "vpc-1" || got.Network.SecurityGroup != "sg-1" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	p, s := newStandIn(t, map[string]func(map[string]interface{}) (int, string){
+		"/cloudservers/delete": func(body map[string]interface{}) (int, string) {
+			servers, _ := body["servers"].([]interface{})
+			if len(servers) == PageSize {
+				return http.StatusBadRequest, `{"error":{"code":"Ecs.0005","message":"request throttled"}}`
+			}
+			return http.StatusOK, `{"job_id":"job-1"}`
+		},
+	})
+	ids := make([]string, 0, PageSize+2)
+	for i := 0; i < PageSize+2; i++ {
+		ids = append(ids, fmt.Sprintf("s-%d", i))
+	}
+	err := p.BatchDelete(ids, DefaultRegion)
+	deleteErr := &cloud.BatchDeleteError{}
+	if !errors.As(err, &deleteErr) {
+		t.Fatalf("want BatchDeleteError, got %v", err)
+	}
+	if !reflect.DeepEqual(deleteErr.FailedIds, ids[:PageSize]) {
+		t.Errorf("failed ids got %v", deleteErr.FailedIds)
+	}
+	if s.count("/cloudservers/delete") != 2 {
+		t.Errorf("want 2 delete requests, got %d", s.count("/cloudservers/delete"))
+	}
+	if err = p.BatchDelete(ids[PageSize:], DefaultRegion); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package huawei
+
+const (
+	CloudName     = "HuaweiCloud"
+	DefaultRegion = "cn-north-4"
+	// BssRegion 费用中心只有全局 endpoint
+	BssRegion = "cn-north-1"
+	PageSize  = 50
+	// TimeFormat bss 接口统一使用 UTC 时间
+	TimeFormat = "2006-01-02T15:04:05Z"
+)
+
+const (
+	DirectionIn  = "ingress"
+	DirectionOut = "egress"
+	EtherType    = "IPv4"
+	ProtocolAll  = "all"
+)
+
+// ECS 实例状态
+const (
+	ServerBuild   = "BUILD"
+	ServerActive  = "ACTIVE"
+	ServerShutoff = "SHUTOFF"
+)
+
+// ECS metadata charging_mode
+const (
+	ChargingPostPaid = "0"
+	ChargingPrePaid  = "1"
+	ChargingSpot     = "2"
+	MarketSpot       = "spot"
+)
+
+// 包年包月订购参数
+const (
+	ChargingModePrePaid = "prePaid"
+	PeriodMonth         = "month"
+	PeriodYear          = "year"
+)
+
+const (
+	VpcOk        = "OK"
+	SubnetActive = "ACTIVE"
+	AddressFixed = "fixed"
+	// AddressFloating 弹性公网 IP
+	AddressFloating = "floating"
+	EipType         = "5_bgp"
+	BandwidthPer    = "PER"
+	ChargeByTraffic = "traffic"
+	PayByTraffic    = "PayByTraffic"
+)
+
+// 规格售卖状态, 取自 cond:operation:status / cond:operation:az
+const (
+	FlavorNormal  = "normal"
+	FlavorAbandon = "abandon"
+	FlavorSellout = "sellout"
+)
+
+// bss 订单状态
+const (
+	OrderCancelled = 4
+	OrderDone      = 5
+	OrderToPay     = 6
+)
+
+// 订单项周期类型 period_type, 其余取值为包年包月或一次性购买
+const (
+	PeriodTypeHour     = 4
+	PeriodTypeOnDemand = 6
+	PeriodTypeUsage    = 7
+)
+
+type regionInfo struct {
+	RegionId  string
+	LocalName string
+}
+
+// Regions ECS/VPC/IMS SDK 都支持的区域
+var Regions = []regionInfo{
+	{"cn-north-1", "华北-北京一"},
+	{"cn-north-4", "华北-北京四"},
+	{"cn-east-2", "华东-上海二"},
+	{"cn-east-3", "华东-上海一"},
+	{"cn-south-1", "华南-广州"},
+	{"cn-southwest-2", "西南-贵阳一"},
+	{"ap-southeast-1", "中国-香港"},
+	{"ap-southeast-2", "亚太-曼谷"},
+	{"ap-southeast-3", "亚太-新加坡"},
+	{"af-south-1", "非洲-约翰内斯堡"},
+}
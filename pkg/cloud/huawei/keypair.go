@@ -0,0 +1,88 @@
+package huawei
+
+import (
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+)
+
+// CreateKeyPair 华为云密钥对没有单独的 ID, 以名称作为 ID
+func (p *HuaweiCloud) CreateKeyPair(req cloud.CreateKeyPairRequest) (cloud.CreateKeyPairResponse, error) {
+	return p.createKeyPair(req.KeyPairName, nil)
+}
+
+func (p *HuaweiCloud) ImportKeyPair(req cloud.ImportKeyPairRequest) (cloud.ImportKeyPairResponse, error) {
+	res, err := p.createKeyPair(req.KeyPairName, &req.PublicKey)
+	if err != nil {
+		return cloud.ImportKeyPairResponse{}, err
+	}
+	return cloud.ImportKeyPairResponse{KeyPairId: res.KeyPairId, KeyPairName: res.KeyPairName}, nil
+}
+
+// createKeyPair publicKey 不为空时导入公钥, 否则由云厂商生成并返回私钥
+func (p *HuaweiCloud) createKeyPair(name string, publicKey *string) (cloud.CreateKeyPairResponse, error) {
+	keyType := model.GetNovaCreateKeypairOptionTypeEnum().SSH
+	request := &model.NovaCreateKeypairRequest{
+		Body: &model.NovaCreateKeypairRequestBody{
+			Keypair: &model.NovaCreateKeypairOption{
+				Name:      name,
+				Type:      &keyType,
+				PublicKey: publicKey,
+			},
+		},
+	}
+	response, err := p.ecsClient.NovaCreateKeypair(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateKeyPair HuaweiCloud failed.err: [%v], name[%v]", err, name)
+		return cloud.CreateKeyPairResponse{}, err
+	}
+	if response.Keypair == nil {
+		return cloud.CreateKeyPairResponse{}, nil
+	}
+	return cloud.CreateKeyPairResponse{
+		KeyPairId:   response.Keypair.Name,
+		KeyPairName: response.Keypair.Name,
+		PrivateKey:  response.Keypair.PrivateKey,
+	}, nil
+}
+
+func (p *HuaweiCloud) DescribeKeyPairs(req cloud.DescribeKeyPairsRequest) (cloud.DescribeKeyPairsResponse, error) {
+	limit := int32(PageSize)
+	request := &model.NovaListKeypairsRequest{Limit: &limit}
+	keyPairs := make([]cloud.KeyPair, 0, 16)
+	for {
+		response, err := p.ecsClient.NovaListKeypairs(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeKeyPairs HuaweiCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeKeyPairsResponse{}, err
+		}
+		if response.Keypairs == nil || len(*response.Keypairs) == 0 {
+			break
+		}
+		for _, result := range *response.Keypairs {
+			if result.Keypair == nil {
+				continue
+			}
+			keyPairs = append(keyPairs, cloud.KeyPair{
+				KeyPairId:   result.Keypair.Name,
+				KeyPairName: result.Keypair.Name,
+				Fingerprint: result.Keypair.Fingerprint,
+			})
+		}
+		if len(*response.Keypairs) < PageSize || len(keyPairs) == 0 {
+			break
+		}
+		marker := keyPairs[len(keyPairs)-1].KeyPairName
+		request.Marker = &marker
+	}
+	return cloud.DescribeKeyPairsResponse{KeyPairs: keyPairs}, nil
+}
+
+func (p *HuaweiCloud) DeleteKeyPair(req cloud.DeleteKeyPairRequest) error {
+	_, err := p.ecsClient.NovaDeleteKeypair(&model.NovaDeleteKeypairRequest{KeypairName: req.KeyPairName})
+	if err != nil {
+		logs.Logger.Errorf("DeleteKeyPair HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package huawei
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/vpc/v2/model"
+)
+
+var vpcStatus = map[string]string{
+	VpcOk:        cloud.VPCStatusAvailable,
+	SubnetActive: cloud.VPCStatusAvailable,
+}
+
+func toVpcStatus(status string) string {
+	if s, ok := vpcStatus[status]; ok {
+		return s
+	}
+	return cloud.VPCStatusPending
+}
+
+func (p *HuaweiCloud) CreateVPC(req cloud.CreateVpcRequest) (cloud.CreateVpcResponse, error) {
+	request := &model.CreateVpcRequest{
+		Body: &model.CreateVpcRequestBody{
+			Vpc: &model.CreateVpcOption{
+				Cidr: &req.CidrBlock,
+				Name: &req.VpcName,
+			},
+		},
+	}
+	response, err := p.vpcClient.CreateVpc(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateVPC HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcResponse{}, err
+	}
+	if response.Vpc != nil {
+		return cloud.CreateVpcResponse{VpcId: response.Vpc.Id}, nil
+	}
+	return cloud.CreateVpcResponse{}, nil
+}
+
+func (p *HuaweiCloud) GetVPC(req cloud.GetVpcRequest) (cloud.GetVpcResponse, error) {
+	response, err := p.vpcClient.ShowVpc(&model.ShowVpcRequest{VpcId: req.VpcId})
+	if err != nil {
+		logs.Logger.Errorf("GetVPC HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.GetVpcResponse{}, err
+	}
+	if response.Vpc == nil {
+		return cloud.GetVpcResponse{}, nil
+	}
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.GetVpcResponse{}, err
+	}
+	switchIds := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		switchIds = append(switchIds, subnet.Id)
+	}
+	return cloud.GetVpcResponse{
+		Vpc: cloud.VPC{
+			VpcId:     response.Vpc.Id,
+			VpcName:   response.Vpc.Name,
+			CidrBlock: response.Vpc.Cidr,
+			SwitchIds: switchIds,
+			RegionId:  req.RegionId,
+			Status:    toVpcStatus(enumValue(response.Vpc.Status)),
+		},
+	}, nil
+}
+
+func (p *HuaweiCloud) DescribeVpcs(req cloud.DescribeVpcsRequest) (cloud.DescribeVpcsResponse, error) {
+	limit := int32(PageSize)
+	request := &model.ListVpcsRequest{Limit: &limit}
+	vpcs := make([]cloud.VPC, 0, 128)
+	for {
+		response, err := p.vpcClient.ListVpcs(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeVpcs HuaweiCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeVpcsResponse{}, err
+		}
+		if response.Vpcs == nil || len(*response.Vpcs) == 0 {
+			break
+		}
+		for _, v := range *response.Vpcs {
+			vpcs = append(vpcs, cloud.VPC{
+				VpcId:     v.Id,
+				VpcName:   v.Name,
+				CidrBlock: v.Cidr,
+				RegionId:  req.RegionId,
+				Status:    toVpcStatus(enumValue(v.Status)),
+			})
+		}
+		if len(*response.Vpcs) < PageSize {
+			break
+		}
+		marker := vpcs[len(vpcs)-1].VpcId
+		request.Marker = &marker
+	}
+	return cloud.DescribeVpcsResponse{Vpcs: vpcs}, nil
+}
+
+// CreateSwitch 华为云子网必须指定网关, 取网段的第一个地址
+func (p *HuaweiCloud) CreateSwitch(req cloud.CreateSwitchRequest) (cloud.CreateSwitchResponse, error) {
+	gateway, err := gatewayIp(req.CidrBlock)
+	if err != nil {
+		return cloud.CreateSwitchResponse{}, err
+	}
+	dhcpEnable := true
+	option := &model.CreateSubnetOption{
+		Name:       req.VSwitchName,
+		Cidr:       req.CidrBlock,
+		VpcId:      req.VpcId,
+		GatewayIp:  gateway,
+		DhcpEnable: &dhcpEnable,
+	}
+	if req.ZoneId != "" {
+		option.AvailabilityZone = &req.ZoneId
+	}
+	response, err := p.vpcClient.CreateSubnet(&model.CreateSubnetRequest{
+		Body: &model.CreateSubnetRequestBody{Subnet: option},
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateSwitch HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSwitchResponse{}, err
+	}
+	if response.Subnet != nil {
+		return cloud.CreateSwitchResponse{SwitchId: response.Subnet.Id}, nil
+	}
+	return cloud.CreateSwitchResponse{}, nil
+}
+
+func gatewayIp(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return "", fmt.Errorf("invalid ipv4 cidr: %s", cidr)
+	}
+	gateway := make(net.IP, len(ip))
+	copy(gateway, ip)
+	gateway[3]++
+	if !ipNet.Contains(gateway) {
+		return "", fmt.Errorf("cidr too small: %s", cidr)
+	}
+	return gateway.String(), nil
+}
+
+func (p *HuaweiCloud) GetSwitch(req cloud.GetSwitchRequest) (cloud.GetSwitchResponse, error) {
+	response, err := p.vpcClient.ShowSubnet(&model.ShowSubnetRequest{SubnetId: req.SwitchId})
+	if err != nil {
+		logs.Logger.Errorf("GetSwitch HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.GetSwitchResponse{}, err
+	}
+	if response.Subnet == nil {
+		return cloud.GetSwitchResponse{}, nil
+	}
+	return cloud.GetSwitchResponse{Switch: p.toSwitch(*response.Subnet)}, nil
+}
+
+func (p *HuaweiCloud) DescribeSwitches(req cloud.DescribeSwitchesRequest) (cloud.DescribeSwitchesResponse, error) {
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.DescribeSwitchesResponse{}, err
+	}
+	switches := make([]cloud.Switch, 0, len(subnets))
+	for _, subnet := range subnets {
+		switches = append(switches, p.toSwitch(subnet))
+	}
+	return cloud.DescribeSwitchesResponse{Switches: switches}, nil
+}
+
+func (p *HuaweiCloud) listSubnets(vpcId string) ([]model.Subnet, error) {
+	limit := int32(PageSize)
+	request := &model.ListSubnetsRequest{Limit: &limit, VpcId: &vpcId}
+	subnets := make([]model.Subnet, 0)
+	for {
+		response, err := p.vpcClient.ListSubnets(request)
+		if err != nil {
+			logs.Logger.Errorf("ListSubnets HuaweiCloud failed.err: [%v], vpcId[%s]", err, vpcId)
+			return nil, err
+		}
+		if response.Subnets == nil || len(*response.Subnets) == 0 {
+			break
+		}
+		subnets = append(subnets, *response.Subnets...)
+		if len(*response.Subnets) < PageSize {
+			break
+		}
+		marker := subnets[len(subnets)-1].Id
+		request.Marker = &marker
+	}
+	return subnets, nil
+}
+
+func (p *HuaweiCloud) toSwitch(subnet model.Subnet) cloud.Switch {
+	return cloud.Switch{
+		VpcId:                   subnet.VpcId,
+		SwitchId:                subnet.Id,
+		Name:                    subnet.Name,
+		AvailableIpAddressCount: p.availableIpCount(subnet.NeutronNetworkId),
+		VStatus:                 toVpcStatus(enumValue(subnet.Status)),
+		ZoneId:                  subnet.AvailabilityZone,
+		CidrBlock:               subnet.Cidr,
+	}
+}
+
+func (p *HuaweiCloud) availableIpCount(networkId string) int {
+	if networkId == "" {
+		return 0
+	}
+	response, err := p.vpcClient.ShowNetworkIpAvailabilities(&model.ShowNetworkIpAvailabilitiesRequest{NetworkId: networkId})
+	if err != nil || response.NetworkIpAvailability == nil {
+		logs.Logger.Errorf("ShowNetworkIpAvailabilities HuaweiCloud failed.err: [%v], networkId[%s]", err, networkId)
+		return 0
+	}
+	return int(response.NetworkIpAvailability.TotalIps - response.NetworkIpAvailability.UsedIps)
+}
+
+func (p *HuaweiCloud) CreateSecurityGroup(req cloud.CreateSecurityGroupRequest) (cloud.CreateSecurityGroupResponse, error) {
+	option := &model.CreateSecurityGroupOption{Name: req.SecurityGroupName}
+	if req.VpcId != "" {
+		option.VpcId = &req.VpcId
+	}
+	response, err := p.vpcClient.CreateSecurityGroup(&model.CreateSecurityGroupRequest{
+		Body: &model.CreateSecurityGroupRequestBody{SecurityGroup: option},
+	})
+	if err != nil {
+		logs.Logger.Errorf("CreateSecurityGroup HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSecurityGroupResponse{}, err
+	}
+	if response.SecurityGroup != nil {
+		return cloud.CreateSecurityGroupResponse{SecurityGroupId: response.SecurityGroup.Id}, nil
+	}
+	return cloud.CreateSecurityGroupResponse{}, nil
+}
+
+func (p *HuaweiCloud) AddIngressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	return p.addSecurityGroupRule(req, DirectionIn)
+}
+
+func (p *HuaweiCloud) AddEgressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	return p.addSecurityGroupRule(req, DirectionOut)
+}
+
+func (p *HuaweiCloud) addSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest, direction string) error {
+	etherType := EtherType
+	option := &model.CreateSecurityGroupRuleOption{
+		SecurityGroupId: req.SecurityGroupId,
+		Direction:       direction,
+		Ethertype:       &etherType,
+	}
+	protocol := strings.ToLower(req.IpProtocol)
+	if protocol != "" && protocol != ProtocolAll {
+		option.Protocol = &protocol
+	}
+	min, max, err := parsePortRange(req.PortRange)
+	if err != nil {
+		return err
+	}
+	if min > 0 {
+		option.PortRangeMin = &min
+		option.PortRangeMax = &max
+	}
+	if req.CidrIp != "" {
+		option.RemoteIpPrefix = &req.CidrIp
+	}
+	if req.GroupId != "" {
+		option.RemoteGroupId = &req.GroupId
+	}
+	_, err = p.vpcClient.CreateSecurityGroupRule(&model.CreateSecurityGroupRuleRequest{
+		Body: &model.CreateSecurityGroupRuleRequestBody{SecurityGroupRule: option},
+	})
+	if err != nil {
+		logs.Logger.Errorf("AddSecurityGroupRule HuaweiCloud failed.err: [%v], req[%v] direction[%s]", err, req, direction)
+		return err
+	}
+	return nil
+}
+
+// parsePortRange 端口范围格式与阿里云一致, 如 22/22, -1/-1 表示全部端口
+func parsePortRange(portRange string) (int32, int32, error) {
+	if portRange == "" {
+		return -1, -1, nil
+	}
+	ports := strings.Split(portRange, "/")
+	if len(ports) != 2 {
+		return 0, 0, errors.New("invalid port range: " + portRange)
+	}
+	min, err := strconv.Atoi(ports[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := strconv.Atoi(ports[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return int32(min), int32(max), nil
+}
+
+func (p *HuaweiCloud) DescribeSecurityGroups(req cloud.DescribeSecurityGroupsRequest) (cloud.DescribeSecurityGroupsResponse, error) {
+	limit := int32(PageSize)
+	request := &model.ListSecurityGroupsRequest{Limit: &limit}
+	if req.VpcId != "" {
+		request.VpcId = &req.VpcId
+	}
+	groups := make([]cloud.SecurityGroup, 0, 128)
+	for {
+		response, err := p.vpcClient.ListSecurityGroups(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeSecurityGroups HuaweiCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeSecurityGroupsResponse{}, err
+		}
+		if response.SecurityGroups == nil || len(*response.SecurityGroups) == 0 {
+			break
+		}
+		for _, group := range *response.SecurityGroups {
+			vpcId := req.VpcId
+			if group.VpcId != nil && *group.VpcId != "" {
+				vpcId = *group.VpcId
+			}
+			groups = append(groups, cloud.SecurityGroup{
+				SecurityGroupId:   group.Id,
+				SecurityGroupType: "normal",
+				SecurityGroupName: group.Name,
+				VpcId:             vpcId,
+				RegionId:          req.RegionId,
+			})
+		}
+		if len(*response.SecurityGroups) < PageSize {
+			break
+		}
+		marker := groups[len(groups)-1].SecurityGroupId
+		request.Marker = &marker
+	}
+	return cloud.DescribeSecurityGroupsResponse{Groups: groups}, nil
+}
+
+func (p *HuaweiCloud) DescribeGroupRules(req cloud.DescribeGroupRulesRequest) (cloud.DescribeGroupRulesResponse, error) {
+	response, err := p.vpcClient.ShowSecurityGroup(&model.ShowSecurityGroupRequest{SecurityGroupId: req.SecurityGroupId})
+	if err != nil {
+		logs.Logger.Errorf("DescribeGroupRules HuaweiCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeGroupRulesResponse{}, err
+	}
+	rules := make([]cloud.SecurityGroupRule, 0, 16)
+	if response.SecurityGroup == nil {
+		return cloud.DescribeGroupRulesResponse{Rules: rules}, nil
+	}
+	vpcId := ""
+	if response.SecurityGroup.VpcId != nil {
+		vpcId = *response.SecurityGroup.VpcId
+	}
+	for _, rule := range response.SecurityGroup.SecurityGroupRules {
+		portRange := "-1/-1"
+		if rule.PortRangeMin > 0 {
+			portRange = fmt.Sprintf("%d/%d", rule.PortRangeMin, rule.PortRangeMax)
+		}
+		protocol := rule.Protocol
+		if protocol == "" {
+			protocol = ProtocolAll
+		}
+		rules = append(rules, cloud.SecurityGroupRule{
+			VpcId:           vpcId,
+			SecurityGroupId: rule.SecurityGroupId,
+			PortRange:       portRange,
+			Protocol:        protocol,
+			Direction:       rule.Direction,
+			GroupId:         rule.RemoteGroupId,
+			CidrIp:          rule.RemoteIpPrefix,
+		})
+	}
+	return cloud.DescribeGroupRulesResponse{Rules: rules}, nil
+}
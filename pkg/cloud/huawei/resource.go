@@ -0,0 +1,265 @@
+package huawei
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	bssModel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/bss/v2/model"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ecs/v2/model"
+	imsModel "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/ims/v2/model"
+	"github.com/spf13/cast"
+)
+
+func (p *HuaweiCloud) GetRegions() (cloud.GetRegionsResponse, error) {
+	regions := make([]cloud.Region, 0, len(Regions))
+	for _, region := range Regions {
+		regions = append(regions, cloud.Region{
+			RegionId:  region.RegionId,
+			LocalName: region.LocalName,
+		})
+	}
+	return cloud.GetRegionsResponse{Regions: regions}, nil
+}
+
+func (p *HuaweiCloud) GetZones(req cloud.GetZonesRequest) (cloud.GetZonesResponse, error) {
+	response, err := p.ecsClient.NovaListAvailabilityZones(&model.NovaListAvailabilityZonesRequest{})
+	if err != nil {
+		logs.Logger.Errorf("GetZones HuaweiCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.GetZonesResponse{}, err
+	}
+	zones := make([]cloud.Zone, 0, 8)
+	if response.AvailabilityZoneInfo == nil {
+		return cloud.GetZonesResponse{Zones: zones}, nil
+	}
+	for _, zone := range *response.AvailabilityZoneInfo {
+		if zone.ZoneState == nil || !zone.ZoneState.Available {
+			continue
+		}
+		zones = append(zones, cloud.Zone{
+			ZoneId:    zone.ZoneName,
+			LocalName: zone.ZoneName,
+		})
+	}
+	return cloud.GetZonesResponse{Zones: zones}, nil
+}
+
+// DescribeAvailableResource 规格的售卖状态优先取 cond:operation:az 中对应可用区的状态, 否则取 cond:operation:status
+func (p *HuaweiCloud) DescribeAvailableResource(req cloud.DescribeAvailableResourceRequest) (cloud.DescribeAvailableResourceResponse, error) {
+	zoneIds := make([]string, 0, 8)
+	if req.ZoneId != "" {
+		zoneIds = append(zoneIds, req.ZoneId)
+	} else {
+		zones, err := p.GetZones(cloud.GetZonesRequest{RegionId: req.RegionId})
+		if err != nil {
+			return cloud.DescribeAvailableResourceResponse{}, err
+		}
+		for _, zone := range zones.Zones {
+			zoneIds = append(zoneIds, zone.ZoneId)
+		}
+	}
+	zoneInsType := make(map[string][]cloud.InstanceType, len(zoneIds))
+	for _, zoneId := range zoneIds {
+		flavors, err := p.listFlavors(zoneId)
+		if err != nil {
+			logs.Logger.Errorf("DescribeAvailableResource HuaweiCloud failed.err: [%v] req[%v]", err, req)
+			return cloud.DescribeAvailableResourceResponse{}, err
+		}
+		resources := make([]cloud.InstanceType, 0, len(flavors))
+		for _, flavor := range flavors {
+			status := flavorStatus(flavor, zoneId)
+			if status == FlavorAbandon {
+				continue
+			}
+			insType := cloud.InstanceType{
+				Status:         "Available",
+				StatusCategory: "WithStock",
+				Value:          flavor.Name,
+			}
+			if status == FlavorSellout {
+				insType.Status = "SoldOut"
+				insType.StatusCategory = "WithoutStock"
+			}
+			resources = append(resources, insType)
+		}
+		zoneInsType[zoneId] = resources
+	}
+	return cloud.DescribeAvailableResourceResponse{InstanceTypes: zoneInsType}, nil
+}
+
+func flavorStatus(flavor model.Flavor, zoneId string) string {
+	if flavor.OsExtraSpecs == nil {
+		return FlavorNormal
+	}
+	if flavor.OsExtraSpecs.Condoperationaz != nil {
+		// 格式: az0.dc1(sellout),az1.dc1(normal)
+		for _, item := range strings.Split(*flavor.OsExtraSpecs.Condoperationaz, ",") {
+			item = strings.TrimSpace(item)
+			if strings.HasPrefix(item, zoneId+"(") {
+				return strings.TrimSuffix(strings.TrimPrefix(item, zoneId+"("), ")")
+			}
+		}
+	}
+	if flavor.OsExtraSpecs.Condoperationstatus != nil {
+		return *flavor.OsExtraSpecs.Condoperationstatus
+	}
+	return FlavorNormal
+}
+
+func (p *HuaweiCloud) listFlavors(zoneId string) ([]model.Flavor, error) {
+	request := &model.ListFlavorsRequest{}
+	if zoneId != "" {
+		request.AvailabilityZone = &zoneId
+	}
+	response, err := p.ecsClient.ListFlavors(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Flavors == nil {
+		return []model.Flavor{}, nil
+	}
+	return *response.Flavors, nil
+}
+
+func (p *HuaweiCloud) DescribeInstanceTypes(req cloud.DescribeInstanceTypesRequest) (cloud.DescribeInstanceTypesResponse, error) {
+	flavors, err := p.listFlavors("")
+	if err != nil {
+		logs.Logger.Errorf("DescribeInstanceTypes HuaweiCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.DescribeInstanceTypesResponse{}, err
+	}
+	names := make(map[string]struct{}, len(req.TypeName))
+	for _, name := range req.TypeName {
+		names[name] = struct{}{}
+	}
+	infos := make([]cloud.InstanceInfo, 0, len(req.TypeName))
+	for _, flavor := range flavors {
+		if _, ok := names[flavor.Name]; !ok {
+			continue
+		}
+		family := ""
+		if flavor.OsExtraSpecs != nil && flavor.OsExtraSpecs.Ecsperformancetype != nil {
+			family = *flavor.OsExtraSpecs.Ecsperformancetype
+		}
+		infos = append(infos, cloud.InstanceInfo{
+			Core:        cast.ToInt(flavor.Vcpus),
+			Memory:      int(flavor.Ram / 1024),
+			Family:      family,
+			InsTypeName: flavor.Name,
+		})
+	}
+	return cloud.DescribeInstanceTypesResponse{Infos: infos}, nil
+}
+
+// DescribeImages 只返回可用的公共镜像
+func (p *HuaweiCloud) DescribeImages(req cloud.DescribeImagesRequest) (cloud.DescribeImagesResponse, error) {
+	limit := int32(PageSize)
+	imageType := imsModel.GetListImagesRequestImagetypeEnum().GOLD
+	status := imsModel.GetListImagesRequestStatusEnum().ACTIVE
+	request := &imsModel.ListImagesRequest{
+		Imagetype: &imageType,
+		Status:    &status,
+		Limit:     &limit,
+	}
+	images := make([]cloud.Image, 0)
+	for {
+		response, err := p.imsClient.ListImages(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeImages HuaweiCloud failed.err: [%v] req[%v]", err, req)
+			return cloud.DescribeImagesResponse{}, err
+		}
+		if response.Images == nil || len(*response.Images) == 0 {
+			break
+		}
+		for _, img := range *response.Images {
+			images = append(images, cloud.Image{
+				OsType:  strings.ToLower(enumValue(img.OsType)),
+				OsName:  img.Name,
+				ImageId: img.Id,
+			})
+		}
+		if len(*response.Images) < PageSize {
+			break
+		}
+		marker := images[len(images)-1].ImageId
+		request.Marker = &marker
+	}
+	return cloud.DescribeImagesResponse{Images: images}, nil
+}
+
+var PayStatus = map[int32]int8{
+	OrderDone:      constants.Paid,
+	OrderToPay:     constants.Unpaid,
+	OrderCancelled: constants.Cancelled,
+}
+
+// orderChargeType 按订单项的周期类型区分计费方式, 与其他云厂商一致, 包年包月订单记为 constants.PostPaid
+func orderChargeType(periodType *int32) string {
+	if periodType == nil {
+		return constants.PostPaid
+	}
+	switch *periodType {
+	case PeriodTypeHour, PeriodTypeOnDemand, PeriodTypeUsage:
+		return constants.PayAsYouGo
+	}
+	return constants.PostPaid
+}
+
+// GetOrders 费用中心的订单主要是包年包月订单, 计费方式取自订单项的周期类型
+func (p *HuaweiCloud) GetOrders(req cloud.GetOrdersRequest) (cloud.GetOrdersResponse, error) {
+	startTime := req.StartTime.UTC().Format(TimeFormat)
+	endTime := req.EndTime.UTC().Format(TimeFormat)
+	limit := int32(req.PageSize)
+	offset := int32((req.PageNum - 1) * req.PageSize)
+	response, err := p.bssClient.ListCustomerOrders(&bssModel.ListCustomerOrdersRequest{
+		CreateTimeBegin: &startTime,
+		CreateTimeEnd:   &endTime,
+		Limit:           &limit,
+		Offset:          &offset,
+	})
+	if err != nil {
+		logs.Logger.Errorf("GetOrders HuaweiCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.GetOrdersResponse{}, err
+	}
+	if response.OrderInfos == nil || len(*response.OrderInfos) == 0 {
+		return cloud.GetOrdersResponse{}, nil
+	}
+
+	orders := make([]cloud.Order, 0, len(*response.OrderInfos))
+	for _, row := range *response.OrderInfos {
+		if row.OrderId == nil {
+			continue
+		}
+		detail, err := p.bssClient.ShowCustomerOrderDetails(&bssModel.ShowCustomerOrderDetailsRequest{OrderId: *row.OrderId})
+		if err != nil {
+			return cloud.GetOrdersResponse{}, err
+		}
+		if detail.OrderLineItems == nil {
+			return cloud.GetOrdersResponse{}, errors.New("empty order line items: " + *row.OrderId)
+		}
+		orderTime, _ := time.Parse(TimeFormat, cast.ToString(row.CreateTime))
+		for _, item := range *detail.OrderLineItems {
+			usageStartTime, _ := time.Parse(TimeFormat, cast.ToString(item.EffectiveTime))
+			usageEndTime, _ := time.Parse(TimeFormat, cast.ToString(item.ExpireTime))
+			orders = append(orders, cloud.Order{
+				OrderId:        cast.ToString(item.OrderLineItemId),
+				OrderTime:      orderTime,
+				Product:        cast.ToString(item.ServiceTypeCode),
+				Quantity:       cast.ToInt32(item.SubscriptionNum),
+				UsageStartTime: usageStartTime,
+				UsageEndTime:   usageEndTime,
+				ChargeType:     orderChargeType(item.PeriodType),
+				PayStatus:      PayStatus[cast.ToInt32(row.Status)],
+				Currency:       cast.ToString(item.Currency),
+				Cost:           cast.ToFloat32(item.AmountAfterDiscount),
+				Extend: map[string]interface{}{
+					"main_order_id": *row.OrderId,
+					"order_type":    cast.ToString(row.OrderType),
+				},
+			})
+		}
+	}
+	return cloud.GetOrdersResponse{Orders: orders}, nil
+}
@@ -6,10 +6,26 @@ import (
 
 const (
 	Pending     = "Pending"
+	Running     = "Running"
+	Stopped     = "Stopped"
 	TaskId      = "TaskId"
 	ClusterName = "ClusterName"
 )
 
+// 实例计费方式, 同时也是 Instance.CostWay 的取值
+const (
+	InstanceChargeTypePrePaid  = "PrePaid"
+	InstanceChargeTypePostPaid = "PostPaid"
+	InstanceChargeTypeSpotPaid = "SpotPaid"
+)
+
+// 包年包月实例购买时长单位
+const (
+	PeriodUnitWeek  = "Week"
+	PeriodUnitMonth = "Month"
+	PeriodUnitYear  = "Year"
+)
+
 type Params struct {
 	Provider     string
 	InstanceType string
@@ -19,7 +35,23 @@ type Params struct {
 	Region       string
 	Disks        *Disks
 	Password     string
-	Tags         []Tag
+	// KeyPairName 不为空时使用密钥对登录, 与 Password 二选一
+	KeyPairName string
+	Tags        []Tag
+	// ChargeType 为空时按量付费, PrePaid 时按 Period/PeriodUnit 购买包年包月实例
+	ChargeType string
+	Period     int
+	PeriodUnit string
+	AutoRenew  bool
+	// Spot 不为空时创建抢占式实例
+	Spot *SpotOption
+	// UserData 实例启动时执行的脚本原文, 由各云厂商按接口要求编码
+	UserData string
+}
+
+// SpotOption 抢占式实例出价, PriceLimit 为每台实例每小时的最高价格, 为 0 时跟随市场价
+type SpotOption struct {
+	PriceLimit float64
 }
 
 type Tag struct {
@@ -147,6 +179,48 @@ type SecurityGroup struct {
 type AddSecurityGroupRuleResponse struct {
 }
 
+type CreateKeyPairRequest struct {
+	RegionId    string
+	KeyPairName string
+}
+
+// CreateKeyPairResponse PrivateKey 只在创建时返回一次, 不会保存
+type CreateKeyPairResponse struct {
+	KeyPairId   string
+	KeyPairName string
+	PrivateKey  string
+}
+
+type ImportKeyPairRequest struct {
+	RegionId    string
+	KeyPairName string
+	PublicKey   string
+}
+
+type ImportKeyPairResponse struct {
+	KeyPairId   string
+	KeyPairName string
+}
+
+type DescribeKeyPairsRequest struct {
+	RegionId string
+}
+
+type DescribeKeyPairsResponse struct {
+	KeyPairs []KeyPair
+}
+
+type KeyPair struct {
+	KeyPairId   string
+	KeyPairName string
+	Fingerprint string
+}
+
+type DeleteKeyPairRequest struct {
+	RegionId    string
+	KeyPairName string
+}
+
 type GetSwitchRequest struct {
 	SwitchId string
 }
@@ -1,5 +1,13 @@
 package cloud
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
 type ProviderType int
 
 const (
@@ -14,6 +22,87 @@ const (
 	VPCStatusAvailable = "Available"
 )
 
+var (
+	ErrChargeTypeNotSupported = errors.New("charge type not supported")
+	ErrPeriodUnitNotSupported = errors.New("period unit not supported")
+	ErrInsufficientStock      = errors.New("insufficient stock")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+)
+
+// BatchDeleteError 分批释放实例时失败的批次, FailedIds 为这些批次的实例 ID
+type BatchDeleteError struct {
+	FailedIds []string
+	Errs      []error
+}
+
+// Add 记录一个释放失败的批次
+func (e *BatchDeleteError) Add(ids []string, err error) {
+	e.FailedIds = append(e.FailedIds, ids...)
+	e.Errs = append(e.Errs, err)
+}
+
+// ErrOrNil 所有批次都成功时返回 nil
+func (e *BatchDeleteError) ErrOrNil() error {
+	if len(e.Errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *BatchDeleteError) Error() string {
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("delete instances %v failed: %s", e.FailedIds, strings.Join(msgs, "; "))
+}
+
+// stockErrorCodes 各云厂商可用区或规格库存不足时返回的错误码
+var stockErrorCodes = []string{
+	"OperationDenied.NoStock",
+	"Zone.NotOnSale",
+	"ResourceInsufficient.",
+	"ResourcesSoldOut",
+	"InsufficientInstanceCapacity",
+}
+
+// quotaErrorCodes 各云厂商实例或 vCPU 配额不足时返回的错误码
+var quotaErrorCodes = []string{
+	"QuotaExceed",
+	"LimitExceeded",
+}
+
+// IsInsufficientStock 判断创建实例失败是否因为库存不足, 库存不足时可以换可用区重试
+func IsInsufficientStock(err error) bool {
+	return matchError(err, ErrInsufficientStock, stockErrorCodes)
+}
+
+// IsQuotaExceeded 判断创建实例失败是否因为配额不足, 配额不足时可以换实例规格重试
+func IsQuotaExceeded(err error) bool {
+	return matchError(err, ErrQuotaExceeded, quotaErrorCodes)
+}
+
+func matchError(err, target error, codes []string) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, target) {
+		return true
+	}
+	msg := err.Error()
+	for _, code := range codes {
+		if strings.Contains(msg, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// DryRunCreator 支持预检创建实例的云厂商实现, 只校验参数、库存和配额, 不会创建实例
+type DryRunCreator interface {
+	DryRunCreate(m Params, num int) error
+}
+
 type Provider interface {
 	BatchCreate(m Params, num int) (instanceIds []string, err error)
 	ProviderType() string
@@ -31,6 +120,10 @@ type Provider interface {
 	AddIngressSecurityGroupRule(req AddSecurityGroupRuleRequest) error
 	AddEgressSecurityGroupRule(req AddSecurityGroupRuleRequest) error
 	DescribeSecurityGroups(req DescribeSecurityGroupsRequest) (DescribeSecurityGroupsResponse, error)
+	CreateKeyPair(req CreateKeyPairRequest) (CreateKeyPairResponse, error)
+	ImportKeyPair(req ImportKeyPairRequest) (ImportKeyPairResponse, error)
+	DescribeKeyPairs(req DescribeKeyPairsRequest) (DescribeKeyPairsResponse, error)
+	DeleteKeyPair(req DeleteKeyPairRequest) error
 	GetRegions() (GetRegionsResponse, error)
 	GetZones(req GetZonesRequest) (GetZonesResponse, error)
 	DescribeAvailableResource(req DescribeAvailableResourceRequest) (DescribeAvailableResourceResponse, error)
@@ -41,10 +134,51 @@ type Provider interface {
 	DescribeGroupRules(req DescribeGroupRulesRequest) (DescribeGroupRulesResponse, error)
 	GetOrders(req GetOrdersRequest) (GetOrdersResponse, error)
 }
-type ProviderDriverFunc func(keyId ...string) (Provider, error)
 
-var registeredPlugins = map[string]ProviderDriverFunc{}
+// ProviderDriverFunc 各云厂商在 init 中注册, 用 ak/sk/region 构造 Provider
+type ProviderDriverFunc func(ak, sk, regionId string) (Provider, error)
+
+type providerDriver struct {
+	newFunc       ProviderDriverFunc
+	defaultRegion string
+}
+
+var (
+	registeredPlugins = map[string]providerDriver{}
+	pluginLock        sync.RWMutex
+)
+
+// RegisterProviderDriver defaultRegion 用于不区分地域的调用, 如校验账号, 查询地域列表
+func RegisterProviderDriver(name string, f ProviderDriverFunc, defaultRegion string) {
+	pluginLock.Lock()
+	defer pluginLock.Unlock()
+	registeredPlugins[name] = providerDriver{newFunc: f, defaultRegion: defaultRegion}
+}
+
+func GetProviderDriver(name string) (ProviderDriverFunc, error) {
+	pluginLock.RLock()
+	defer pluginLock.RUnlock()
+	driver, ok := registeredPlugins[name]
+	if !ok {
+		return nil, fmt.Errorf("unavailable provider: %s", name)
+	}
+	return driver.newFunc, nil
+}
+
+func GetDefaultRegion(name string) string {
+	pluginLock.RLock()
+	defer pluginLock.RUnlock()
+	return registeredPlugins[name].defaultRegion
+}
 
-func RegisterProviderDriver(name string, f ProviderDriverFunc) {
-	registeredPlugins[name] = f
+// ProviderNames 按名称排序返回所有已注册的云厂商
+func ProviderNames() []string {
+	pluginLock.RLock()
+	defer pluginLock.RUnlock()
+	names := make([]string, 0, len(registeredPlugins))
+	for name := range registeredPlugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,62 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsInsufficientStock(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("InvalidParameter"), false},
+		{fmt.Errorf("create: %w", ErrInsufficientStock), true},
+		{errors.New("SDK.ServerError\nErrorCode: OperationDenied.NoStock"), true},
+		{errors.New("[TencentCloudSDKError] Code=ResourceInsufficient.ZoneSoldOutForSpecifiedInstance"), true},
+		{errors.New("InsufficientInstanceCapacity: We currently do not have sufficient capacity"), true},
+	}
+	for _, c := range cases {
+		if got := IsInsufficientStock(c.err); got != c.want {
+			t.Errorf("IsInsufficientStock(%v) got %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestIsQuotaExceeded(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("OperationDenied.NoStock"), false},
+		{fmt.Errorf("create: %w", ErrQuotaExceeded), true},
+		{errors.New("SDK.ServerError\nErrorCode: QuotaExceed.PostPaidInstance"), true},
+		{errors.New("[TencentCloudSDKError] Code=LimitExceeded.InstanceQuota"), true},
+		{errors.New("VcpuLimitExceeded: You have requested more vCPU capacity"), true},
+	}
+	for _, c := range cases {
+		if got := IsQuotaExceeded(c.err); got != c.want {
+			t.Errorf("IsQuotaExceeded(%v) got %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestBatchDeleteError(t *testing.T) {
+	deleteErr := &BatchDeleteError{}
+	if deleteErr.ErrOrNil() != nil {
+		t.Fatal("want nil without failed batch")
+	}
+	deleteErr.Add([]string{"i-1", "i-2"}, errors.New("throttled"))
+	deleteErr.Add([]string{"i-5"}, errors.New("not found"))
+	err := deleteErr.ErrOrNil()
+	target := &BatchDeleteError{}
+	if !errors.As(err, &target) || len(target.FailedIds) != 3 {
+		t.Fatalf("got %v", err)
+	}
+	if err.Error() != "delete instances [i-1 i-2 i-5] failed: throttled; not found" {
+		t.Errorf("got %q", err.Error())
+	}
+}
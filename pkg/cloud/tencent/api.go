@@ -0,0 +1,345 @@
+package tencent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	billing "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/billing/v20180709"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+type TencentCloud struct {
+	cvmClient     *cvm.Client
+	vpcClient     *vpc.Client
+	billingClient *billing.Client
+}
+
+func init() {
+	cloud.RegisterProviderDriver(CloudName, newDriver, DefaultRegion)
+}
+
+func newDriver(ak, sk, region string) (cloud.Provider, error) {
+	client, err := New(ak, sk, region)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func New(AK, SK, region string) (*TencentCloud, error) {
+	return NewWithEndpoint(AK, SK, region, "")
+}
+
+// NewWithEndpoint endpoint 不为空时所有请求通过 http 发往该地址, 用于对接本地模拟服务
+func NewWithEndpoint(AK, SK, region, endpoint string) (*TencentCloud, error) {
+	credential := common.NewCredential(AK, SK)
+	cpf := profile.NewClientProfile()
+	if endpoint != "" {
+		cpf.HttpProfile.Endpoint = endpoint
+		cpf.HttpProfile.Scheme = "HTTP"
+	}
+	cvmClt, err := cvm.NewClient(credential, region, cpf)
+	if err != nil {
+		return nil, err
+	}
+	vpcClt, err := vpc.NewClient(credential, region, cpf)
+	if err != nil {
+		return nil, err
+	}
+	billingClt, err := billing.NewClient(credential, region, cpf)
+	if err != nil {
+		return nil, err
+	}
+	return &TencentCloud{cvmClient: cvmClt, vpcClient: vpcClt, billingClient: billingClt}, nil
+}
+
+func (*TencentCloud) ProviderType() string {
+	return CloudName
+}
+
+// BatchCreate the maximum of 'num' is 100
+func (p *TencentCloud) BatchCreate(m cloud.Params, num int) (instanceIds []string, err error) {
+	request := cvm.NewRunInstancesRequest()
+	request.InstanceChargeType = common.StringPtr(PostPaid)
+	request.Placement = &cvm.Placement{Zone: common.StringPtr(m.Zone)}
+	request.InstanceType = common.StringPtr(m.InstanceType)
+	request.ImageId = common.StringPtr(m.ImageId)
+	request.InstanceCount = common.Int64Ptr(int64(num))
+	request.InstanceName = common.StringPtr(instanceName(m.Tags))
+	if m.ChargeType == cloud.InstanceChargeTypePrePaid {
+		request.InstanceChargeType = common.StringPtr(PrePaid)
+		request.InstanceChargePrepaid, err = chargePrepaid(m)
+		if err != nil {
+			return nil, err
+		}
+	} else if m.Spot != nil {
+		request.InstanceChargeType = common.StringPtr(SpotPaid)
+		// 不指定出价时按当前固定折扣价格出价
+		if m.Spot.PriceLimit > 0 {
+			request.InstanceMarketOptions = &cvm.InstanceMarketOptionsRequest{
+				MarketType: common.StringPtr(MarketSpot),
+				SpotOptions: &cvm.SpotMarketOptions{
+					MaxPrice:         common.StringPtr(strconv.FormatFloat(m.Spot.PriceLimit, 'f', -1, 64)),
+					SpotInstanceType: common.StringPtr(SpotTypeOneTime),
+				},
+			}
+		}
+	}
+	if m.Network != nil {
+		request.VirtualPrivateCloud = &cvm.VirtualPrivateCloud{
+			VpcId:    common.StringPtr(m.Network.VpcId),
+			SubnetId: common.StringPtr(m.Network.SubnetId),
+		}
+		if m.Network.SecurityGroup != "" {
+			request.SecurityGroupIds = common.StringPtrs(strings.Split(m.Network.SecurityGroup, ","))
+		}
+		if m.Network.InternetMaxBandwidthOut != 0 {
+			chargeType := BandwidthPostPaid
+			if m.Network.InternetChargeType == PayByTraffic {
+				chargeType = TrafficPostPaid
+			}
+			request.InternetAccessible = &cvm.InternetAccessible{
+				InternetChargeType:      common.StringPtr(chargeType),
+				InternetMaxBandwidthOut: common.Int64Ptr(int64(m.Network.InternetMaxBandwidthOut)),
+				PublicIpAssigned:        common.BoolPtr(true),
+			}
+		}
+	}
+	if m.Disks != nil {
+		request.SystemDisk = &cvm.SystemDisk{
+			DiskType: common.StringPtr(m.Disks.SystemDisk.Category),
+			DiskSize: common.Int64Ptr(int64(m.Disks.SystemDisk.Size)),
+		}
+		for _, disk := range m.Disks.DataDisk {
+			request.DataDisks = append(request.DataDisks, &cvm.DataDisk{
+				DiskType:           common.StringPtr(disk.Category),
+				DiskSize:           common.Int64Ptr(int64(disk.Size)),
+				DeleteWithInstance: common.BoolPtr(true),
+			})
+		}
+	}
+	if m.KeyPairName != "" {
+		keyId, err := p.keyPairId(m.KeyPairName)
+		if err != nil {
+			logs.Logger.Errorf("BatchCreate TencentCloud failed.err: [%v], key pair[%v]", err, m.KeyPairName)
+			return nil, err
+		}
+		request.LoginSettings = &cvm.LoginSettings{KeyIds: common.StringPtrs([]string{keyId})}
+	} else if m.Password != "" {
+		request.LoginSettings = &cvm.LoginSettings{Password: common.StringPtr(m.Password)}
+	}
+	if m.UserData != "" {
+		request.UserData = common.StringPtr(base64.StdEncoding.EncodeToString([]byte(m.UserData)))
+	}
+	if len(m.Tags) > 0 {
+		tags := make([]*cvm.Tag, 0, len(m.Tags))
+		for _, tag := range m.Tags {
+			tags = append(tags, &cvm.Tag{Key: common.StringPtr(tag.Key), Value: common.StringPtr(tag.Value)})
+		}
+		request.TagSpecification = []*cvm.TagSpecification{{
+			ResourceType: common.StringPtr(ResourceInstance),
+			Tags:         tags,
+		}}
+	}
+	response, err := p.cvmClient.RunInstances(request)
+	if err != nil {
+		logs.Logger.Errorf("BatchCreate TencentCloud failed.err: [%v], req[%v]", err, m)
+		return nil, err
+	}
+	if response.Response != nil {
+		instanceIds = common.StringValues(response.Response.InstanceIdSet)
+	}
+	return instanceIds, nil
+}
+
+// chargePrepaid CVM 包年包月只支持按月购买
+func chargePrepaid(m cloud.Params) (*cvm.InstanceChargePrepaid, error) {
+	period := m.Period
+	switch m.PeriodUnit {
+	case cloud.PeriodUnitMonth:
+	case cloud.PeriodUnitYear:
+		period *= 12
+	default:
+		return nil, fmt.Errorf("%w: %s", cloud.ErrPeriodUnitNotSupported, m.PeriodUnit)
+	}
+	renewFlag := RenewManual
+	if m.AutoRenew {
+		renewFlag = RenewAuto
+	}
+	return &cvm.InstanceChargePrepaid{
+		Period:    common.Int64Ptr(int64(period)),
+		RenewFlag: common.StringPtr(renewFlag),
+	}, nil
+}
+
+func instanceName(tags []cloud.Tag) string {
+	for _, tag := range tags {
+		if tag.Key == cloud.ClusterName {
+			return tag.Value
+		}
+	}
+	return "bridgx"
+}
+
+func (p *TencentCloud) GetInstances(ids []string) (instances []cloud.Instance, err error) {
+	for _, onceIds := range utils.StringSliceSplit(ids, PageSize) {
+		request := cvm.NewDescribeInstancesRequest()
+		request.InstanceIds = common.StringPtrs(onceIds)
+		request.Limit = common.Int64Ptr(PageSize)
+		response, err := p.cvmClient.DescribeInstances(request)
+		if err != nil {
+			logs.Logger.Errorf("GetInstances TencentCloud failed.err: [%v], ids[%v]", err, onceIds)
+			return nil, err
+		}
+		if response.Response != nil {
+			instances = append(instances, generateInstances(response.Response.InstanceSet)...)
+		}
+	}
+	return instances, nil
+}
+
+func (p *TencentCloud) GetInstancesByTags(region string, tags []cloud.Tag) (instances []cloud.Instance, err error) {
+	filters := make([]*cvm.Filter, 0, len(tags))
+	for _, tag := range tags {
+		filters = append(filters, &cvm.Filter{
+			Name:   common.StringPtr("tag:" + tag.Key),
+			Values: common.StringPtrs([]string{tag.Value}),
+		})
+	}
+	var offset int64
+	for {
+		request := cvm.NewDescribeInstancesRequest()
+		request.Filters = filters
+		request.Offset = common.Int64Ptr(offset)
+		request.Limit = common.Int64Ptr(PageSize)
+		response, err := p.cvmClient.DescribeInstances(request)
+		if err != nil {
+			logs.Logger.Errorf("GetInstancesByTags TencentCloud failed.err: [%v], tags[%v]", err, tags)
+			return nil, err
+		}
+		if response.Response == nil {
+			break
+		}
+		instances = append(instances, generateInstances(response.Response.InstanceSet)...)
+		offset += PageSize
+		if response.Response.TotalCount == nil || *response.Response.TotalCount <= offset {
+			break
+		}
+	}
+	return instances, nil
+}
+
+func (p *TencentCloud) GetInstancesByCluster(regionId, clusterName string) (instances []cloud.Instance, err error) {
+	return p.GetInstancesByTags(regionId, []cloud.Tag{{
+		Key:   cloud.ClusterName,
+		Value: clusterName,
+	}})
+}
+
+var instanceStatus = map[string]string{
+	InstancePending: cloud.Pending,
+	InstanceRunning: cloud.Running,
+	InstanceStopped: cloud.Stopped,
+}
+
+var instanceChargeType = map[string]string{
+	PrePaid:  "PrePaid",
+	PostPaid: "PostPaid",
+	SpotPaid: "SpotPaid",
+}
+
+func generateInstances(cloudInstance []*cvm.Instance) (instances []cloud.Instance) {
+	for _, instance := range cloudInstance {
+		if instance == nil {
+			continue
+		}
+		ipOuter := ""
+		if len(instance.PublicIpAddresses) > 0 {
+			ipOuter = *instance.PublicIpAddresses[0]
+		}
+		network := &cloud.Network{
+			SecurityGroup: strings.Join(common.StringValues(instance.SecurityGroupIds), ","),
+		}
+		if instance.VirtualPrivateCloud != nil {
+			network.VpcId = stringValue(instance.VirtualPrivateCloud.VpcId)
+			network.SubnetId = stringValue(instance.VirtualPrivateCloud.SubnetId)
+		}
+		if instance.InternetAccessible != nil {
+			network.InternetChargeType = stringValue(instance.InternetAccessible.InternetChargeType)
+			if instance.InternetAccessible.InternetMaxBandwidthOut != nil {
+				network.InternetMaxBandwidthOut = int(*instance.InternetAccessible.InternetMaxBandwidthOut)
+			}
+		}
+		status := stringValue(instance.InstanceState)
+		if s, ok := instanceStatus[status]; ok {
+			status = s
+		}
+		instances = append(instances, cloud.Instance{
+			Id:       stringValue(instance.InstanceId),
+			CostWay:  instanceChargeType[stringValue(instance.InstanceChargeType)],
+			Provider: CloudName,
+			IpInner:  strings.Join(common.StringValues(instance.PrivateIpAddresses), ","),
+			IpOuter:  ipOuter,
+			Network:  network,
+			ImageId:  stringValue(instance.ImageId),
+			Status:   status,
+		})
+	}
+	return
+}
+
+// BatchDelete 某一批释放失败时继续释放其余批次, 返回所有失败批次的实例 ID
+func (p *TencentCloud) BatchDelete(ids []string, regionId string) error {
+	deleteErr := &cloud.BatchDeleteError{}
+	for _, onceIds := range utils.StringSliceSplit(ids, PageSize) {
+		request := cvm.NewTerminateInstancesRequest()
+		request.InstanceIds = common.StringPtrs(onceIds)
+		response, err := p.cvmClient.TerminateInstances(request)
+		if err != nil {
+			logs.Logger.Errorf("BatchDelete TencentCloud failed.err: [%v], ids[%v]", err, onceIds)
+			deleteErr.Add(onceIds, err)
+			continue
+		}
+		logs.Logger.Infof("[BatchDelete] requestId: %s", stringValue(response.Response.RequestId))
+	}
+	return deleteErr.ErrOrNil()
+}
+
+func (p *TencentCloud) StartInstance(id string) error {
+	request := cvm.NewStartInstancesRequest()
+	request.InstanceIds = common.StringPtrs([]string{id})
+	response, err := p.cvmClient.StartInstances(request)
+	if err != nil {
+		logs.Logger.Errorf("StartInstance TencentCloud failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	logs.Logger.Infof("[StartInstance] requestId: %s", stringValue(response.Response.RequestId))
+	return nil
+}
+
+func (p *TencentCloud) StopInstance(id string) error {
+	request := cvm.NewStopInstancesRequest()
+	request.InstanceIds = common.StringPtrs([]string{id})
+	response, err := p.cvmClient.StopInstances(request)
+	if err != nil {
+		logs.Logger.Errorf("StopInstance TencentCloud failed.err: [%v], id[%s]", err, id)
+		return err
+	}
+	logs.Logger.Infof("[StopInstance] requestId: %s", stringValue(response.Response.RequestId))
+	return nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,254 @@
+package tencent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+// cvmStandIn 本地模拟 CVM/VPC/计费接口, 按 X-TC-Action 返回预置数据并记录请求体
+type cvmStandIn struct {
+	mu        sync.Mutex
+	responses map[string]string
+	requests  map[string][]map[string]interface{}
+	// handlers 按请求体返回不同数据, 优先于 responses
+	handlers map[string]func(params map[string]interface{}) string
+}
+
+func newStandIn(t *testing.T, responses map[string]string) (*TencentCloud, *cvmStandIn) {
+	s := &cvmStandIn{responses: responses, requests: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	p, err := NewWithEndpoint("ak", "sk", DefaultRegion, strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, s
+}
+
+func (s *cvmStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := r.Header.Get("X-TC-Action")
+	body, _ := ioutil.ReadAll(r.Body)
+	params := make(map[string]interface{})
+	_ = json.Unmarshal(body, &params)
+	s.mu.Lock()
+	s.requests[action] = append(s.requests[action], params)
+	resp, ok := s.responses[action]
+	if handler, found := s.handlers[action]; found {
+		resp, ok = handler(params), true
+	}
+	s.mu.Unlock()
+	if !ok {
+		resp = `{"Error":{"Code":"InvalidAction","Message":"unknown action ` + action + `"}}`
+	}
+	body, _ = json.Marshal(map[string]interface{}{"Response": withRequestId(resp)})
+	_, _ = w.Write(body)
+}
+
+func withRequestId(resp string) map[string]interface{} {
+	res := make(map[string]interface{})
+	_ = json.Unmarshal([]byte(resp), &res)
+	res["RequestId"] = "req-1"
+	return res
+}
+
+func (s *cvmStandIn) lastRequest(action string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := s.requests[action]
+	if len(reqs) == 0 {
+		return nil
+	}
+	return reqs[len(reqs)-1]
+}
+
+func TestBatchCreate(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{
+		"RunInstances": `{"InstanceIdSet":["ins-1","ins-2"]}`,
+	})
+	ids, err := p.BatchCreate(cloud.Params{
+		InstanceType: "S5.MEDIUM4",
+		ImageId:      "img-1",
+		Zone:         "ap-guangzhou-3",
+		Network: &cloud.Network{
+			VpcId:                   "vpc-1",
+			SubnetId:                "subnet-1",
+			SecurityGroup:           "sg-1,sg-2",
+			InternetChargeType:      PayByTraffic,
+			InternetMaxBandwidthOut: 5,
+		},
+		Disks: &cloud.Disks{SystemDisk: cloud.DiskConf{Category: "CLOUD_PREMIUM", Size: 50}},
+		Tags:  []cloud.Tag{{Key: cloud.ClusterName, Value: "c1"}, {Key: cloud.TaskId, Value: "7"}},
+	}, 2)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("BatchCreate got %v, %v", ids, err)
+	}
+	req := s.lastRequest("RunInstances")
+	if req["InstanceChargeType"] != PostPaid || req["InstanceCount"] != float64(2) || req["InstanceName"] != "c1" {
+		t.Errorf("unexpected request: %v", req)
+	}
+	if sgs := req["SecurityGroupIds"].([]interface{}); len(sgs) != 2 {
+		t.Errorf("SecurityGroupIds got %v", sgs)
+	}
+	internet := req["InternetAccessible"].(map[string]interface{})
+	if internet["InternetChargeType"] != TrafficPostPaid {
+		t.Errorf("InternetAccessible got %v", internet)
+	}
+	tags := req["TagSpecification"].([]interface{})[0].(map[string]interface{})["Tags"].([]interface{})
+	if len(tags) != 2 {
+		t.Errorf("tags got %v", tags)
+	}
+}
+
+func TestBatchCreateSpot(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{
+		"RunInstances": `{"InstanceIdSet":["ins-1"]}`,
+	})
+	_, err := p.BatchCreate(cloud.Params{Zone: "ap-guangzhou-3", Spot: &cloud.SpotOption{PriceLimit: 0.25}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := s.lastRequest("RunInstances")
+	market, _ := req["InstanceMarketOptions"].(map[string]interface{})
+	if req["InstanceChargeType"] != SpotPaid || market["MarketType"] != MarketSpot ||
+		market["SpotOptions"].(map[string]interface{})["MaxPrice"] != "0.25" {
+		t.Errorf("unexpected request: %v", req)
+	}
+}
+
+func TestBatchCreateKeyPair(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{
+		"DescribeKeyPairs": `{"TotalCount":2,"KeyPairSet":[{"KeyId":"skey-2","KeyName":"ops-bak"},{"KeyId":"skey-1","KeyName":"ops"}]}`,
+		"RunInstances":     `{"InstanceIdSet":["ins-1"]}`,
+	})
+	_, err := p.BatchCreate(cloud.Params{Zone: "ap-guangzhou-3", KeyPairName: "ops", Password: "ignored"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, _ := s.lastRequest("RunInstances")["LoginSettings"].(map[string]interface{})
+	if keyIds, _ := login["KeyIds"].([]interface{}); len(keyIds) != 1 || keyIds[0] != "skey-1" || login["Password"] != nil {
+		t.Errorf("LoginSettings got %v", login)
+	}
+	if err = p.DeleteKeyPair(cloud.DeleteKeyPairRequest{KeyPairName: "missing"}); err == nil {
+		t.Error("want error for missing key pair")
+	}
+}
+
+func TestGetInstancesByCluster(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{
+		"DescribeInstances": `{"TotalCount":2,"InstanceSet":[
+			{"InstanceId":"ins-1","InstanceState":"RUNNING","InstanceChargeType":"POSTPAID_BY_HOUR","PrivateIpAddresses":["10.0.0.2"],"PublicIpAddresses":["1.1.1.1"],"SecurityGroupIds":["sg-1"],"VirtualPrivateCloud":{"VpcId":"vpc-1","SubnetId":"subnet-1"},"ImageId":"img-1"},
+			{"InstanceId":"ins-2","InstanceState":"PENDING","InstanceChargeType":"POSTPAID_BY_HOUR"}]}`,
+	})
+	instances, err := p.GetInstancesByCluster(DefaultRegion, "c1")
+	if err != nil || len(instances) != 2 {
+		t.Fatalf("GetInstancesByCluster got %v, %v", instances, err)
+	}
+	filter := s.lastRequest("DescribeInstances")["Filters"].([]interface{})[0].(map[string]interface{})
+	if filter["Name"] != "tag:"+cloud.ClusterName {
+		t.Errorf("filter got %v", filter)
+	}
+	if ins := instances[0]; ins.Status != cloud.Running || ins.IpInner != "10.0.0.2" || ins.IpOuter != "1.1.1.1" ||
+		ins.CostWay != "PostPaid" || ins.Network.SubnetId != "subnet-1" {
+		t.Errorf("instance got %+v", ins)
+	}
+	if instances[1].Status != cloud.Pending {
+		t.Errorf("instance got %+v", instances[1])
+	}
+}
+
+func TestBatchDeleteError(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{})
+	s.handlers = map[string]func(map[string]interface{}) string{
+		"TerminateInstances": func(params map[string]interface{}) string {
+			if ids, _ := params["InstanceIds"].([]interface{}); len(ids) == PageSize {
+				return `{"Error":{"Code":"RequestLimitExceeded","Message":"throttled"}}`
+			}
+			return `{}`
+		},
+	}
+	ids := make([]string, 0, PageSize+2)
+	for i := 0; i < PageSize+2; i++ {
+		ids = append(ids, fmt.Sprintf("ins-%d", i))
+	}
+	err := p.BatchDelete(ids, DefaultRegion)
+	deleteErr := &cloud.BatchDeleteError{}
+	if !errors.As(err, &deleteErr) {
+		t.Fatalf("want BatchDeleteError, got %v", err)
+	}
+	if !reflect.DeepEqual(deleteErr.FailedIds, ids[:PageSize]) {
+		t.Errorf("failed ids got %v", deleteErr.FailedIds)
+	}
+	if reqs := len(s.requests["TerminateInstances"]); reqs != 2 {
+		t.Errorf("want 2 TerminateInstances requests, got %d", reqs)
+	}
+	if err = p.BatchDelete(ids[PageSize:], DefaultRegion); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
+
+func TestSecurityGroupRule(t *testing.T) {
+	p, s := newStandIn(t, map[string]string{
+		"CreateSecurityGroupPolicies": `{}`,
+		"DescribeSecurityGroupPolicies": `{"SecurityGroupPolicySet":{"Ingress":[
+			{"Protocol":"TCP","Port":"22","CidrBlock":"0.0.0.0/0","Action":"ACCEPT"},
+			{"Protocol":"TCP","Port":"8000-9000","CidrBlock":"10.0.0.0/8","Action":"ACCEPT"}],
+			"Egress":[{"Protocol":"ALL","Port":"ALL","CidrBlock":"0.0.0.0/0","Action":"ACCEPT"}]}}`,
+	})
+	err := p.AddIngressSecurityGroupRule(cloud.AddSecurityGroupRuleRequest{
+		SecurityGroupId: "sg-1", IpProtocol: "tcp", PortRange: "8000/9000", CidrIp: "10.0.0.0/8",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := s.lastRequest("CreateSecurityGroupPolicies")["SecurityGroupPolicySet"].(map[string]interface{})["Ingress"].([]interface{})[0].(map[string]interface{})
+	if policy["Port"] != "8000-9000" || policy["Protocol"] != "TCP" || policy["Action"] != PolicyAccept {
+		t.Errorf("policy got %v", policy)
+	}
+	res, err := p.DescribeGroupRules(cloud.DescribeGroupRulesRequest{SecurityGroupId: "sg-1"})
+	if err != nil || len(res.Rules) != 3 {
+		t.Fatalf("DescribeGroupRules got %v, %v", res, err)
+	}
+	want := []string{"22/22", "8000/9000", "-1/-1"}
+	for i, rule := range res.Rules {
+		if rule.PortRange != want[i] {
+			t.Errorf("rule %d port got %s, want %s", i, rule.PortRange, want[i])
+		}
+	}
+}
+
+func TestDescribeAvailableResource(t *testing.T) {
+	p, _ := newStandIn(t, map[string]string{
+		"DescribeZoneInstanceConfigInfos": `{"InstanceTypeQuotaSet":[
+			{"Zone":"ap-guangzhou-3","InstanceType":"S5.MEDIUM4","Status":"SELL"},
+			{"Zone":"ap-guangzhou-3","InstanceType":"S5.MEDIUM4","Status":"SELL"},
+			{"Zone":"ap-guangzhou-4","InstanceType":"S5.LARGE8","Status":"SOLD_OUT"}]}`,
+	})
+	res, err := p.DescribeAvailableResource(cloud.DescribeAvailableResourceRequest{RegionId: DefaultRegion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types := res.InstanceTypes["ap-guangzhou-3"]; len(types) != 1 || types[0].StatusCategory != "WithStock" {
+		t.Errorf("ap-guangzhou-3 got %v", types)
+	}
+	if types := res.InstanceTypes["ap-guangzhou-4"]; len(types) != 1 || types[0].StatusCategory != "WithoutStock" {
+		t.Errorf("ap-guangzhou-4 got %v", types)
+	}
+}
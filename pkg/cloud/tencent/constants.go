@@ -0,0 +1,70 @@
+package tencent
+
+const (
+	CloudName     = "TencentCloud"
+	DefaultRegion = "ap-guangzhou"
+	PageSize      = 100
+	TimeFormat    = "2006-01-02 15:04:05"
+)
+
+// 实例状态
+const (
+	InstancePending = "PENDING"
+	InstanceRunning = "RUNNING"
+	InstanceStopped = "STOPPED"
+)
+
+// 计费模式
+const (
+	PrePaid  = "PREPAID"
+	PostPaid = "POSTPAID_BY_HOUR"
+	SpotPaid = "SPOTPAID"
+)
+
+// 包年包月续费标识
+const (
+	RenewAuto   = "NOTIFY_AND_AUTO_RENEW"
+	RenewManual = "NOTIFY_AND_MANUAL_RENEW"
+)
+
+// 竞价实例
+const (
+	MarketSpot      = "spot"
+	SpotTypeOneTime = "one-time"
+)
+
+// 公网计费模式
+const (
+	TrafficPostPaid   = "TRAFFIC_POSTPAID_BY_HOUR"
+	BandwidthPostPaid = "BANDWIDTH_POSTPAID_BY_HOUR"
+	PayByTraffic      = "PayByTraffic"
+)
+
+const (
+	ResourceInstance = "instance"
+	StateAvailable   = "AVAILABLE"
+	SellStatus       = "SELL"
+	ImagePublic      = "PUBLIC_IMAGE"
+	PolicyAccept     = "ACCEPT"
+	PortAll          = "ALL"
+	ProtocolAll      = "all"
+	DirectionIn      = "ingress"
+	DirectionOut     = "egress"
+	FilterKeyName    = "key-name"
+	DefaultProjectId = 0
+)
+
+// 订单付费模式
+const (
+	PrePay  = "prePay"
+	PostPay = "postPay"
+)
+
+// 订单状态
+const (
+	DealUnpaid    = 1
+	DealPaid      = 2
+	DealShipping  = 3
+	DealSucceeded = 4
+	DealCancelled = 11
+)
@@ -0,0 +1,119 @@
+package tencent
+
+import (
+	"fmt"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// CreateKeyPair 密钥对创建在默认项目下
+func (p *TencentCloud) CreateKeyPair(req cloud.CreateKeyPairRequest) (cloud.CreateKeyPairResponse, error) {
+	request := cvm.NewCreateKeyPairRequest()
+	request.KeyName = common.StringPtr(req.KeyPairName)
+	request.ProjectId = common.Int64Ptr(DefaultProjectId)
+	response, err := p.cvmClient.CreateKeyPair(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateKeyPair TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateKeyPairResponse{}, err
+	}
+	if response.Response == nil || response.Response.KeyPair == nil {
+		return cloud.CreateKeyPairResponse{}, nil
+	}
+	return cloud.CreateKeyPairResponse{
+		KeyPairId:   stringValue(response.Response.KeyPair.KeyId),
+		KeyPairName: stringValue(response.Response.KeyPair.KeyName),
+		PrivateKey:  stringValue(response.Response.KeyPair.PrivateKey),
+	}, nil
+}
+
+func (p *TencentCloud) ImportKeyPair(req cloud.ImportKeyPairRequest) (cloud.ImportKeyPairResponse, error) {
+	request := cvm.NewImportKeyPairRequest()
+	request.KeyName = common.StringPtr(req.KeyPairName)
+	request.ProjectId = common.Int64Ptr(DefaultProjectId)
+	request.PublicKey = common.StringPtr(req.PublicKey)
+	response, err := p.cvmClient.ImportKeyPair(request)
+	if err != nil {
+		logs.Logger.Errorf("ImportKeyPair TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.ImportKeyPairResponse{}, err
+	}
+	if response.Response == nil {
+		return cloud.ImportKeyPairResponse{}, nil
+	}
+	return cloud.ImportKeyPairResponse{
+		KeyPairId:   stringValue(response.Response.KeyId),
+		KeyPairName: req.KeyPairName,
+	}, nil
+}
+
+func (p *TencentCloud) DescribeKeyPairs(req cloud.DescribeKeyPairsRequest) (cloud.DescribeKeyPairsResponse, error) {
+	keyPairs, err := p.listKeyPairs(nil)
+	if err != nil {
+		logs.Logger.Errorf("DescribeKeyPairs TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeKeyPairsResponse{}, err
+	}
+	return cloud.DescribeKeyPairsResponse{KeyPairs: keyPairs}, nil
+}
+
+// DeleteKeyPair 腾讯云按 KeyId 删除, 先按名称查出 KeyId
+func (p *TencentCloud) DeleteKeyPair(req cloud.DeleteKeyPairRequest) error {
+	keyId, err := p.keyPairId(req.KeyPairName)
+	if err != nil {
+		logs.Logger.Errorf("DeleteKeyPair TencentCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	request := cvm.NewDeleteKeyPairsRequest()
+	request.KeyIds = common.StringPtrs([]string{keyId})
+	_, err = p.cvmClient.DeleteKeyPairs(request)
+	if err != nil {
+		logs.Logger.Errorf("DeleteKeyPair TencentCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
+// keyPairId 创建实例和删除密钥对都需要 KeyId, 集群中保存的是名称
+func (p *TencentCloud) keyPairId(name string) (string, error) {
+	keyPairs, err := p.listKeyPairs([]*cvm.Filter{{
+		Name:   common.StringPtr(FilterKeyName),
+		Values: common.StringPtrs([]string{name}),
+	}})
+	if err != nil {
+		return "", err
+	}
+	for _, keyPair := range keyPairs {
+		if keyPair.KeyPairName == name {
+			return keyPair.KeyPairId, nil
+		}
+	}
+	return "", fmt.Errorf("key pair %s not found", name)
+}
+
+func (p *TencentCloud) listKeyPairs(filters []*cvm.Filter) ([]cloud.KeyPair, error) {
+	keyPairs := make([]cloud.KeyPair, 0, 16)
+	for offset := 0; ; offset += PageSize {
+		request := cvm.NewDescribeKeyPairsRequest()
+		request.Filters = filters
+		request.Offset = common.Int64Ptr(int64(offset))
+		request.Limit = common.Int64Ptr(PageSize)
+		response, err := p.cvmClient.DescribeKeyPairs(request)
+		if err != nil {
+			return nil, err
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, keyPair := range response.Response.KeyPairSet {
+			keyPairs = append(keyPairs, cloud.KeyPair{
+				KeyPairId:   stringValue(keyPair.KeyId),
+				KeyPairName: stringValue(keyPair.KeyName),
+			})
+		}
+		if len(response.Response.KeyPairSet) < PageSize {
+			break
+		}
+	}
+	return keyPairs, nil
+}
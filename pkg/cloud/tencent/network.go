@@ -0,0 +1,348 @@
+package tencent
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+// CreateVPC 腾讯云 VPC 创建后即可用, 无中间状态
+func (p *TencentCloud) CreateVPC(req cloud.CreateVpcRequest) (cloud.CreateVpcResponse, error) {
+	request := vpc.NewCreateVpcRequest()
+	request.VpcName = common.StringPtr(req.VpcName)
+	request.CidrBlock = common.StringPtr(req.CidrBlock)
+	response, err := p.vpcClient.CreateVpc(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateVPC TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcResponse{}, err
+	}
+	if response.Response == nil || response.Response.Vpc == nil {
+		return cloud.CreateVpcResponse{}, nil
+	}
+	return cloud.CreateVpcResponse{
+		VpcId:     stringValue(response.Response.Vpc.VpcId),
+		RequestId: stringValue(response.Response.RequestId),
+	}, nil
+}
+
+func (p *TencentCloud) GetVPC(req cloud.GetVpcRequest) (cloud.GetVpcResponse, error) {
+	request := vpc.NewDescribeVpcsRequest()
+	request.VpcIds = common.StringPtrs([]string{req.VpcId})
+	response, err := p.vpcClient.DescribeVpcs(request)
+	if err != nil {
+		logs.Logger.Errorf("GetVPC TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.GetVpcResponse{}, err
+	}
+	if response.Response == nil || len(response.Response.VpcSet) == 0 {
+		return cloud.GetVpcResponse{}, nil
+	}
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.GetVpcResponse{}, err
+	}
+	switchIds := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		switchIds = append(switchIds, stringValue(subnet.SubnetId))
+	}
+	res := toVpc(response.Response.VpcSet[0], req.RegionId)
+	res.SwitchIds = switchIds
+	return cloud.GetVpcResponse{Vpc: res}, nil
+}
+
+func toVpc(v *vpc.Vpc, regionId string) cloud.VPC {
+	return cloud.VPC{
+		VpcId:     stringValue(v.VpcId),
+		VpcName:   stringValue(v.VpcName),
+		CidrBlock: stringValue(v.CidrBlock),
+		RegionId:  regionId,
+		Status:    cloud.VPCStatusAvailable,
+		CreateAt:  stringValue(v.CreatedTime),
+	}
+}
+
+func (p *TencentCloud) DescribeVpcs(req cloud.DescribeVpcsRequest) (cloud.DescribeVpcsResponse, error) {
+	vpcs := make([]cloud.VPC, 0, 128)
+	for offset := 0; ; offset += PageSize {
+		request := vpc.NewDescribeVpcsRequest()
+		request.Offset = common.StringPtr(strconv.Itoa(offset))
+		request.Limit = common.StringPtr(strconv.Itoa(PageSize))
+		response, err := p.vpcClient.DescribeVpcs(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeVpcs TencentCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeVpcsResponse{}, err
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, v := range response.Response.VpcSet {
+			vpcs = append(vpcs, toVpc(v, req.RegionId))
+		}
+		if len(response.Response.VpcSet) < PageSize {
+			break
+		}
+	}
+	return cloud.DescribeVpcsResponse{Vpcs: vpcs}, nil
+}
+
+func (p *TencentCloud) CreateSwitch(req cloud.CreateSwitchRequest) (cloud.CreateSwitchResponse, error) {
+	request := vpc.NewCreateSubnetRequest()
+	request.VpcId = common.StringPtr(req.VpcId)
+	request.SubnetName = common.StringPtr(req.VSwitchName)
+	request.CidrBlock = common.StringPtr(req.CidrBlock)
+	request.Zone = common.StringPtr(req.ZoneId)
+	response, err := p.vpcClient.CreateSubnet(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateSwitch TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSwitchResponse{}, err
+	}
+	if response.Response == nil || response.Response.Subnet == nil {
+		return cloud.CreateSwitchResponse{}, nil
+	}
+	return cloud.CreateSwitchResponse{
+		SwitchId:  stringValue(response.Response.Subnet.SubnetId),
+		RequestId: stringValue(response.Response.RequestId),
+	}, nil
+}
+
+func (p *TencentCloud) GetSwitch(req cloud.GetSwitchRequest) (cloud.GetSwitchResponse, error) {
+	request := vpc.NewDescribeSubnetsRequest()
+	request.SubnetIds = common.StringPtrs([]string{req.SwitchId})
+	response, err := p.vpcClient.DescribeSubnets(request)
+	if err != nil {
+		logs.Logger.Errorf("GetSwitch TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.GetSwitchResponse{}, err
+	}
+	if response.Response == nil || len(response.Response.SubnetSet) == 0 {
+		return cloud.GetSwitchResponse{}, nil
+	}
+	return cloud.GetSwitchResponse{Switch: toSwitch(response.Response.SubnetSet[0])}, nil
+}
+
+func (p *TencentCloud) DescribeSwitches(req cloud.DescribeSwitchesRequest) (cloud.DescribeSwitchesResponse, error) {
+	subnets, err := p.listSubnets(req.VpcId)
+	if err != nil {
+		return cloud.DescribeSwitchesResponse{}, err
+	}
+	switches := make([]cloud.Switch, 0, len(subnets))
+	for _, subnet := range subnets {
+		switches = append(switches, toSwitch(subnet))
+	}
+	return cloud.DescribeSwitchesResponse{Switches: switches}, nil
+}
+
+func (p *TencentCloud) listSubnets(vpcId string) ([]*vpc.Subnet, error) {
+	subnets := make([]*vpc.Subnet, 0, PageSize)
+	for offset := 0; ; offset += PageSize {
+		request := vpc.NewDescribeSubnetsRequest()
+		request.Filters = []*vpc.Filter{{
+			Name:   common.StringPtr("vpc-id"),
+			Values: common.StringPtrs([]string{vpcId}),
+		}}
+		request.Offset = common.StringPtr(strconv.Itoa(offset))
+		request.Limit = common.StringPtr(strconv.Itoa(PageSize))
+		response, err := p.vpcClient.DescribeSubnets(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeSubnets TencentCloud failed.err: [%v], vpcId[%s]", err, vpcId)
+			return nil, err
+		}
+		if response.Response == nil {
+			break
+		}
+		subnets = append(subnets, response.Response.SubnetSet...)
+		if len(response.Response.SubnetSet) < PageSize {
+			break
+		}
+	}
+	return subnets, nil
+}
+
+func toSwitch(subnet *vpc.Subnet) cloud.Switch {
+	isDefault := 0
+	if subnet.IsDefault != nil && *subnet.IsDefault {
+		isDefault = 1
+	}
+	availableIpCount := 0
+	if subnet.AvailableIpAddressCount != nil {
+		availableIpCount = int(*subnet.AvailableIpAddressCount)
+	}
+	return cloud.Switch{
+		VpcId:                   stringValue(subnet.VpcId),
+		SwitchId:                stringValue(subnet.SubnetId),
+		Name:                    stringValue(subnet.SubnetName),
+		IsDefault:               isDefault,
+		AvailableIpAddressCount: availableIpCount,
+		VStatus:                 cloud.VPCStatusAvailable,
+		CreateAt:                stringValue(subnet.CreatedTime),
+		ZoneId:                  stringValue(subnet.Zone),
+		CidrBlock:               stringValue(subnet.CidrBlock),
+	}
+}
+
+// CreateSecurityGroup 腾讯云安全组不归属于 VPC, VpcId 仅用于回填
+func (p *TencentCloud) CreateSecurityGroup(req cloud.CreateSecurityGroupRequest) (cloud.CreateSecurityGroupResponse, error) {
+	request := vpc.NewCreateSecurityGroupRequest()
+	request.GroupName = common.StringPtr(req.SecurityGroupName)
+	request.GroupDescription = common.StringPtr(req.SecurityGroupName)
+	response, err := p.vpcClient.CreateSecurityGroup(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateSecurityGroup TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSecurityGroupResponse{}, err
+	}
+	if response.Response == nil || response.Response.SecurityGroup == nil {
+		return cloud.CreateSecurityGroupResponse{}, nil
+	}
+	return cloud.CreateSecurityGroupResponse{
+		SecurityGroupId: stringValue(response.Response.SecurityGroup.SecurityGroupId),
+		RequestId:       stringValue(response.Response.RequestId),
+	}, nil
+}
+
+func (p *TencentCloud) AddIngressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	policy, err := toPolicy(req)
+	if err != nil {
+		return err
+	}
+	return p.addPolicies(req, &vpc.SecurityGroupPolicySet{Ingress: []*vpc.SecurityGroupPolicy{policy}})
+}
+
+func (p *TencentCloud) AddEgressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	policy, err := toPolicy(req)
+	if err != nil {
+		return err
+	}
+	return p.addPolicies(req, &vpc.SecurityGroupPolicySet{Egress: []*vpc.SecurityGroupPolicy{policy}})
+}
+
+func (p *TencentCloud) addPolicies(req cloud.AddSecurityGroupRuleRequest, policySet *vpc.SecurityGroupPolicySet) error {
+	request := vpc.NewCreateSecurityGroupPoliciesRequest()
+	request.SecurityGroupId = common.StringPtr(req.SecurityGroupId)
+	request.SecurityGroupPolicySet = policySet
+	_, err := p.vpcClient.CreateSecurityGroupPolicies(request)
+	if err != nil {
+		logs.Logger.Errorf("AddSecurityGroupRule TencentCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
+func toPolicy(req cloud.AddSecurityGroupRuleRequest) (*vpc.SecurityGroupPolicy, error) {
+	port, err := toPort(req.PortRange)
+	if err != nil {
+		return nil, err
+	}
+	policy := &vpc.SecurityGroupPolicy{
+		Protocol: common.StringPtr(strings.ToUpper(req.IpProtocol)),
+		Port:     common.StringPtr(port),
+		Action:   common.StringPtr(PolicyAccept),
+	}
+	if strings.EqualFold(req.IpProtocol, ProtocolAll) {
+		policy.Port = nil
+	}
+	if req.GroupId != "" {
+		policy.SecurityGroupId = common.StringPtr(req.GroupId)
+	} else {
+		policy.CidrBlock = common.StringPtr(req.CidrIp)
+	}
+	return policy, nil
+}
+
+// toPort 端口范围由 "1/65535" 转换为腾讯云的 "1-65535", 单端口为 "22", 全部端口为 "ALL"
+func toPort(portRange string) (string, error) {
+	if portRange == "" {
+		return PortAll, nil
+	}
+	ports := strings.Split(portRange, "/")
+	if len(ports) != 2 {
+		return "", errors.New("invalid port range: " + portRange)
+	}
+	if ports[0] == "-1" || (ports[0] == "1" && ports[1] == "65535") {
+		return PortAll, nil
+	}
+	if ports[0] == ports[1] {
+		return ports[0], nil
+	}
+	return ports[0] + "-" + ports[1], nil
+}
+
+func fromPort(port string) string {
+	switch {
+	case port == "" || port == PortAll:
+		return "-1/-1"
+	case strings.Contains(port, "-"):
+		return strings.Replace(port, "-", "/", 1)
+	default:
+		return port + "/" + port
+	}
+}
+
+func (p *TencentCloud) DescribeSecurityGroups(req cloud.DescribeSecurityGroupsRequest) (cloud.DescribeSecurityGroupsResponse, error) {
+	groups := make([]cloud.SecurityGroup, 0, 128)
+	for offset := 0; ; offset += PageSize {
+		request := vpc.NewDescribeSecurityGroupsRequest()
+		request.Offset = common.StringPtr(strconv.Itoa(offset))
+		request.Limit = common.StringPtr(strconv.Itoa(PageSize))
+		response, err := p.vpcClient.DescribeSecurityGroups(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeSecurityGroups TencentCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeSecurityGroupsResponse{}, err
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, group := range response.Response.SecurityGroupSet {
+			groups = append(groups, cloud.SecurityGroup{
+				SecurityGroupId:   stringValue(group.SecurityGroupId),
+				SecurityGroupName: stringValue(group.SecurityGroupName),
+				CreateAt:          stringValue(group.CreatedTime),
+				VpcId:             req.VpcId,
+				RegionId:          req.RegionId,
+			})
+		}
+		if len(response.Response.SecurityGroupSet) < PageSize {
+			break
+		}
+	}
+	return cloud.DescribeSecurityGroupsResponse{Groups: groups}, nil
+}
+
+func (p *TencentCloud) DescribeGroupRules(req cloud.DescribeGroupRulesRequest) (cloud.DescribeGroupRulesResponse, error) {
+	request := vpc.NewDescribeSecurityGroupPoliciesRequest()
+	request.SecurityGroupId = common.StringPtr(req.SecurityGroupId)
+	response, err := p.vpcClient.DescribeSecurityGroupPolicies(request)
+	if err != nil {
+		logs.Logger.Errorf("DescribeGroupRules TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeGroupRulesResponse{}, err
+	}
+	if response.Response == nil || response.Response.SecurityGroupPolicySet == nil {
+		return cloud.DescribeGroupRulesResponse{}, nil
+	}
+	policySet := response.Response.SecurityGroupPolicySet
+	rules := make([]cloud.SecurityGroupRule, 0, len(policySet.Ingress)+len(policySet.Egress))
+	rules = append(rules, toRules(req.SecurityGroupId, DirectionIn, policySet.Ingress)...)
+	rules = append(rules, toRules(req.SecurityGroupId, DirectionOut, policySet.Egress)...)
+	return cloud.DescribeGroupRulesResponse{Rules: rules}, nil
+}
+
+func toRules(groupId, direction string, policies []*vpc.SecurityGroupPolicy) []cloud.SecurityGroupRule {
+	rules := make([]cloud.SecurityGroupRule, 0, len(policies))
+	for _, policy := range policies {
+		if stringValue(policy.Action) != PolicyAccept {
+			continue
+		}
+		rules = append(rules, cloud.SecurityGroupRule{
+			SecurityGroupId: groupId,
+			PortRange:       fromPort(stringValue(policy.Port)),
+			Protocol:        strings.ToLower(stringValue(policy.Protocol)),
+			Direction:       direction,
+			GroupId:         stringValue(policy.SecurityGroupId),
+			CidrIp:          stringValue(policy.CidrBlock),
+			CreateAt:        stringValue(policy.ModifyTime),
+		})
+	}
+	return rules
+}
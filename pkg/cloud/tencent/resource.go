@@ -0,0 +1,255 @@
+package tencent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	billing "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/billing/v20180709"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func (p *TencentCloud) GetRegions() (cloud.GetRegionsResponse, error) {
+	response, err := p.cvmClient.DescribeRegions(cvm.NewDescribeRegionsRequest())
+	if err != nil {
+		logs.Logger.Errorf("GetRegions TencentCloud failed.err: [%v]", err)
+		return cloud.GetRegionsResponse{}, err
+	}
+	regions := make([]cloud.Region, 0)
+	if response.Response == nil {
+		return cloud.GetRegionsResponse{Regions: regions}, nil
+	}
+	for _, region := range response.Response.RegionSet {
+		if stringValue(region.RegionState) != StateAvailable {
+			continue
+		}
+		regions = append(regions, cloud.Region{
+			RegionId:  stringValue(region.Region),
+			LocalName: stringValue(region.RegionName),
+		})
+	}
+	return cloud.GetRegionsResponse{Regions: regions}, nil
+}
+
+// GetZones 可用区随客户端所在地域返回
+func (p *TencentCloud) GetZones(req cloud.GetZonesRequest) (cloud.GetZonesResponse, error) {
+	response, err := p.cvmClient.DescribeZones(cvm.NewDescribeZonesRequest())
+	if err != nil {
+		logs.Logger.Errorf("GetZones TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.GetZonesResponse{}, err
+	}
+	zones := make([]cloud.Zone, 0)
+	if response.Response == nil {
+		return cloud.GetZonesResponse{Zones: zones}, nil
+	}
+	for _, zone := range response.Response.ZoneSet {
+		if stringValue(zone.ZoneState) != StateAvailable {
+			continue
+		}
+		zones = append(zones, cloud.Zone{
+			ZoneId:    stringValue(zone.Zone),
+			LocalName: stringValue(zone.ZoneName),
+		})
+	}
+	return cloud.GetZonesResponse{Zones: zones}, nil
+}
+
+func (p *TencentCloud) DescribeAvailableResource(req cloud.DescribeAvailableResourceRequest) (cloud.DescribeAvailableResourceResponse, error) {
+	request := cvm.NewDescribeZoneInstanceConfigInfosRequest()
+	request.Filters = []*cvm.Filter{{
+		Name:   common.StringPtr("instance-charge-type"),
+		Values: common.StringPtrs([]string{PostPaid}),
+	}}
+	if req.ZoneId != "" {
+		request.Filters = append(request.Filters, &cvm.Filter{
+			Name:   common.StringPtr("zone"),
+			Values: common.StringPtrs([]string{req.ZoneId}),
+		})
+	}
+	response, err := p.cvmClient.DescribeZoneInstanceConfigInfos(request)
+	if err != nil {
+		logs.Logger.Errorf("DescribeAvailableResource TencentCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.DescribeAvailableResourceResponse{}, err
+	}
+	res := make(map[string][]cloud.InstanceType)
+	if response.Response == nil {
+		return cloud.DescribeAvailableResourceResponse{InstanceTypes: res}, nil
+	}
+	seen := make(map[string]bool)
+	for _, item := range response.Response.InstanceTypeQuotaSet {
+		zoneId := stringValue(item.Zone)
+		typeName := stringValue(item.InstanceType)
+		if seen[zoneId+"/"+typeName] {
+			continue
+		}
+		seen[zoneId+"/"+typeName] = true
+		status, statusCategory := "SoldOut", "WithoutStock"
+		if stringValue(item.Status) == SellStatus {
+			status, statusCategory = "Available", "WithStock"
+		}
+		res[zoneId] = append(res[zoneId], cloud.InstanceType{
+			Status:         status,
+			StatusCategory: statusCategory,
+			Value:          typeName,
+		})
+	}
+	return cloud.DescribeAvailableResourceResponse{InstanceTypes: res}, nil
+}
+
+func (p *TencentCloud) DescribeInstanceTypes(req cloud.DescribeInstanceTypesRequest) (cloud.DescribeInstanceTypesResponse, error) {
+	infos := make([]cloud.InstanceInfo, 0, len(req.TypeName))
+	seen := make(map[string]bool, len(req.TypeName))
+	// 单个过滤条件最多 10 个值
+	for _, names := range utils.StringSliceSplit(req.TypeName, 10) {
+		request := cvm.NewDescribeInstanceTypeConfigsRequest()
+		request.Filters = []*cvm.Filter{{
+			Name:   common.StringPtr("instance-type"),
+			Values: common.StringPtrs(names),
+		}}
+		response, err := p.cvmClient.DescribeInstanceTypeConfigs(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeInstanceTypes TencentCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeInstanceTypesResponse{}, err
+		}
+		if response.Response == nil {
+			continue
+		}
+		for _, config := range response.Response.InstanceTypeConfigSet {
+			typeName := stringValue(config.InstanceType)
+			if seen[typeName] {
+				continue
+			}
+			seen[typeName] = true
+			info := cloud.InstanceInfo{
+				Family:      stringValue(config.InstanceFamily),
+				InsTypeName: typeName,
+			}
+			if config.CPU != nil {
+				info.Core = int(*config.CPU)
+			}
+			if config.Memory != nil {
+				info.Memory = int(*config.Memory)
+			}
+			infos = append(infos, info)
+		}
+	}
+	return cloud.DescribeInstanceTypesResponse{Infos: infos}, nil
+}
+
+func (p *TencentCloud) DescribeImages(req cloud.DescribeImagesRequest) (cloud.DescribeImagesResponse, error) {
+	images := make([]cloud.Image, 0, PageSize)
+	for offset := uint64(0); ; offset += PageSize {
+		request := cvm.NewDescribeImagesRequest()
+		request.Filters = []*cvm.Filter{{
+			Name:   common.StringPtr("image-type"),
+			Values: common.StringPtrs([]string{ImagePublic}),
+		}}
+		request.Offset = common.Uint64Ptr(offset)
+		request.Limit = common.Uint64Ptr(PageSize)
+		response, err := p.cvmClient.DescribeImages(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeImages TencentCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeImagesResponse{}, err
+		}
+		if response.Response == nil {
+			break
+		}
+		for _, img := range response.Response.ImageSet {
+			osType := "linux"
+			if strings.Contains(strings.ToLower(stringValue(img.Platform)), "windows") {
+				osType = "windows"
+			}
+			images = append(images, cloud.Image{
+				OsType:  osType,
+				OsName:  stringValue(img.OsName),
+				ImageId: stringValue(img.ImageId),
+			})
+		}
+		if len(response.Response.ImageSet) < PageSize {
+			break
+		}
+	}
+	return cloud.DescribeImagesResponse{Images: images}, nil
+}
+
+var PayStatus = map[int64]int8{
+	DealUnpaid:    constants.Unpaid,
+	DealPaid:      constants.Paid,
+	DealShipping:  constants.Paid,
+	DealSucceeded: constants.Paid,
+	DealCancelled: constants.Cancelled,
+}
+
+var chargeType = map[string]string{
+	PrePay:  constants.PostPaid,
+	PostPay: constants.PayAsYouGo,
+}
+
+// GetOrders 订单金额单位为分
+func (p *TencentCloud) GetOrders(req cloud.GetOrdersRequest) (cloud.GetOrdersResponse, error) {
+	request := billing.NewDescribeDealsByCondRequest()
+	request.StartTime = common.StringPtr(req.StartTime.Format(TimeFormat))
+	request.EndTime = common.StringPtr(req.EndTime.Format(TimeFormat))
+	request.Limit = common.Int64Ptr(int64(req.PageSize))
+	// Offset 为页码, 从 0 开始
+	request.Offset = common.Int64Ptr(int64(req.PageNum - 1))
+	response, err := p.billingClient.DescribeDealsByCond(request)
+	if err != nil {
+		logs.Logger.Errorf("GetOrders TencentCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.GetOrdersResponse{}, err
+	}
+	if response.Response == nil || len(response.Response.Deals) == 0 {
+		return cloud.GetOrdersResponse{}, nil
+	}
+	orders := make([]cloud.Order, 0, len(response.Response.Deals))
+	for _, deal := range response.Response.Deals {
+		orderTime, _ := time.ParseInLocation(TimeFormat, stringValue(deal.CreateTime), time.Local)
+		var cost float32
+		if deal.RealTotalCost != nil {
+			cost = float32(*deal.RealTotalCost) / 100
+		}
+		var status int64
+		if deal.Status != nil {
+			status = *deal.Status
+		}
+		orders = append(orders, cloud.Order{
+			OrderId:        stringValue(deal.OrderId),
+			OrderTime:      orderTime,
+			Product:        stringValue(deal.ProductCode),
+			Quantity:       1,
+			UsageStartTime: orderTime,
+			UsageEndTime:   usageEndTime(orderTime, deal.TimeSpan, stringValue(deal.TimeUnit)),
+			ChargeType:     chargeType[stringValue(deal.PayMode)],
+			PayStatus:      PayStatus[status],
+			Currency:       stringValue(deal.Currency),
+			Cost:           cost,
+			Extend: map[string]interface{}{
+				"big_deal_id":      stringValue(deal.BigDealId),
+				"sub_product_code": stringValue(deal.SubProductCode),
+			},
+		})
+	}
+	return cloud.GetOrdersResponse{Orders: orders}, nil
+}
+
+func usageEndTime(start time.Time, span *float64, unit string) time.Time {
+	if span == nil {
+		return start
+	}
+	n := int(*span)
+	switch unit {
+	case "y":
+		return start.AddDate(n, 0, 0)
+	case "m":
+		return start.AddDate(0, n, 0)
+	case "d":
+		return start.AddDate(0, 0, n)
+	case "h":
+		return start.Add(time.Duration(n) * time.Hour)
+	}
+	return start
+}
@@ -6,8 +6,12 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
 )
 
+// ErrInvalidCipherText 密文长度或填充不合法, 通常是使用了错误的 key 或数据不是 AESEncrypt 的输出
+var ErrInvalidCipherText = errors.New("invalid aes cipher text")
+
 func AESEncrypt(key, plaintext string) (string, error) {
 	// 注意,这里的 key必须是 16, 24, or 32 bytes
 	keyB := ensureKeyLength(key)
@@ -35,11 +39,17 @@ func AESDecrypt(key string, ct16 string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return "", ErrInvalidCipherText
+	}
 	iv := make([]byte, block.BlockSize())
 	blockMode := cipher.NewCBCDecrypter(block, iv)
 	origData := make([]byte, len(ciphertext))
 	blockMode.CryptBlocks(origData, ciphertext)
-	origData = unPadding(origData)
+	origData, err = unPadding(origData, block.BlockSize())
+	if err != nil {
+		return "", err
+	}
 	return string(origData), nil
 }
 
@@ -54,8 +64,14 @@ func padding(src []byte, blockSize int) []byte {
 	return append(src, padText...)
 }
 
-func unPadding(src []byte) []byte {
+func unPadding(src []byte, blockSize int) ([]byte, error) {
 	length := len(src)
+	if length == 0 {
+		return nil, ErrInvalidCipherText
+	}
 	unPad := int(src[length-1])
-	return src[:(length - unPad)]
+	if unPad == 0 || unPad > blockSize || unPad > length {
+		return nil, ErrInvalidCipherText
+	}
+	return src[:(length - unPad)], nil
 }
@@ -0,0 +1,22 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestAESDecrypt(t *testing.T) {
+	ct, err := AESEncrypt("key", "plaintext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := AESDecrypt("key", ct); err != nil || pt != "plaintext" {
+		t.Errorf("got %q, %v", pt, err)
+	}
+	cases := []string{"", "abcd", hex.EncodeToString(make([]byte, 16)), "0123456789abcdef0123456789abcdef"}
+	for _, cs := range cases {
+		if _, err := AESDecrypt("key", cs); err == nil {
+			t.Errorf("%q want error", cs)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const defaultPrometheusTimeout = 10 * time.Second
+
+// Prometheus 通过 Prometheus HTTP API 的即时查询获取指标, 查询结果必须是单个序列或标量
+type Prometheus struct {
+	address string
+	client  *http.Client
+}
+
+func NewPrometheus(address string) *Prometheus {
+	return &Prometheus{
+		address: strings.TrimRight(address, "/"),
+		client:  &http.Client{Timeout: defaultPrometheusTimeout},
+	}
+}
+
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string              `json:"resultType"`
+		Result     jsoniter.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type prometheusSample struct {
+	Value []interface{} `json:"value"`
+}
+
+func (p *Prometheus) Query(ctx context.Context, req QueryRequest) (float64, error) {
+	query, err := renderQuery(req)
+	if err != nil {
+		return 0, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.address+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var res prometheusResponse
+	if err = jsoniter.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return 0, fmt.Errorf("decode prometheus response, status %d: %w", resp.StatusCode, err)
+	}
+	if res.Status != "success" {
+		return 0, fmt.Errorf("prometheus query failed: %s: %s", res.ErrorType, res.Error)
+	}
+	var value []interface{}
+	switch res.Data.ResultType {
+	case "scalar":
+		if err = jsoniter.Unmarshal(res.Data.Result, &value); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var samples []prometheusSample
+		if err = jsoniter.Unmarshal(res.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		if len(samples) == 0 {
+			return 0, ErrNoData
+		}
+		if len(samples) > 1 {
+			return 0, fmt.Errorf("%w: %d", ErrMultipleSeries, len(samples))
+		}
+		value = samples[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported prometheus result type %q", res.Data.ResultType)
+	}
+	return parseSampleValue(value)
+}
+
+// parseSampleValue 样本格式为 [时间戳, "值"]
+func parseSampleValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, ErrNoData
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample value %v", value[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func renderQuery(req QueryRequest) (string, error) {
+	tpl, err := template.New("query").Option("missingkey=error").Parse(req.Query)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, req); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrometheusQuery(t *testing.T) {
+	var query string
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	p := NewPrometheus(server.URL + "/")
+	req := QueryRequest{ClusterName: "c1", Query: `avg(cpu{cluster="{{.ClusterName}}"})`}
+
+	body = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000.1,"61.5"]}]}}`
+	value, err := p.Query(context.Background(), req)
+	if err != nil || value != 61.5 {
+		t.Fatalf("vector got %v, %v", value, err)
+	}
+	if query != `avg(cpu{cluster="c1"})` {
+		t.Errorf("query got %q", query)
+	}
+
+	body = `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"3"]}}`
+	if value, err = p.Query(context.Background(), req); err != nil || value != 3 {
+		t.Errorf("scalar got %v, %v", value, err)
+	}
+
+	body = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	if _, err = p.Query(context.Background(), req); !errors.Is(err, ErrNoData) {
+		t.Errorf("empty vector got %v", err)
+	}
+
+	body = `{"status":"success","data":{"resultType":"vector","result":[{"value":[1,"1"]},{"value":[1,"2"]}]}}`
+	if _, err = p.Query(context.Background(), req); !errors.Is(err, ErrMultipleSeries) {
+		t.Errorf("multiple series got %v", err)
+	}
+
+	body = `{"status":"error","errorType":"bad_data","error":"parse error"}`
+	if _, err = p.Query(context.Background(), req); err == nil {
+		t.Error("want error for failed query")
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// SourcePrometheus 通过 PromQL 查询指标
+	SourcePrometheus = "prometheus"
+	// SourcePush 业务通过接口主动上报的指标
+	SourcePush = "push"
+)
+
+var (
+	ErrNoData         = errors.New("no metric data")
+	ErrSourceNotFound = errors.New("metric source not found")
+	ErrMultipleSeries = errors.New("query returned multiple series")
+	sources           = make(map[string]Source)
+	sourcesMu         sync.RWMutex
+)
+
+// QueryRequest Query 为数据源相关的查询语句, 可以引用 {{.ClusterName}} 和 {{.MetricName}}
+type QueryRequest struct {
+	ClusterName string
+	MetricName  string
+	Query       string
+}
+
+// Source 指标数据源, 返回集群当前的一个指标值
+type Source interface {
+	Query(ctx context.Context, req QueryRequest) (float64, error)
+}
+
+// RegisterSource 注册数据源, 同名数据源后注册的覆盖先注册的
+func RegisterSource(name string, source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[name] = source
+}
+
+func GetSource(name string) (Source, error) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	source, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSourceNotFound, name)
+	}
+	return source, nil
+}
+
+func SourceNames() []string {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
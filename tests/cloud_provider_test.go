@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/cloud/fake"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderDriverResolution(t *testing.T) {
+	var created int32
+	const name = "CountingFake"
+	cloud.RegisterProviderDriver(name, func(ak, sk, regionId string) (cloud.Provider, error) {
+		atomic.AddInt32(&created, 1)
+		return fake.New(ak, sk, regionId), nil
+	}, fake.DefaultRegion)
+	t.Cleanup(fake.Reset)
+
+	ak := fmt.Sprintf("driver-ak-%d", id_generator.GetNextId())
+	account := &model.Account{AccountName: ak, AccountKey: ak, AccountSecret: "sk", Provider: name}
+	assert.Nil(t, model.Create(account))
+	c := &types.ClusterInfo{Name: "driver-cluster", Provider: name, AccountKey: ak, RegionId: fake.DefaultRegion}
+
+	//通过注册的 driver 创建客户端, 之后使用缓存
+	for i := 0; i < 2; i++ {
+		_, err := service.GetInstances(c, []string{"i-not-exist"})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+
+	//删除账号后清理该 ak 的客户端, 不能再使用缓存访问云厂商
+	assert.Nil(t, service.DeleteCloudAccount(context.Background(), []int64{account.Id}, account.OrgId))
+	_, err := service.GetInstances(c, []string{"i-not-exist"})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+}
@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/cloud/fake"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/stretchr/testify/assert"
+)
+
+//newFakeCluster 使用 fake 云厂商的集群, 子网只有 13 个可用 IP
+func newFakeCluster(t *testing.T) (*types.ClusterInfo, *fake.FakeCloud) {
+	ak := fmt.Sprintf("fake-ak-%d", id_generator.GetNextId())
+	account := &model.Account{AccountName: ak, AccountKey: ak, AccountSecret: "sk", Provider: fake.CloudName}
+	if err := model.Create(account); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Reset)
+	p := fake.New(ak, "sk", fake.DefaultRegion)
+	p.SetPendingDuration(0)
+	vpcRes, _ := p.CreateVPC(cloud.CreateVpcRequest{RegionId: fake.DefaultRegion, CidrBlock: "10.0.0.0/16"})
+	swRes, _ := p.CreateSwitch(cloud.CreateSwitchRequest{RegionId: fake.DefaultRegion, VpcId: vpcRes.VpcId, CidrBlock: "10.0.1.0/28"})
+	c := &types.ClusterInfo{
+		Name:         "fake-cluster-" + ak,
+		RegionId:     fake.DefaultRegion,
+		ZoneId:       "fake-zone-a",
+		InstanceType: "fake.small",
+		Image:        "fake-image",
+		Provider:     fake.CloudName,
+		AccountKey:   ak,
+		NetworkConfig: &types.NetworkConfig{
+			Vpc:      vpcRes.VpcId,
+			SubnetId: swRes.SwitchId,
+		},
+	}
+	return c, p
+}
+
+func TestFakeClusterExpandAndShrink(t *testing.T) {
+	c, _ := newFakeCluster(t)
+	//每轮扩容 5 台再全部释放, 释放的 IP 需要被后续扩容复用
+	for i := 0; i < 10; i++ {
+		taskId := int64(id_generator.GetNextId())
+		instances, err := service.ExpandCluster(context.Background(), c, 5, taskId)
+		assert.Nil(t, err, "round %d", i)
+		assert.Len(t, instances, 5, "round %d", i)
+		ids := make([]string, 0, len(instances))
+		ips := make(map[string]bool, len(instances))
+		for _, instance := range instances {
+			assert.NotEmpty(t, instance.IpInner, "round %d", i)
+			ips[instance.IpInner] = true
+			ids = append(ids, instance.Id)
+		}
+		assert.Len(t, ips, 5, "round %d", i)
+		assert.Nil(t, service.Shrink(c, ids))
+		now := time.Now()
+		assert.Nil(t, model.BatchUpdateByInstanceIds(ids, model.Instance{Status: constants.Deleted, DeleteAt: &now}))
+	}
+}
+
+func TestFakeClusterRepair(t *testing.T) {
+	c, p := newFakeCluster(t)
+	taskId := int64(id_generator.GetNextId())
+	//BatchCreate 创建了实例但没有返回 id, RepairCluster 按任务标签释放这些实例
+	p.SetDropCreatedIds(true)
+	instances, err := service.ExpandAndRepair(context.Background(), c, 3, taskId)
+	assert.NotNil(t, err)
+	assert.Empty(t, instances)
+	left, _ := p.GetInstancesByTags(fake.DefaultRegion, []cloud.Tag{{Key: cloud.TaskId, Value: strconv.FormatInt(taskId, 10)}})
+	assert.Empty(t, left)
+}
+
+func TestFakeClusterCleanUnusedInstances(t *testing.T) {
+	c, p := newFakeCluster(t)
+	//不在 BridgX 中的集群实例被清理
+	ids, err := p.BatchCreate(cloud.Params{
+		Region:  fake.DefaultRegion,
+		Network: &cloud.Network{SubnetId: c.NetworkConfig.SubnetId},
+		Tags:    []cloud.Tag{{Key: cloud.ClusterName, Value: c.Name}},
+	}, 2)
+	assert.Nil(t, err)
+	assert.Len(t, ids, 2)
+	cleaned, err := service.CleanClusterUnusedInstances(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cleaned)
+	left, _ := p.GetInstancesByCluster(fake.DefaultRegion, c.Name)
+	assert.Empty(t, left)
+}
+
+func TestFakeClusterCancelledExpand(t *testing.T) {
+	c, p := newFakeCluster(t)
+	taskId := int64(id_generator.GetNextId())
+	params := cloud.Params{
+		Region:  fake.DefaultRegion,
+		Network: &cloud.Network{SubnetId: c.NetworkConfig.SubnetId},
+		Tags: []cloud.Tag{
+			{Key: cloud.ClusterName, Value: c.Name},
+			{Key: cloud.TaskId, Value: strconv.FormatInt(taskId, 10)},
+		},
+	}
+	//recorded 是之前批次已经记录到 DB 的实例, leaked 是创建后没有记录的实例
+	recorded, err := p.BatchCreate(params, 1)
+	assert.Nil(t, err)
+	leaked, err := p.BatchCreate(params, 1)
+	assert.Nil(t, err)
+	now := time.Now()
+	assert.Nil(t, model.BatchCreateInstance([]model.Instance{{
+		InstanceId:  recorded[0],
+		ClusterName: c.Name,
+		TaskId:      taskId,
+		Status:      constants.Running,
+		CreateAt:    &now,
+	}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service.ExpandCluster(ctx, c, 1, taskId)
+	assert.Equal(t, context.Canceled, err)
+
+	left, _ := p.GetInstancesByTags(fake.DefaultRegion, []cloud.Tag{{Key: cloud.TaskId, Value: strconv.FormatInt(taskId, 10)}})
+	leftIds := make([]string, 0, len(left))
+	for _, instance := range left {
+		leftIds = append(leftIds, instance.Id)
+	}
+	assert.Equal(t, recorded, leftIds)
+	assert.NotContains(t, leftIds, leaked[0])
+}
+
+func TestFakeClusterShrinkProgress(t *testing.T) {
+	c, _ := newFakeCluster(t)
+	instances, err := service.ExpandCluster(context.Background(), c, 3, int64(id_generator.GetNextId()))
+	assert.Nil(t, err)
+	assert.Len(t, instances, 3)
+	now := time.Now()
+	task := &model.Task{TaskName: "shrink_progress_test", TaskAction: constants.TaskActionShrink, Status: constants.TaskStatusRunning, TaskFilter: c.Name}
+	task.Id = int64(id_generator.GetNextId())
+	task.CreateAt = &now
+	task.UpdateAt = &now
+	assert.Nil(t, model.Create(task))
+	//缩容释放的实例计入 released_count, 不计入扩容使用的 created_count
+	res, err := service.ShrinkCluster(context.Background(), c, 2, task.Id)
+	assert.Nil(t, err)
+	assert.Len(t, res.InstanceIdList, 2)
+	current := &model.Task{}
+	assert.Nil(t, model.Get(task.Id, current))
+	assert.Equal(t, 2, current.ReleasedCount)
+	assert.Equal(t, 0, current.CreatedCount)
+}
+
+func TestFakeClusterDrainRestoresStatus(t *testing.T) {
+	c, _ := newFakeCluster(t)
+	instances, err := service.ExpandCluster(context.Background(), c, 2, int64(id_generator.GetNextId()))
+	assert.Nil(t, err)
+	assert.Len(t, instances, 2)
+	assert.Nil(t, model.BatchUpdateByInstanceIds([]string{instances[0].Id}, model.Instance{Status: constants.Starting}))
+	//pre_terminate 钩子超时放弃释放时, 实例恢复为摘除前的状态而不是都变为 RUNNING
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c.LifecycleConfig = &types.LifecycleConfig{PreTerminate: &types.LifecycleHook{
+		WebhookUrl:     server.URL,
+		TimeoutSeconds: 1,
+		DefaultResult:  constants.LifecycleResultAbandon,
+	}}
+	ips := instances[0].IpInner + "," + instances[1].IpInner
+	_, err = service.ShrinkClusterBySpecificIps(context.Background(), c, ips, 2, int64(id_generator.GetNextId()))
+	assert.ErrorIs(t, err, service.ErrLifecycleAbandoned)
+	statuses, err := model.GetInstanceStatuses(context.Background(), []string{instances[0].Id, instances[1].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, constants.Starting, statuses[instances[0].Id])
+	assert.Equal(t, constants.Running, statuses[instances[1].Id])
+}
+
+func TestFakeClusterRollingUpdateKeepsEarlierBatches(t *testing.T) {
+	c, p := newFakeCluster(t)
+	_, err := service.ExpandCluster(context.Background(), c, 4, int64(id_generator.GetNextId()))
+	assert.Nil(t, err)
+	outdated, err := model.GetActiveInstancesByClusterName(c.Name)
+	assert.Nil(t, err)
+	assert.Len(t, outdated, 4)
+
+	//第 2 批摘除第一台旧实例时占满子网只留 1 个 IP, 第 2 批只能扩容 1 台
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls != 3 {
+			return
+		}
+		fillers, _ := p.BatchCreate(cloud.Params{Region: fake.DefaultRegion, Network: &cloud.Network{SubnetId: c.NetworkConfig.SubnetId}}, 13)
+		if len(fillers) > 0 {
+			_ = p.BatchDelete(fillers[:1], fake.DefaultRegion)
+		}
+	}))
+	defer server.Close()
+	c.LifecycleConfig = &types.LifecycleConfig{PreTerminate: &types.LifecycleHook{WebhookUrl: server.URL}}
+
+	now := time.Now()
+	task := &model.Task{TaskName: "rolling_update_test", TaskAction: constants.TaskActionRollingUpdate, Status: constants.TaskStatusRunning, TaskFilter: c.Name}
+	task.Id = int64(id_generator.GetNextId())
+	task.CreateAt = &now
+	task.UpdateAt = &now
+	assert.Nil(t, model.Create(task))
+	info := &model.RollingUpdateTaskInfo{ClusterName: c.Name, BatchSize: 2, MaxSurge: 1, MaxUnavailable: 1}
+	res, err := service.RollingUpdateCluster(context.Background(), c, info, outdated, task.Id)
+	assert.NotNil(t, err)
+	assert.Len(t, res.NewInstanceIdList, 2)
+
+	//第 2 批回滚时不能释放第 1 批已经在提供服务的新实例
+	kept, err := p.GetInstances(res.NewInstanceIdList)
+	assert.Nil(t, err)
+	assert.Len(t, kept, 2)
+	statuses, err := model.GetInstanceStatuses(context.Background(), res.NewInstanceIdList)
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 2)
+}
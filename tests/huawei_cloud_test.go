@@ -7,7 +7,11 @@ import (
 )
 
 func TestGetHuaweiCloudClient(t *testing.T) {
-	p := huawei.New("", "", "cn-north-4")
-	_, err := p.GetInstances(make([]string, 0))
+	p, err := huawei.New("", "", "cn-north-4")
+	if err != nil {
+		t.Log(err)
+		return
+	}
+	_, err = p.GetInstances(make([]string, 0))
 	t.Logf("err:%v\n", err)
 }
@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledActionNotFound(t *testing.T) {
+	ctx := context.Background()
+	missing := int64(id_generator.GetNextId())
+	assert.ErrorIs(t, service.SetScheduledActionStatus(ctx, missing, constants.ScheduledActionStatusPaused, "test"), service.ErrScheduledActionNotFound)
+	assert.ErrorIs(t, service.DeleteScheduledAction(ctx, missing), service.ErrScheduledActionNotFound)
+
+	action := &model.ScheduledAction{ClusterName: "TEST_CLUSTER", ActionName: "scheduled_action_test", Cron: "0 8 * * *", Status: constants.ScheduledActionStatusEnable}
+	assert.Nil(t, model.Create(action))
+	//重复暂停同一个定时任务不会返回不存在
+	assert.Nil(t, service.SetScheduledActionStatus(ctx, action.Id, constants.ScheduledActionStatusPaused, "test"))
+	assert.Nil(t, service.SetScheduledActionStatus(ctx, action.Id, constants.ScheduledActionStatusPaused, "test"))
+	assert.Nil(t, service.DeleteScheduledAction(ctx, action.Id))
+	assert.ErrorIs(t, service.DeleteScheduledAction(ctx, action.Id), service.ErrScheduledActionNotFound)
+}
@@ -1,11 +1,16 @@
 package tests
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/pool"
+	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/galaxy-future/BridgX/pkg/id_generator"
 	"github.com/galaxy-future/BridgX/pkg/utils"
 	jsoniter "github.com/json-iterator/go"
@@ -40,3 +45,117 @@ func TestTaskExpand(t *testing.T) {
 	task.UpdateAt = &now
 	pool.DoExpand(task)
 }
+
+func TestRetryTaskOnce(t *testing.T) {
+	task := &model.Task{
+		TaskName:   "retry_once_test",
+		TaskAction: constants.TaskActionExpand,
+		Status:     constants.TaskStatusFailed,
+		TaskFilter: "TEST_CLUSTER",
+		TaskInfo:   `{"cluster_name":"TEST_CLUSTER","count":2}`,
+	}
+	now := time.Now()
+	task.Id = int64(id_generator.GetNextId())
+	task.CreateAt = &now
+	task.UpdateAt = &now
+	if err := model.Create(task); err != nil {
+		t.Fatal(err)
+	}
+	var created int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.RetryTask(context.Background(), task.Id, 0); err == nil {
+				atomic.AddInt32(&created, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Errorf("concurrent retries created %d tasks, want 1", created)
+	}
+	children, _ := model.GetRetryTasks(context.Background(), task.Id)
+	if len(children) != 1 {
+		t.Errorf("got %d retry tasks, want 1", len(children))
+	}
+}
+
+func TestCancelTaskTransitions(t *testing.T) {
+	cases := []struct {
+		status        string
+		supportCancel bool
+		want          string
+	}{
+		{constants.TaskStatusQueued, true, constants.TaskStatusCancelled},
+		{constants.TaskStatusInit, true, constants.TaskStatusCancelled},
+		{constants.TaskStatusRunning, true, constants.TaskStatusCancelling},
+		{constants.TaskStatusPaused, true, constants.TaskStatusCancelling},
+		{constants.TaskStatusCancelling, true, ""},
+		{constants.TaskStatusCancelled, true, ""},
+		{constants.TaskStatusSuccess, true, ""},
+		{constants.TaskStatusFailed, true, ""},
+		{constants.TaskStatusPartialSuccess, true, ""},
+		{constants.TaskStatusCoalesced, true, ""},
+		{constants.TaskStatusQueued, false, ""},
+		{constants.TaskStatusRunning, false, ""},
+	}
+	for _, cs := range cases {
+		now := time.Now()
+		task := &model.Task{
+			TaskName:      "cancel_test",
+			TaskAction:    constants.TaskActionExpand,
+			Status:        cs.status,
+			SupportCancel: cs.supportCancel,
+			TaskFilter:    "TEST_CLUSTER",
+			TaskInfo:      `{"cluster_name":"TEST_CLUSTER","count":2}`,
+		}
+		task.Id = int64(id_generator.GetNextId())
+		task.CreateAt = &now
+		task.UpdateAt = &now
+		if err := model.Create(task); err != nil {
+			t.Fatal(err)
+		}
+		got, err := service.CancelTask(context.Background(), task.Id)
+		persisted := &model.Task{}
+		_ = model.Get(task.Id, persisted)
+		if cs.want == "" {
+			if err != service.ErrTaskNotCancellable {
+				t.Errorf("cancel %s (support %v) got err %v, want ErrTaskNotCancellable", cs.status, cs.supportCancel, err)
+			}
+			if persisted.Status != cs.status {
+				t.Errorf("cancel %s (support %v) changed status to %s", cs.status, cs.supportCancel, persisted.Status)
+			}
+			continue
+		}
+		if err != nil || got != cs.want || persisted.Status != cs.want {
+			t.Errorf("cancel %s got %s persisted %s err %v, want %s", cs.status, got, persisted.Status, err, cs.want)
+		}
+		if cs.want == constants.TaskStatusCancelled && persisted.FinishTime == nil {
+			t.Errorf("cancel %s did not set finish_time", cs.status)
+		}
+	}
+}
+
+func TestCreateTaskWithExpectCount(t *testing.T) {
+	ctx := context.Background()
+	cluster := &model.Cluster{ClusterName: fmt.Sprintf("expect_count_test_%d", id_generator.GetNextId()), ExpectCount: 3, Declarative: true}
+	if err := model.Create(cluster); err != nil {
+		t.Fatal(err)
+	}
+	expectCount := func() int {
+		c, err := model.GetByClusterName(cluster.ClusterName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.ExpectCount
+	}
+	//声明式集群创建任务时在同一事务中调整期望数量, 缩容时不小于 0
+	if _, err := service.CreateExpandTaskWithExpectCount(ctx, cluster.ClusterName, 2, "expect_count_test", 0); err != nil || expectCount() != 5 {
+		t.Errorf("expand got expect count %d err %v, want 5", expectCount(), err)
+	}
+	if _, err := service.CreateShrinkTaskWithExpectCount(ctx, cluster.ClusterName, 10, "", "expect_count_test", 0); err != nil || expectCount() != 0 {
+		t.Errorf("shrink got expect count %d err %v, want 0", expectCount(), err)
+	}
+}